@@ -0,0 +1,273 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// results of a record
+const (
+	ResultOK        = "ok"
+	ResultFailed    = "failed"
+	ResultCancelled = "cancelled"
+)
+
+// Record is one mutating operation, it is stored as one json line
+type Record struct {
+	Time      time.Time         `json:"time"`
+	User      string            `json:"user"`
+	Profile   string            `json:"profile"`
+	Operation string            `json:"operation"`
+	Targets   []string          `json:"targets,omitempty"`
+	Params    map[string]string `json:"params,omitempty"`
+	Result    string            `json:"result"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// Logger appends records to the audit file, it is safe for concurrent use.
+// The methods of a nil logger do nothing, liftoff works without the audit log
+type Logger struct {
+	mu      sync.Mutex
+	path    string
+	user    string
+	profile string
+}
+
+// Log is the logger every mutating operation reports to, nil until Open succeeded
+var Log *Logger
+
+// Path is the audit file next to the config file
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "liftoff", "audit.jsonl"), nil
+}
+
+// Open makes sure the audit file can be written, the user is the login name and the hostname
+func Open(path string) (*Logger, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	file.Close()
+	name := "unknown"
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		name += "@" + hostname
+	}
+	return &Logger{path: path, user: name}, nil
+}
+
+// SetProfile sets the profile of the following records
+func (l *Logger) SetProfile(profile string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.profile = profile
+}
+
+// Write appends the record, time, user and profile are filled in when empty
+func (l *Logger) Write(record Record) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+	if record.User == "" {
+		record.User = l.user
+	}
+	if record.Profile == "" {
+		record.Profile = l.profile
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// Records returns the records matching the filter, the newest first
+func (l *Logger) Records(filter Filter) ([]Record, error) {
+	if l == nil {
+		return nil, errors.New("audit log is not available")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	file, err := os.Open(l.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record Record
+		// a broken line, e.g. after a crash while writing, is skipped
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		if filter.Match(record) {
+			records = append(records, record)
+		}
+	}
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	return records, scanner.Err()
+}
+
+// Export writes the records as json lines in the order they happened
+func Export(w io.Writer, records []Record) error {
+	encoder := json.NewEncoder(w)
+	for i := len(records) - 1; i >= 0; i-- {
+		if err := encoder.Encode(records[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Operation writes the record of a finished operation, a failing audit file only ends up in the log
+func (l *Logger) Operation(operation string, targets []string, params map[string]string, err error) {
+	if l == nil {
+		return
+	}
+	record := Record{Operation: operation, Targets: targets, Params: params, Result: ResultOK}
+	if err != nil {
+		record.Result = ResultFailed
+		record.Error = err.Error()
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		record.Result = ResultCancelled
+	}
+	if err := l.Write(record); err != nil {
+		log.Println("could not write the audit log", err)
+	}
+}
+
+// Filter selects records, empty fields match everything
+type Filter struct {
+	Operation string
+	Profile   string
+	User      string
+	Target    string
+	Result    string
+	Since     time.Time
+	// matched against operation, user, profile, targets and params
+	Text string
+}
+
+// ParseFilter reads space separated key=value pairs (op, profile, user, target, result, since) and free text,
+// since takes a duration like 24h
+func ParseFilter(input string) (Filter, error) {
+	var filter Filter
+	var text []string
+	for _, field := range strings.Fields(input) {
+		key, value, found := strings.Cut(field, "=")
+		if !found {
+			text = append(text, field)
+			continue
+		}
+		switch key {
+		case "op", "operation":
+			filter.Operation = value
+		case "profile":
+			filter.Profile = value
+		case "user":
+			filter.User = value
+		case "target":
+			filter.Target = value
+		case "result":
+			filter.Result = value
+		case "since":
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return Filter{}, fmt.Errorf("since needs a duration like 24h: %w", err)
+			}
+			filter.Since = time.Now().Add(-duration)
+		default:
+			return Filter{}, fmt.Errorf("unknown filter %s, use op, profile, user, target, result or since", key)
+		}
+	}
+	filter.Text = strings.Join(text, " ")
+	return filter, nil
+}
+
+// Match reports whether the record passes the filter, operation matches as prefix so server matches server.delete
+func (f Filter) Match(record Record) bool {
+	if f.Operation != "" && !strings.HasPrefix(record.Operation, f.Operation) {
+		return false
+	}
+	if f.Profile != "" && record.Profile != f.Profile {
+		return false
+	}
+	if f.User != "" && !strings.HasPrefix(record.User, f.User) {
+		return false
+	}
+	if f.Result != "" && record.Result != f.Result {
+		return false
+	}
+	if !f.Since.IsZero() && record.Time.Before(f.Since) {
+		return false
+	}
+	if f.Target != "" && !contains(record.Targets, f.Target) {
+		return false
+	}
+	if f.Text != "" && !strings.Contains(recordText(record), f.Text) {
+		return false
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func recordText(record Record) string {
+	parts := []string{record.Operation, record.User, record.Profile, record.Error}
+	parts = append(parts, record.Targets...)
+	for key, value := range record.Params {
+		parts = append(parts, key+"="+value)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Filter
+		wantErr bool
+	}{
+		{"empty", "", Filter{}, false},
+		{"keys", "op=server profile=prod user=ana target=42 result=failed", Filter{Operation: "server", Profile: "prod", User: "ana", Target: "42", Result: "failed"}, false},
+		{"operation alias", "operation=volume.resize", Filter{Operation: "volume.resize"}, false},
+		{"free text", "web 1", Filter{Text: "web 1"}, false},
+		{"keys and free text", "op=server web", Filter{Operation: "server", Text: "web"}, false},
+		{"since without duration", "since=yesterday", Filter{}, true},
+		{"unknown key", "server=web", Filter{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFilter(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFilter(%q) err = %v, want error %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFilter(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilterSince(t *testing.T) {
+	before := time.Now()
+	filter, err := ParseFilter("since=24h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := time.Now()
+	if filter.Since.Before(before.Add(-24*time.Hour)) || filter.Since.After(after.Add(-24*time.Hour)) {
+		t.Errorf("since = %s, want 24 hours ago", filter.Since)
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	now := time.Now()
+	record := Record{
+		Time:      now.Add(-2 * time.Hour),
+		User:      "ana@laptop",
+		Profile:   "prod",
+		Operation: "server.delete",
+		Targets:   []string{"42", "43"},
+		Params:    map[string]string{"name": "web-1"},
+		Result:    ResultFailed,
+		Error:     "server is protected",
+	}
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"empty filter", Filter{}, true},
+		{"operation", Filter{Operation: "server.delete"}, true},
+		{"operation prefix", Filter{Operation: "server"}, true},
+		{"other operation", Filter{Operation: "volume"}, false},
+		{"operation is no suffix match", Filter{Operation: "delete"}, false},
+		{"profile", Filter{Profile: "prod"}, true},
+		{"profile is no prefix match", Filter{Profile: "pro"}, false},
+		{"user prefix", Filter{User: "ana"}, true},
+		{"other user", Filter{User: "bob"}, false},
+		{"result", Filter{Result: ResultFailed}, true},
+		{"other result", Filter{Result: ResultOK}, false},
+		{"since before the record", Filter{Since: now.Add(-3 * time.Hour)}, true},
+		{"since after the record", Filter{Since: now.Add(-time.Hour)}, false},
+		{"one of the targets", Filter{Target: "43"}, true},
+		{"target is no substring match", Filter{Target: "4"}, false},
+		{"text in params", Filter{Text: "name=web-1"}, true},
+		{"text in a param value", Filter{Text: "web"}, true},
+		{"text in the error", Filter{Text: "protected"}, true},
+		{"text in a target", Filter{Text: "43"}, true},
+		{"text not found", Filter{Text: "db"}, false},
+		{"all keys have to match", Filter{Operation: "server", Result: ResultOK}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(record); got != tt.want {
+				t.Errorf("Match(%+v) = %v, want %v", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
@@ -13,11 +13,11 @@ const (
 )
 
 type Config struct {
-	Users          []User   `yaml:"users"`
-	Packages       []string `yaml:"packages"`
-	PackageUpdate  bool     `yaml:"package_update"`
-	PackageUpgrade bool     `yaml:"package_upgrade"`
-	RunCmd         []string `yaml:"runcmd"`
+	Users          []User   `yaml:"users,omitempty"`
+	Packages       []string `yaml:"packages,omitempty"`
+	PackageUpdate  bool     `yaml:"package_update,omitempty"`
+	PackageUpgrade bool     `yaml:"package_upgrade,omitempty"`
+	RunCmd         []string `yaml:"runcmd,omitempty"`
 }
 
 type User struct {
@@ -62,3 +62,38 @@ func GetConfig(configType string, ssh_key string) (string, error) {
 	}
 	return string(stringConf), nil
 }
+
+// UserData renders the config as hetzner user data, cloud-init only accepts it with the header
+func UserData(conf Config) (string, error) {
+	stringConf, err := yaml.Marshal(&conf)
+	if err != nil {
+		return "", err
+	}
+	return "#cloud-config\n" + string(stringConf), nil
+}
+
+// MountVolumeCommands mounts a hetzner volume to mountPath and adds it to fstab so it survives reboots
+func MountVolumeCommands(volumeID int64, mountPath string, format string) []string {
+	if format == "" {
+		format = "auto"
+	}
+	device := fmt.Sprintf("/dev/disk/by-id/scsi-0HC_Volume_%d", volumeID)
+	return []string{
+		fmt.Sprintf("mkdir -p %s", mountPath),
+		fmt.Sprintf("mount -o discard,defaults %s %s", device, mountPath),
+		fmt.Sprintf("echo '%s %s %s discard,nofail,defaults 0 0' >> /etc/fstab", device, mountPath, format),
+	}
+}
+
+// Load reads a cloud-config file, only the keys of Config are kept
+func Load(path string) (Config, error) {
+	var conf Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return conf, err
+	}
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		return conf, fmt.Errorf("invalid cloud-config %s: %w", path, err)
+	}
+	return conf, nil
+}
@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/crabstars/liftoff/hetzner"
+	"github.com/urfave/cli/v2"
+)
+
+func actionCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "action",
+		Usage: "follow hetzner actions",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "wait",
+				Usage:     "wait until the actions are done, fails if one of them failed",
+				ArgsUsage: "<action id>...",
+				Flags: []cli.Flag{
+					&cli.DurationFlag{Name: "timeout", Usage: "give up after this duration", Value: hetzner.ActionTimeout},
+				},
+				Action: waitForActions,
+			},
+		},
+	}
+}
+
+func waitForActions(c *cli.Context) error {
+	apiKey, err := token(c)
+	if err != nil {
+		return err
+	}
+	if c.NArg() == 0 {
+		return errors.New("missing action id")
+	}
+	ctx, cancel := context.WithTimeout(c.Context, c.Duration("timeout"))
+	defer cancel()
+	for _, arg := range c.Args().Slice() {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid action id %s", arg)
+		}
+		err = hetzner.WaitForAction(ctx, apiKey, id, func(progress int) {
+			fmt.Fprintf(c.App.ErrWriter, "action %d: %d%%\n", id, progress)
+		})
+		if err != nil {
+			return fmt.Errorf("action %d: %w", id, err)
+		}
+		fmt.Fprintf(c.App.Writer, "action %d done\n", id)
+	}
+	return nil
+}
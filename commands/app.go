@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/crabstars/liftoff/audit"
+	"github.com/crabstars/liftoff/config"
+	"github.com/crabstars/liftoff/logging"
+	sshconnector "github.com/crabstars/liftoff/ssh"
+	"github.com/urfave/cli/v2"
+)
+
+// keys of the resolved settings in the app metadata
+const (
+	metaConfig      = "config"
+	metaProfileName = "profile-name"
+	metaProfile     = "profile"
+)
+
+// NewApp returns the cli, without a subcommand runTUI starts the interactive ui with the resolved profile
+func NewApp(runTUI func(ctx context.Context, conf config.Config, profileName string, profile config.Profile) error) *cli.App {
+	return &cli.App{
+		Name:  "liftoff",
+		Usage: "create hetzner servers and deploy apps on them",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "profile", Aliases: []string{"p"}, Usage: "profile of the config file, overrides current_profile", EnvVars: []string{config.EnvProfile}},
+			&cli.StringFlag{Name: "token", Usage: "hetzner cloud api token, overrides the profile"},
+			&cli.StringFlag{Name: "ssh-key", Usage: "name of the hetzner ssh key added to new servers, overrides the profile"},
+			&cli.BoolFlag{Name: "verbose", Usage: "print the log to stderr"},
+		},
+		Before: func(c *cli.Context) error {
+			// the tui writes the log into debug.log, the cli keeps it out of the output
+			if c.Args().Present() && !c.Bool("verbose") && os.Getenv("DEBUG") == "" {
+				log.SetOutput(io.Discard)
+			}
+			conf, err := config.Load()
+			if err != nil {
+				return err
+			}
+			if c.IsSet("token") {
+				logging.Redact(c.String("token"))
+			}
+			name, profile, err := conf.Resolve(c.String("profile"))
+			// login replaces a token which can not be read anymore
+			if err != nil && c.Args().First() != "auth" {
+				return err
+			}
+			if c.IsSet("token") {
+				profile.Token = c.String("token")
+			}
+			logging.Redact(profile.Token)
+			if c.IsSet("ssh-key") {
+				profile.SSHKeyName = c.String("ssh-key")
+			}
+			sshconnector.KeyPath = profile.SSHKeyPath
+			audit.Log.SetProfile(name)
+			c.App.Metadata = map[string]interface{}{metaConfig: conf, metaProfileName: name, metaProfile: profile}
+			return nil
+		},
+		Action: func(c *cli.Context) error {
+			// a typo in a command must fail scripts instead of starting nothing
+			if c.Args().Present() {
+				if err := cli.ShowAppHelp(c); err != nil {
+					return err
+				}
+				return fmt.Errorf("unknown command %q", c.Args().First())
+			}
+			return runTUI(c.Context, c.App.Metadata[metaConfig].(config.Config), c.App.Metadata[metaProfileName].(string), activeProfile(c))
+		},
+		Commands: []*cli.Command{
+			authCommand(),
+			serverCommand(),
+			sshKeyCommand(),
+			inventoryCommand(),
+			auditCommand(),
+			costCommand(),
+			deployCommand(),
+			actionCommand(),
+		},
+	}
+}
+
+// activeProfile returns the profile resolved in Before with the flag overrides
+func activeProfile(c *cli.Context) config.Profile {
+	return c.App.Metadata[metaProfile].(config.Profile)
+}
+
+// token returns the api token of the active profile
+func token(c *cli.Context) (string, error) {
+	token := activeProfile(c).Token
+	if token == "" {
+		return "", errors.New("no api token, add it to the profile, set HETZNER_CLOUD_API_KEY or use --token")
+	}
+	return token, nil
+}
+
+// serverArg returns the first argument, a server name or id
+func serverArg(c *cli.Context) (string, error) {
+	if c.NArg() < 1 {
+		return "", errors.New("missing server name or id")
+	}
+	return c.Args().First(), nil
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/crabstars/liftoff/audit"
+	"github.com/urfave/cli/v2"
+)
+
+func auditCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "audit",
+		Usage:     "show the audit log of create, delete, reboot, resize and deploy operations",
+		ArgsUsage: "[filter, e.g. op=server.delete since=24h]",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "op", Usage: "operation or its prefix, e.g. server or volume.resize"},
+			&cli.StringFlag{Name: "of-profile", Usage: "profile the operation ran with"},
+			&cli.StringFlag{Name: "target", Usage: "id of a target"},
+			&cli.StringFlag{Name: "result", Usage: "ok, failed or cancelled"},
+			&cli.DurationFlag{Name: "since", Usage: "only operations of the last duration, e.g. 24h"},
+			&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Value: outputTable, Usage: "table or json, json writes json lines in the order the operations happened"},
+		},
+		Action: listAudit,
+	}
+}
+
+func listAudit(c *cli.Context) error {
+	filter, err := audit.ParseFilter(strings.Join(c.Args().Slice(), " "))
+	if err != nil {
+		return err
+	}
+	if c.IsSet("op") {
+		filter.Operation = c.String("op")
+	}
+	// --profile selects the config profile of liftoff itself
+	if c.IsSet("of-profile") {
+		filter.Profile = c.String("of-profile")
+	}
+	if c.IsSet("target") {
+		filter.Target = c.String("target")
+	}
+	if c.IsSet("result") {
+		filter.Result = c.String("result")
+	}
+	if c.IsSet("since") {
+		filter.Since = time.Now().Add(-c.Duration("since"))
+	}
+	records, err := audit.Log.Records(filter)
+	if err != nil {
+		return err
+	}
+	switch c.String("output") {
+	case outputJSON:
+		return audit.Export(c.App.Writer, records)
+	case outputTable:
+		tw := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "TIME\tUSER\tPROFILE\tOPERATION\tTARGETS\tRESULT\tERROR")
+		for _, record := range records {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", record.Time.Local().Format(time.DateTime), record.User, record.Profile,
+				record.Operation, strings.Join(record.Targets, ","), record.Result, record.Error)
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("unknown output %s, use table or json", c.String("output"))
+}
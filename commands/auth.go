@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/crabstars/liftoff/config"
+	"github.com/crabstars/liftoff/hetzner"
+	"github.com/crabstars/liftoff/logging"
+	"github.com/crabstars/liftoff/secrets"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
+)
+
+func authCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "auth",
+		Usage: "store the api token of the profile in the keyring or an encrypted file",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "login",
+				Usage: "validate the token against the api and store it",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "store", Usage: "keyring or file, without it the keyring is used when there is one"},
+					&cli.BoolFlag{Name: "token-stdin", Usage: "read the token from stdin instead of the prompt"},
+				},
+				Action: login,
+			},
+			{
+				Name:   "logout",
+				Usage:  "remove the stored token of the profile",
+				Action: logout,
+			},
+		},
+	}
+}
+
+func login(c *cli.Context) error {
+	token, err := readToken(c)
+	if err != nil {
+		return err
+	}
+	logging.Redact(token)
+	if err := hetzner.ValidateToken(c.Context, token); err != nil {
+		return err
+	}
+
+	name := c.App.Metadata[metaProfileName].(string)
+	store := c.String("store")
+	if store == "" {
+		store, err = secrets.Store(name, token)
+	} else {
+		err = secrets.Save(store, name, token)
+	}
+	if err != nil {
+		return err
+	}
+
+	conf := c.App.Metadata[metaConfig].(config.Config)
+	if conf.Profiles == nil {
+		conf.Profiles = make(map[string]config.Profile)
+	}
+	profile := conf.Profiles[name]
+	// a plain token of an old config is moved, not kept next to the stored one
+	migrated := profile.Token != ""
+	profile.Token = ""
+	profile.TokenStore = store
+	conf.Profiles[name] = profile
+	if err := config.Save(conf); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.App.Writer, "token of profile %s stored in the %s\n", name, store)
+	if migrated {
+		fmt.Fprintln(c.App.Writer, "plain token removed from the config file")
+	}
+	return nil
+}
+
+func logout(c *cli.Context) error {
+	name := c.App.Metadata[metaProfileName].(string)
+	conf := c.App.Metadata[metaConfig].(config.Config)
+	profile, ok := conf.Profiles[name]
+	if !ok || profile.TokenStore == "" {
+		return fmt.Errorf("profile %s has no stored token", name)
+	}
+	if err := secrets.Delete(profile.TokenStore, name); err != nil {
+		return err
+	}
+	profile.TokenStore = ""
+	conf.Profiles[name] = profile
+	if err := config.Save(conf); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.App.Writer, "token of profile %s removed\n", name)
+	return nil
+}
+
+// readToken takes the token of --token, stdin or a prompt without echo
+func readToken(c *cli.Context) (string, error) {
+	if c.IsSet("token") {
+		return c.String("token"), nil
+	}
+	var token string
+	if c.Bool("token-stdin") || !term.IsTerminal(int(os.Stdin.Fd())) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", err
+		}
+		token = line
+	} else {
+		fmt.Fprint(c.App.ErrWriter, "hetzner cloud api token: ")
+		input, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(c.App.ErrWriter)
+		if err != nil {
+			return "", err
+		}
+		token = string(input)
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", errors.New("empty token")
+	}
+	return token, nil
+}
@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/crabstars/liftoff/hetzner"
+	"github.com/urfave/cli/v2"
+)
+
+func costCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "cost",
+		Usage: "show the running cost of the project grouped by a label with the projected month end",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "by", Value: "project", Usage: "label key the resources are grouped by"},
+			&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Value: outputTable, Usage: "table or json"},
+		},
+		Action: listCost,
+	}
+}
+
+// costOutput is the json form of a cost group, the field names are part of the cli interface
+type costOutput struct {
+	Group       string  `json:"group"`
+	Resources   int     `json:"resources"`
+	Hourly      float64 `json:"hourly"`
+	Monthly     float64 `json:"monthly"`
+	MonthToDate float64 `json:"month_to_date"`
+	Projected   float64 `json:"projected"`
+	Currency    string  `json:"currency"`
+}
+
+func listCost(c *cli.Context) error {
+	apiKey, err := token(c)
+	if err != nil {
+		return err
+	}
+	items, currency, err := hetzner.ListCostItems(c.Context, apiKey)
+	if err != nil {
+		return err
+	}
+	groups, total := hetzner.GroupCosts(items, c.String("by"), time.Now())
+	groups = append(groups, total)
+	switch c.String("output") {
+	case outputJSON:
+		outputs := make([]costOutput, len(groups))
+		for i, group := range groups {
+			outputs[i] = costOutput{
+				Group: group.Name, Resources: len(group.Items), Hourly: group.RunRate.Hourly, Monthly: group.RunRate.Monthly,
+				MonthToDate: group.MonthToDate, Projected: group.Projected, Currency: currency,
+			}
+		}
+		encoder := json.NewEncoder(c.App.Writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(outputs)
+	case outputTable:
+		tw := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+		// gross prices, traffic and backups are not included
+		fmt.Fprintf(tw, "%s\tRESOURCES\tPER HOUR (%s)\tPER MONTH\tTHIS MONTH\tMONTH END\n", c.String("by"), currency)
+		for _, group := range groups {
+			fmt.Fprintf(tw, "%s\t%d\t%.4f\t%.2f\t%.2f\t%.2f\n", group.Name, len(group.Items), group.RunRate.Hourly, group.RunRate.Monthly,
+				group.MonthToDate, group.Projected)
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("unknown output %s, use table or json", c.String("output"))
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/crabstars/liftoff/config"
+	"github.com/crabstars/liftoff/hetzner"
+	"github.com/crabstars/liftoff/logging"
+	sshconnector "github.com/crabstars/liftoff/ssh"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/urfave/cli/v2"
+)
+
+var recipes = map[string]sshconnector.Recipe{
+	"example":  sshconnector.ExampleCSharpWeather,
+	"redeploy": sshconnector.ExampleCSharpWeatherRedeploy,
+}
+
+func deployCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "deploy",
+		Usage:     "run a recipe on a server over ssh",
+		ArgsUsage: "<name or id>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "recipe", Usage: "example, redeploy or a recipe of the config file", Value: "example"},
+		},
+		Action: deploy,
+	}
+}
+
+func deploy(c *cli.Context) error {
+	recipe, err := findRecipe(c.App.Metadata[metaConfig].(config.Config), c.String("recipe"))
+	if err != nil {
+		return err
+	}
+	server, apiKey, err := findServer(c)
+	if err != nil {
+		return err
+	}
+	// recipes reach other servers with {{peer:NAME}}
+	recipe, err = hetzner.ResolvePeers(c.Context, apiKey, recipe)
+	if err != nil {
+		return err
+	}
+	// the build output of the recipe is progress, the result goes to the writer
+	sshconnector.Output = logging.RedactWriter{W: c.App.ErrWriter}
+	if err := hetzner.DeployRecipe(c.Context, apiKey, server, recipe); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.App.Writer, "%s deployed on %s\n", recipe.Name, server.Name)
+	return nil
+}
+
+// findRecipe returns the built in recipe or the one of the config file with the name
+func findRecipe(conf config.Config, name string) (sshconnector.Recipe, error) {
+	if recipe, ok := recipes[name]; ok {
+		return recipe, nil
+	}
+	configured, ok := conf.Recipes[name]
+	if !ok {
+		return sshconnector.Recipe{}, fmt.Errorf("unknown recipe %s, use example, redeploy or add it to the recipes of the config file", name)
+	}
+	if len(configured.Commands) == 0 {
+		return sshconnector.Recipe{}, fmt.Errorf("recipe %s has no commands", name)
+	}
+	recipe := sshconnector.Recipe{Name: name, App: configured.App, Repo: configured.Repo, Dir: configured.Dir}
+	if recipe.App == "" {
+		recipe.App = name
+	}
+	if _, err := hcloud.ValidateResourceLabels(map[string]interface{}{hetzner.LabelApp: recipe.App}); err != nil {
+		return sshconnector.Recipe{}, fmt.Errorf("app of recipe %s: %w", name, err)
+	}
+	for i, cmd := range configured.Commands {
+		recipe.Commands = append(recipe.Commands, sshconnector.NewCommand(cmd, fmt.Sprintf("%s step %d of %d done", name, i+1, len(configured.Commands))))
+	}
+	return recipe, nil
+}
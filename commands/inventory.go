@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/crabstars/liftoff/inventory"
+	"github.com/urfave/cli/v2"
+)
+
+func inventoryCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "inventory",
+		Usage: "list the servers liftoff created and what is deployed on them",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "deleted", Usage: "include deleted servers"},
+			&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Value: outputTable, Usage: "table or json"},
+		},
+		Action: listInventory,
+	}
+}
+
+// inventoryOutput is the json form of an inventory record, the field names are part of the cli interface
+type inventoryOutput struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Profile    string     `json:"profile"`
+	Type       string     `json:"type"`
+	Location   string     `json:"location"`
+	Recipe     string     `json:"recipe"`
+	Repo       string     `json:"repo"`
+	Commit     string     `json:"commit"`
+	CreatedAt  time.Time  `json:"created_at"`
+	DeployedAt *time.Time `json:"deployed_at"`
+	DeletedAt  *time.Time `json:"deleted_at"`
+}
+
+func listInventory(c *cli.Context) error {
+	servers, err := inventory.DB.Servers(c.Bool("deleted"))
+	if err != nil {
+		return err
+	}
+	switch c.String("output") {
+	case outputJSON:
+		outputs := make([]inventoryOutput, len(servers))
+		for i, server := range servers {
+			outputs[i] = inventoryOutput{
+				ID: server.ID, Name: server.Name, Profile: server.Profile, Type: server.ServerType, Location: server.Location,
+				Recipe: server.Recipe, Repo: server.Repo, Commit: server.Commit, CreatedAt: server.CreatedAt,
+				DeployedAt: optionalTime(server.DeployedAt), DeletedAt: optionalTime(server.DeletedAt),
+			}
+		}
+		encoder := json.NewEncoder(c.App.Writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(outputs)
+	case outputTable:
+		tw := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tPROFILE\tTYPE\tLOCATION\tRECIPE\tCOMMIT\tCREATED\tDELETED")
+		for _, server := range servers {
+			commit := server.Commit
+			if len(commit) > 7 {
+				commit = commit[:7]
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", server.ID, server.Name, server.Profile, server.ServerType, server.Location,
+				server.Recipe, commit, server.CreatedAt.Local().Format(time.DateTime), formatOptionalTime(server.DeletedAt))
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("unknown output %s, use table or json", c.String("output"))
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
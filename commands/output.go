@@ -0,0 +1,161 @@
+package commands
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/crabstars/liftoff/hetzner"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	outputTable    = "table"
+	outputJSON     = "json"
+	outputYAML     = "yaml"
+	outputCSV      = "csv"
+	outputTemplate = "template"
+)
+
+func outputFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Value: outputTable, Usage: "table, json, yaml, csv or template"},
+		&cli.StringFlag{Name: "template", Usage: "go template executed for every server, e.g. '{{.Name}} {{.IPv4}}'"},
+	}
+}
+
+// serverOutput is the machine readable form of a server, the field names are part of the cli interface
+type serverOutput struct {
+	ID          int64             `json:"id" yaml:"id"`
+	Name        string            `json:"name" yaml:"name"`
+	IPv4        string            `json:"ipv4" yaml:"ipv4"`
+	IPv6        string            `json:"ipv6" yaml:"ipv6"`
+	PrivateIP   string            `json:"private_ip" yaml:"private_ip"`
+	Status      string            `json:"status" yaml:"status"`
+	Type        string            `json:"type" yaml:"type"`
+	Location    string            `json:"location" yaml:"location"`
+	Labels      map[string]string `json:"labels" yaml:"labels"`
+	MonthlyCost float64           `json:"monthly_cost" yaml:"monthly_cost"`
+}
+
+var csvHeader = []string{"id", "name", "ipv4", "ipv6", "private_ip", "status", "type", "location", "labels", "monthly_cost"}
+
+func newServerOutput(server *hcloud.Server) serverOutput {
+	output := serverOutput{
+		ID:          server.ID,
+		Name:        server.Name,
+		IPv4:        hetzner.PublicIPv4(server),
+		IPv6:        hetzner.PublicIPv6(server),
+		PrivateIP:   hetzner.PrivateIP(server),
+		Status:      string(server.Status),
+		Labels:      server.Labels,
+		MonthlyCost: hetzner.MonthlyCost(server),
+	}
+	if server.ServerType != nil {
+		output.Type = server.ServerType.Name
+	}
+	if server.Datacenter != nil {
+		output.Location = server.Datacenter.Location.Name
+	}
+	if output.Labels == nil {
+		output.Labels = map[string]string{}
+	}
+	return output
+}
+
+// writeServers prints the servers in the format of the output flag, single prints an object instead of a list
+func writeServers(c *cli.Context, servers []*hcloud.Server, single bool) error {
+	outputs := make([]serverOutput, len(servers))
+	for i, server := range servers {
+		outputs[i] = newServerOutput(server)
+	}
+	format := c.String("output")
+	// a template alone is enough
+	if c.IsSet("template") && !c.IsSet("output") {
+		format = outputTemplate
+	}
+	w := c.App.Writer
+	switch format {
+	case outputTable:
+		return writeTable(w, outputs)
+	case outputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if single && len(outputs) == 1 {
+			return encoder.Encode(outputs[0])
+		}
+		return encoder.Encode(outputs)
+	case outputYAML:
+		var data []byte
+		var err error
+		if single && len(outputs) == 1 {
+			data, err = yaml.Marshal(outputs[0])
+		} else {
+			data, err = yaml.Marshal(outputs)
+		}
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case outputCSV:
+		return writeCSV(w, outputs)
+	case outputTemplate:
+		return writeTemplate(w, c.String("template"), outputs)
+	}
+	return fmt.Errorf("unknown output %s, use table, json, yaml, csv or template", format)
+}
+
+func writeTable(w io.Writer, outputs []serverOutput) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSTATUS\tIPV4\tTYPE\tLOCATION\tMONTHLY\tLABELS")
+	for _, output := range outputs {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%.2f\t%s\n", output.ID, output.Name, output.Status, output.IPv4, output.Type, output.Location, output.MonthlyCost, hetzner.FormatLabels(output.Labels))
+	}
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, outputs []serverOutput) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, output := range outputs {
+		err := cw.Write([]string{
+			strconv.FormatInt(output.ID, 10), output.Name, output.IPv4, output.IPv6, output.PrivateIP,
+			output.Status, output.Type, output.Location, hetzner.FormatLabels(output.Labels),
+			strconv.FormatFloat(output.MonthlyCost, 'f', 2, 64),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeTemplate executes the template once per server, a missing newline is added
+func writeTemplate(w io.Writer, text string, outputs []serverOutput) error {
+	if text == "" {
+		return fmt.Errorf("the template output needs --template")
+	}
+	tmpl, err := template.New("output").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return err
+	}
+	for _, output := range outputs {
+		if err := tmpl.Execute(w, output); err != nil {
+			return err
+		}
+		if text[len(text)-1] != '\n' {
+			fmt.Fprintln(w)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/crabstars/liftoff/hetzner"
+	"github.com/crabstars/liftoff/logging"
+	sshconnector "github.com/crabstars/liftoff/ssh"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/urfave/cli/v2"
+)
+
+func serverCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "server",
+		Usage: "list, create, delete, reboot or connect to servers",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "list all servers",
+				Flags: append(outputFlags(),
+					&cli.StringFlag{Name: "selector", Aliases: []string{"l"}, Usage: "only list servers matching the label selector"},
+				),
+				Action: listServers,
+			},
+			{
+				Name:  "create",
+				Usage: "create a server with the docker-ce image",
+				Flags: append(outputFlags(),
+					&cli.StringFlag{Name: "name", Usage: "server name", Required: true},
+					&cli.StringFlag{Name: "country", Usage: "country of the datacenter", Value: hetzner.CountryGermany},
+					&cli.StringFlag{Name: "labels", Usage: "labels as key=value,key2=value2"},
+					&cli.StringFlag{Name: "type", Usage: "server type, overrides the profile"},
+					&cli.StringFlag{Name: "image", Usage: "image name, overrides the profile"},
+					&cli.StringFlag{Name: "location", Usage: "location like fsn1, overrides the profile and the country"},
+					&cli.StringSliceFlag{Name: "key", Usage: "name of another hetzner ssh key added to the server, can be repeated"},
+					&cli.BoolFlag{Name: "wait", Usage: "block until the server is running"},
+					&cli.BoolFlag{Name: "override-budget", Usage: "create the server although it exceeds the monthly budget of the profile"},
+				),
+				Action: createServer,
+			},
+			{
+				Name:      "delete",
+				Usage:     "delete a server",
+				ArgsUsage: "<name or id>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "yes", Aliases: []string{"y"}, Usage: "do not ask for confirmation"},
+				},
+				Action: deleteServer,
+			},
+			{
+				Name:      "reboot",
+				Usage:     "reboot a server and wait until it is done",
+				ArgsUsage: "<name or id>",
+				Action:    rebootServer,
+			},
+			{
+				Name:      "ssh",
+				Usage:     "open a shell on the server or run a command",
+				ArgsUsage: "<name or id> [command]",
+				Action:    sshServer,
+			},
+		},
+	}
+}
+
+func listServers(c *cli.Context) error {
+	apiKey, err := token(c)
+	if err != nil {
+		return err
+	}
+	listedAt := time.Now()
+	servers, err := hetzner.ListServer(c.Context, apiKey, c.String("selector"))
+	if err != nil {
+		return err
+	}
+	if c.String("selector") == "" {
+		hetzner.RecordMissingServers(c.App.Metadata[metaProfileName].(string), servers, listedAt)
+	}
+	return writeServers(c, servers, false)
+}
+
+func createServer(c *cli.Context) error {
+	apiKey, err := token(c)
+	if err != nil {
+		return err
+	}
+	profile := activeProfile(c)
+	var sshKeys []string
+	if profile.SSHKeyName != "" {
+		sshKeys = append(sshKeys, profile.SSHKeyName)
+	}
+	for _, key := range c.StringSlice("key") {
+		if key != profile.SSHKeyName {
+			sshKeys = append(sshKeys, key)
+		}
+	}
+	if len(sshKeys) == 0 {
+		return errors.New("no ssh key, add it to the profile, set SSH_KEY_NAME or use --ssh-key or --key")
+	}
+	labels, err := hetzner.ParseLabels(c.String("labels"))
+	if err != nil {
+		return err
+	}
+	serverOption := hetzner.CreateServerModel{
+		ServerName:    c.String("name"),
+		DeployCountry: c.String("country"),
+		SshKeyNames:   sshKeys,
+		Labels:        labels,
+		ServerType:    profile.ServerType,
+		Image:         profile.Image,
+		Location:      profile.Location,
+		CloudConfig:   profile.CloudConfig,
+		Profile:       c.App.Metadata[metaProfileName].(string),
+	}
+	if c.IsSet("type") {
+		serverOption.ServerType = c.String("type")
+	}
+	if c.IsSet("image") {
+		serverOption.Image = c.String("image")
+	}
+	if c.IsSet("location") {
+		serverOption.Location = c.String("location")
+	}
+	if err := checkCreateBudget(c, apiKey, profile.MonthlyBudget, serverOption); err != nil {
+		return err
+	}
+	result, err := hetzner.NewServer(c.Context, apiKey, serverOption)
+	if err != nil {
+		return err
+	}
+	server := result.Server
+	if c.Bool("wait") {
+		server, err = hetzner.WaitForServer(c.Context, apiKey, result)
+		if err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintf(c.App.ErrWriter, "wait until it is running with: liftoff action wait %d\n", result.Action.ID)
+	}
+	return writeServers(c, []*hcloud.Server{server}, true)
+}
+
+// checkCreateBudget blocks a create which raises the run-rate over the budget of the profile,
+// --override-budget creates it anyway and writes the override to the audit log
+func checkCreateBudget(c *cli.Context, apiKey string, limit float64, serverOption hetzner.CreateServerModel) error {
+	if limit <= 0 {
+		return nil
+	}
+	budget := hetzner.Budget{Limit: limit}
+	estimate, err := hetzner.EstimateCreate(c.Context, apiKey, serverOption, 1)
+	if err == nil {
+		budget, err = hetzner.CheckBudget(c.Context, apiKey, limit, estimate.Total)
+	}
+	if err == nil {
+		err = budget.Err()
+	} else {
+		err = fmt.Errorf("could not check the monthly budget: %w", err)
+	}
+	if err == nil {
+		return nil
+	}
+	if !c.Bool("override-budget") {
+		return fmt.Errorf("%w, use --override-budget to create it anyway", err)
+	}
+	budget.Override("server.create")
+	fmt.Fprintf(c.App.ErrWriter, "warning: %s\n", err)
+	return nil
+}
+
+func deleteServer(c *cli.Context) error {
+	server, apiKey, err := findServer(c)
+	if err != nil {
+		return err
+	}
+	if !c.Bool("yes") {
+		fmt.Fprintf(c.App.Writer, "type the name of the server to delete %s: ", server.Name)
+		var answer string
+		fmt.Fscanln(os.Stdin, &answer)
+		if answer != server.Name {
+			return errors.New("name does not match, server not deleted")
+		}
+	}
+	if err := hetzner.RemoveServer(c.Context, apiKey, server.ID, nil); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.App.Writer, "%s deleted\n", server.Name)
+	return nil
+}
+
+func rebootServer(c *cli.Context) error {
+	server, apiKey, err := findServer(c)
+	if err != nil {
+		return err
+	}
+	if err := hetzner.RebootServer(c.Context, apiKey, server.ID); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.App.Writer, "%s rebooted\n", server.Name)
+	return nil
+}
+
+// sshServer runs the command over the ssh connector, an interactive shell needs a terminal and uses the ssh client
+func sshServer(c *cli.Context) error {
+	server, _, err := findServer(c)
+	if err != nil {
+		return err
+	}
+	ip := server.PublicNet.IPv4.IP.String()
+	command := strings.Join(c.Args().Tail(), " ")
+	if command != "" {
+		sshconnector.Output = logging.LogWriter{}
+		return sshconnector.RunCommandsOnServer(c.Context, ip, []sshconnector.Command{sshconnector.NewCommand(command, "")})
+	}
+	args := []string{"-o", "StrictHostKeyChecking=accept-new"}
+	if keyPath := activeProfile(c).SSHKeyPath; keyPath != "" {
+		args = append(args, "-i", keyPath)
+	}
+	shell := exec.CommandContext(c.Context, "ssh", append(args, sshconnector.UserName+"@"+ip)...)
+	shell.Stdin, shell.Stdout, shell.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return shell.Run()
+}
+
+// findServer resolves the server of the first argument
+func findServer(c *cli.Context) (*hcloud.Server, string, error) {
+	apiKey, err := token(c)
+	if err != nil {
+		return nil, "", err
+	}
+	nameOrID, err := serverArg(c)
+	if err != nil {
+		return nil, "", err
+	}
+	server, err := hetzner.FindServer(c.Context, apiKey, nameOrID)
+	return server, apiKey, err
+}
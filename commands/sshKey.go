@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/crabstars/liftoff/hetzner"
+	sshconnector "github.com/crabstars/liftoff/ssh"
+	"github.com/urfave/cli/v2"
+)
+
+func sshKeyCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "ssh-key",
+		Usage: "list, generate, upload or delete the ssh keys of the project",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "list",
+				Usage:  "list the keys with fingerprint and the matching private key of ~/.ssh",
+				Action: listSshKeys,
+			},
+			{
+				Name:      "generate",
+				Usage:     "generate an ed25519 keypair and upload the public key",
+				ArgsUsage: "<name>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "path", Usage: "path of the private key, the public key gets .pub appended", Value: sshconnector.DefaultKeyPath},
+				},
+				Action: generateSshKey,
+			},
+			{
+				Name:      "upload",
+				Usage:     "upload a public key",
+				ArgsUsage: "<public key file>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "name", Usage: "key name, defaults to <hostname>-<file name>"},
+				},
+				Action: uploadSshKey,
+			},
+			{
+				Name:      "delete",
+				Usage:     "delete a key, servers created with it keep it",
+				ArgsUsage: "<name or id>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "yes", Aliases: []string{"y"}, Usage: "do not ask for confirmation"},
+				},
+				Action: deleteSshKey,
+			},
+		},
+	}
+}
+
+func listSshKeys(c *cli.Context) error {
+	apiKey, err := token(c)
+	if err != nil {
+		return err
+	}
+	keys, err := hetzner.ListSshKeys(c.Context, apiKey)
+	if err != nil {
+		return err
+	}
+	local, _ := sshconnector.LocalKeys()
+	tw := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tFINGERPRINT\tPRIVATE KEY")
+	for _, key := range keys {
+		privateKey := "-"
+		if localKey, found := sshconnector.FindLocalKey(local, key.Fingerprint); found && localKey.PrivatePath != "" {
+			privateKey = localKey.PrivatePath
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", key.ID, key.Name, key.Fingerprint, privateKey)
+	}
+	return tw.Flush()
+}
+
+func generateSshKey(c *cli.Context) error {
+	apiKey, err := token(c)
+	if err != nil {
+		return err
+	}
+	name := c.Args().First()
+	if name == "" {
+		return errors.New("missing key name")
+	}
+	local, err := sshconnector.GenerateKey(c.String("path"), name)
+	if err != nil {
+		return err
+	}
+	key, err := hetzner.UploadSshKey(c.Context, apiKey, name, local.PublicKey)
+	if err != nil {
+		return fmt.Errorf("%s generated but not uploaded: %w", local.PrivatePath, err)
+	}
+	fmt.Fprintf(c.App.Writer, "%s uploaded as %s (%s)\n", local.PublicPath, key.Name, key.Fingerprint)
+	return nil
+}
+
+func uploadSshKey(c *cli.Context) error {
+	apiKey, err := token(c)
+	if err != nil {
+		return err
+	}
+	if !c.Args().Present() {
+		return errors.New("missing public key file")
+	}
+	local, err := sshconnector.ReadLocalKey(c.Args().First())
+	if err != nil {
+		return err
+	}
+	name := c.String("name")
+	if name == "" {
+		hostname, _ := os.Hostname()
+		name = fmt.Sprintf("%s-%s", hostname, local.Name)
+	}
+	key, err := hetzner.UploadSshKey(c.Context, apiKey, name, local.PublicKey)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.App.Writer, "%s uploaded as %s (%s)\n", local.PublicPath, key.Name, key.Fingerprint)
+	return nil
+}
+
+func deleteSshKey(c *cli.Context) error {
+	apiKey, err := token(c)
+	if err != nil {
+		return err
+	}
+	if !c.Args().Present() {
+		return errors.New("missing key name or id")
+	}
+	key, err := hetzner.FindSshKey(c.Context, apiKey, c.Args().First())
+	if err != nil {
+		return err
+	}
+	if !c.Bool("yes") {
+		fmt.Fprintf(c.App.Writer, "type the name of the key to delete %s: ", key.Name)
+		var answer string
+		fmt.Fscanln(os.Stdin, &answer)
+		if answer != key.Name {
+			return errors.New("name does not match, key not deleted")
+		}
+	}
+	if err := hetzner.RemoveSshKey(c.Context, apiKey, key.ID); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.App.Writer, "%s deleted\n", key.Name)
+	return nil
+}
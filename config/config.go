@@ -0,0 +1,62 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config holds the user settings of liftoff, stored in ~/.config/liftoff/config.yaml
+type Config struct {
+	// profile used without --profile or LIFTOFF_PROFILE
+	CurrentProfile string             `yaml:"current_profile,omitempty"`
+	Profiles       map[string]Profile `yaml:"profiles,omitempty"`
+	// visible columns of the server table in this order, empty uses the defaults
+	Columns []string `yaml:"columns,omitempty"`
+	// recipes of liftoff deploy by name, the built in example and redeploy can not be replaced
+	Recipes map[string]Recipe `yaml:"recipes,omitempty"`
+}
+
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "liftoff", "config.yaml"), nil
+}
+
+// Load reads the config file, a missing file is an empty config
+func Load() (Config, error) {
+	var conf Config
+	path, err := Path()
+	if err != nil {
+		return conf, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return conf, nil
+	}
+	if err != nil {
+		return conf, err
+	}
+	err = yaml.Unmarshal(data, &conf)
+	return conf, err
+}
+
+func Save(conf Config) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(conf)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
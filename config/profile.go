@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/crabstars/liftoff/secrets"
+)
+
+// DefaultProfile is used when neither the config file nor a flag names a profile
+const DefaultProfile = "default"
+
+// environment variables which override the values of the profile
+const (
+	EnvProfile    = "LIFTOFF_PROFILE"
+	EnvToken      = "HETZNER_CLOUD_API_KEY"
+	EnvSSHKeyName = "SSH_KEY_NAME"
+	EnvSSHKeyPath = "SSH_KEY_PATH"
+)
+
+// Profile holds the settings of one hetzner project
+type Profile struct {
+	// plain token, only read so old configs keep working, liftoff auth login moves it into TokenStore
+	Token string `yaml:"token,omitempty"`
+	// keyring or file, set by liftoff auth login
+	TokenStore string `yaml:"token_store,omitempty"`
+	// name of the hetzner ssh key added to new servers
+	SSHKeyName string `yaml:"ssh_key_name,omitempty"`
+	// private key used to connect to the servers, ~ is the home directory
+	SSHKeyPath string `yaml:"ssh_key_path,omitempty"`
+	// defaults for new servers, empty ones use the liftoff defaults
+	ServerType string `yaml:"server_type,omitempty"`
+	Image      string `yaml:"image,omitempty"`
+	Location   string `yaml:"location,omitempty"`
+	// cloud-config file merged into the generated one
+	CloudConfig string `yaml:"cloud_config,omitempty"`
+	// limit of the monthly run-rate in the currency of the account, creates and resizes
+	// above it need an override, 0 disables it
+	MonthlyBudget float64 `yaml:"monthly_budget,omitempty"`
+}
+
+// ProfileNames returns the names of all profiles sorted
+func (c Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the name and the settings of the profile with the environment overrides applied.
+// An empty name uses LIFTOFF_PROFILE, then the current profile of the file and at last the default profile
+func (c Config) Resolve(name string) (string, Profile, error) {
+	if name == "" {
+		name = os.Getenv(EnvProfile)
+	}
+	if name == "" {
+		name = c.CurrentProfile
+	}
+	if name == "" {
+		name = DefaultProfile
+	}
+	profile, ok := c.Profiles[name]
+	// the default profile may only exist in the environment
+	if !ok && name != DefaultProfile {
+		return name, profile, fmt.Errorf("profile %s not found", name)
+	}
+	if token := os.Getenv(EnvToken); token != "" {
+		profile.Token = token
+	} else if profile.Token == "" && profile.TokenStore != "" {
+		token, err := secrets.Lookup(profile.TokenStore, name)
+		if err != nil {
+			return name, profile, err
+		}
+		profile.Token = token
+	}
+	if keyName := os.Getenv(EnvSSHKeyName); keyName != "" {
+		profile.SSHKeyName = keyName
+	}
+	if keyPath := os.Getenv(EnvSSHKeyPath); keyPath != "" {
+		profile.SSHKeyPath = keyPath
+	}
+	return name, profile, nil
+}
@@ -0,0 +1,12 @@
+package config
+
+// Recipe deploys an own app next to the built in recipes, liftoff deploy --recipe NAME runs it
+type Recipe struct {
+	// set as the liftoff-app label after a deploy, it must be a valid label value. Empty uses the name of the recipe
+	App string `yaml:"app,omitempty"`
+	// git repo the commands check out into Dir, its commit is recorded after a deploy
+	Repo string `yaml:"repo,omitempty"`
+	Dir  string `yaml:"dir,omitempty"`
+	// run one after another on the server, {{peer:NAME}} is replaced by the private ip of the server NAME
+	Commands []string `yaml:"commands"`
+}
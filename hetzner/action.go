@@ -0,0 +1,56 @@
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// waitForActions blocks until all given actions are finished and returns the
+// errors of the failed ones, every action shows up in the Tracker
+func waitForActions(ctx context.Context, client *hcloud.Client, actions ...*hcloud.Action) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, action := range actions {
+		if action == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(action *hcloud.Action) {
+			defer wg.Done()
+			if err := watchAction(ctx, client, action, nil); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(action)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// runAction runs the call with the ActionTimeout, waits until the returned actions are done and writes
+// the audit record of the operation. call returns the name of the resource, it completes the description
+func runAction(ctx context.Context, hetzner_cloud_api_key string, id int64, operation string, params map[string]string, description string, call func(context.Context, *hcloud.Client) (string, []*hcloud.Action, error)) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, ActionTimeout)
+	defer cancel()
+	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+	name, actions, err := call(ctx, client)
+	if name != "" {
+		description += " " + name
+	}
+	if err == nil {
+		err = waitForActions(ctx, client, actions...)
+	}
+	if err != nil {
+		log.Println(description, "failed", err)
+	}
+	auditOperation(operation, id, params, err)
+	return description, err
+}
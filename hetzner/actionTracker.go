@@ -0,0 +1,160 @@
+package hetzner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// MaxTrackedActions is the number of actions the tracker keeps, the oldest finished ones are dropped first
+const MaxTrackedActions = 50
+
+// TrackedAction is a hetzner action started by liftoff together with its last known progress
+type TrackedAction struct {
+	ID        int64
+	Command   string
+	Resources string
+	Progress  int
+	Status    hcloud.ActionStatus
+	Err       string
+	Started   time.Time
+	Finished  time.Time
+}
+
+// Running reports whether the action did not finish yet
+func (a TrackedAction) Running() bool {
+	return a.Status == hcloud.ActionStatusRunning
+}
+
+// ActionTracker records every action liftoff waits for, it is safe for concurrent use
+type ActionTracker struct {
+	mu      sync.Mutex
+	actions []TrackedAction
+}
+
+// Tracker is the tracker all action waits of this package report to
+var Tracker = &ActionTracker{}
+
+func (t *ActionTracker) track(action *hcloud.Action) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, tracked := range t.actions {
+		if tracked.ID == action.ID {
+			return
+		}
+	}
+	started := action.Started
+	if started.IsZero() {
+		started = time.Now()
+	}
+	t.actions = append(t.actions, TrackedAction{
+		ID:        action.ID,
+		Command:   action.Command,
+		Resources: actionResources(action),
+		Progress:  action.Progress,
+		Status:    hcloud.ActionStatusRunning,
+		Started:   started,
+	})
+	t.prune()
+}
+
+func (t *ActionTracker) update(id int64, progress int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := range t.actions {
+		if t.actions[i].ID == id {
+			t.actions[i].Progress = progress
+			return
+		}
+	}
+}
+
+func (t *ActionTracker) finish(id int64, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := range t.actions {
+		if t.actions[i].ID != id {
+			continue
+		}
+		t.actions[i].Finished = time.Now()
+		if err != nil {
+			t.actions[i].Status = hcloud.ActionStatusError
+			t.actions[i].Err = err.Error()
+		} else {
+			t.actions[i].Status = hcloud.ActionStatusSuccess
+			t.actions[i].Progress = 100
+		}
+		return
+	}
+}
+
+// prune drops the oldest finished actions, running ones are always kept
+func (t *ActionTracker) prune() {
+	for len(t.actions) > MaxTrackedActions {
+		dropped := false
+		for i, action := range t.actions {
+			if !action.Running() {
+				t.actions = append(t.actions[:i], t.actions[i+1:]...)
+				dropped = true
+				break
+			}
+		}
+		if !dropped {
+			return
+		}
+	}
+}
+
+// Snapshot returns a copy of all tracked actions, oldest first
+func (t *ActionTracker) Snapshot() []TrackedAction {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TrackedAction(nil), t.actions...)
+}
+
+// RunningCount returns the number of tracked actions which did not finish yet
+func (t *ActionTracker) RunningCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	count := 0
+	for _, action := range t.actions {
+		if action.Running() {
+			count++
+		}
+	}
+	return count
+}
+
+func actionResources(action *hcloud.Action) string {
+	resources := make([]string, 0, len(action.Resources))
+	for _, resource := range action.Resources {
+		resources = append(resources, fmt.Sprintf("%s %d", resource.Type, resource.ID))
+	}
+	return strings.Join(resources, ", ")
+}
+
+// watchAction tracks the action and blocks until it is finished, progress is optional
+func watchAction(ctx context.Context, client *hcloud.Client, action *hcloud.Action, progress func(int)) error {
+	Tracker.track(action)
+	progressCh, errCh := client.Action.WatchProgress(ctx, action)
+	for {
+		select {
+		case p, ok := <-progressCh:
+			if !ok {
+				progressCh = nil
+				continue
+			}
+			Tracker.update(action.ID, p)
+			if progress != nil {
+				progress(p)
+			}
+		case err := <-errCh:
+			Tracker.finish(action.ID, err)
+			return err
+		}
+	}
+}
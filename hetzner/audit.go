@@ -0,0 +1,38 @@
+package hetzner
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/crabstars/liftoff/audit"
+)
+
+// auditOperation writes the audit record of an operation on one resource, an id of 0 means no target
+func auditOperation(operation string, id int64, params map[string]string, err error) {
+	var targets []string
+	if id != 0 {
+		targets = []string{strconv.FormatInt(id, 10)}
+	}
+	audit.Log.Operation(operation, targets, params, err)
+}
+
+// createParams are the parameters of a server create as they end up in the audit log
+func createParams(serverOption CreateServerModel) map[string]string {
+	params := map[string]string{
+		"name":     serverOption.ServerName,
+		"type":     serverOption.ServerType,
+		"image":    serverOption.Image,
+		"location": serverOption.Location,
+		"country":  serverOption.DeployCountry,
+		"ssh_keys": strings.Join(serverOption.SshKeyNames, ","),
+	}
+	if len(serverOption.Labels) > 0 {
+		params["labels"] = FormatLabels(serverOption.Labels)
+	}
+	for key, value := range params {
+		if value == "" {
+			delete(params, key)
+		}
+	}
+	return params
+}
@@ -0,0 +1,20 @@
+package hetzner
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// ValidateToken makes a cheap read request, a wrong token gets an unauthorized error
+func ValidateToken(ctx context.Context, hetzner_cloud_api_key string) error {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+	_, _, err := client.Server.List(ctx, hcloud.ServerListOpts{ListOpts: hcloud.ListOpts{PerPage: 1}})
+	if hcloud.IsError(err, hcloud.ErrorCodeUnauthorized) {
+		return errors.New("the token is not valid for any hetzner project")
+	}
+	return err
+}
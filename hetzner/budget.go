@@ -0,0 +1,117 @@
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/audit"
+)
+
+// BudgetWarnRatio is the share of the budget from which the run-rate gets a warning
+const BudgetWarnRatio = 0.8
+
+var ErrBudgetExceeded = errors.New("monthly budget exceeded")
+
+// BudgetError is returned when an operation would exceed the budget, it matches ErrBudgetExceeded
+type BudgetError struct {
+	Budget Budget
+}
+
+func (e *BudgetError) Error() string {
+	b := e.Budget
+	return fmt.Sprintf("%s: %.2f %s per month would become %.2f %s, the budget is %.2f %s",
+		ErrBudgetExceeded, b.RunRate, b.Currency, b.RunRate+b.Added, b.Currency, b.Limit, b.Currency)
+}
+
+func (e *BudgetError) Unwrap() error {
+	return ErrBudgetExceeded
+}
+
+// Budget compares the monthly run-rate of the project with the budget of the profile,
+// the run-rate is what all resources cost if they keep running for a whole month
+type Budget struct {
+	// 0 disables the budget
+	Limit    float64
+	RunRate  float64
+	Currency string
+	// monthly cost of the resources about to be created or resized
+	Added float64
+}
+
+func (b Budget) Enabled() bool {
+	return b.Limit > 0
+}
+
+// Exceeded reports whether the added resources raise the run-rate over the limit
+func (b Budget) Exceeded() bool {
+	return b.Enabled() && b.RunRate+b.Added > b.Limit
+}
+
+// Warning reports whether the run-rate reached BudgetWarnRatio of the limit
+func (b Budget) Warning() bool {
+	return b.Enabled() && b.RunRate >= b.Limit*BudgetWarnRatio
+}
+
+// Err returns a *BudgetError if the budget is exceeded
+func (b Budget) Err() error {
+	if !b.Exceeded() {
+		return nil
+	}
+	return &BudgetError{Budget: b}
+}
+
+// Override writes the audit record of an operation which was started although it exceeds the budget
+func (b Budget) Override(operation string) {
+	audit.Log.Operation("budget.override", nil, map[string]string{
+		"operation": operation,
+		"limit":     strconv.FormatFloat(b.Limit, 'f', 2, 64),
+		"run_rate":  strconv.FormatFloat(b.RunRate, 'f', 2, 64),
+		"added":     strconv.FormatFloat(b.Added, 'f', 2, 64),
+	}, nil)
+}
+
+// BudgetOf sums the monthly cost of the items
+func BudgetOf(items []CostItem, currency string, limit float64) Budget {
+	_, total := GroupCosts(items, "", time.Now())
+	return Budget{Limit: limit, RunRate: total.RunRate.Monthly, Currency: currency}
+}
+
+// CheckBudget loads the run-rate of the project and adds the monthly cost of new resources
+func CheckBudget(ctx context.Context, hetzner_cloud_api_key string, limit float64, added Cost) (Budget, error) {
+	items, currency, err := ListCostItems(ctx, hetzner_cloud_api_key)
+	if err != nil {
+		return Budget{Limit: limit}, err
+	}
+	budget := BudgetOf(items, currency, limit)
+	budget.Added = added.Monthly
+	return budget, nil
+}
+
+// checkAdded fails with a *BudgetError if the monthly cost of a new or grown resource raises
+// the run-rate over the limit, a limit of 0 skips the check
+func checkAdded(ctx context.Context, hetzner_cloud_api_key string, limit float64, added Cost) error {
+	if limit <= 0 {
+		return nil
+	}
+	budget, err := CheckBudget(ctx, hetzner_cloud_api_key, limit, added)
+	if err != nil {
+		return err
+	}
+	return budget.Err()
+}
+
+type BudgetLoadedMsg struct {
+	Budget Budget
+	Err    error
+}
+
+func LoadBudget(ctx context.Context, hetzner_cloud_api_key string, limit float64) tea.Cmd {
+	return func() tea.Msg {
+		budget, err := CheckBudget(ctx, hetzner_cloud_api_key, limit, Cost{})
+		return BudgetLoadedMsg{Budget: budget, Err: err}
+	}
+}
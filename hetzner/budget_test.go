@@ -0,0 +1,67 @@
+package hetzner
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBudget(t *testing.T) {
+	tests := []struct {
+		name         string
+		budget       Budget
+		wantExceeded bool
+		wantWarning  bool
+	}{
+		{"limit 0 disables the budget", Budget{Limit: 0, RunRate: 500, Added: 100}, false, false},
+		{"far below the limit", Budget{Limit: 100, RunRate: 10, Added: 10}, false, false},
+		{"just below the warning", Budget{Limit: 100, RunRate: 79.99}, false, false},
+		{"warning at 80 percent", Budget{Limit: 100, RunRate: 80}, false, true},
+		{"added exactly up to the limit", Budget{Limit: 100, RunRate: 60, Added: 40}, false, false},
+		{"run-rate exactly at the limit", Budget{Limit: 100, RunRate: 100}, false, true},
+		{"added over the limit", Budget{Limit: 100, RunRate: 60, Added: 40.01}, true, false},
+		{"run-rate already over the limit", Budget{Limit: 100, RunRate: 120}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.budget.Exceeded(); got != tt.wantExceeded {
+				t.Errorf("Exceeded() = %v, want %v", got, tt.wantExceeded)
+			}
+			if got := tt.budget.Warning(); got != tt.wantWarning {
+				t.Errorf("Warning() = %v, want %v", got, tt.wantWarning)
+			}
+			err := tt.budget.Err()
+			if (err != nil) != tt.wantExceeded {
+				t.Fatalf("Err() = %v, want an error %v", err, tt.wantExceeded)
+			}
+			if err == nil {
+				return
+			}
+			if !errors.Is(err, ErrBudgetExceeded) {
+				t.Errorf("errors.Is(%v, ErrBudgetExceeded) = false", err)
+			}
+			var budgetErr *BudgetError
+			if !errors.As(err, &budgetErr) || budgetErr.Budget != tt.budget {
+				t.Errorf("errors.As(%v) does not return the budget %+v", err, tt.budget)
+			}
+		})
+	}
+}
+
+func TestBudgetOf(t *testing.T) {
+	created := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+	items := []CostItem{
+		{Kind: CostKindServer, Created: created, Cost: Cost{Hourly: 0.01, Monthly: 5}},
+		{Kind: CostKindVolume, Created: created, Cost: Cost{Hourly: 0.001, Monthly: 0.44}},
+		// a resource created this hour counts with its full monthly price
+		{Kind: CostKindLoadBalancer, Created: time.Now(), Cost: Cost{Hourly: 0.01, Monthly: 5.39}},
+	}
+	budget := BudgetOf(items, "EUR", 20)
+	want := Budget{Limit: 20, RunRate: 10.83, Currency: "EUR"}
+	if budget.Limit != want.Limit || budget.Currency != want.Currency || budget.Added != 0 || !equalMoney(budget.RunRate, want.RunRate) {
+		t.Errorf("BudgetOf() = %+v, want %+v", budget, want)
+	}
+	if empty := BudgetOf(nil, "EUR", 20); empty.RunRate != 0 || empty.Exceeded() || empty.Warning() {
+		t.Errorf("BudgetOf(nil) = %+v, want an empty run-rate", empty)
+	}
+}
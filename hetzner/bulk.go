@@ -0,0 +1,136 @@
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	sshconnector "github.com/crabstars/liftoff/ssh"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+const (
+	BulkDelete   = "delete"
+	BulkReboot   = "reboot"
+	BulkPowerOff = "power off"
+	BulkSnapshot = "snapshot"
+	BulkLabel    = "label"
+	BulkRedeploy = "redeploy"
+)
+
+// BulkActions are offered for selected servers in this order
+var BulkActions = []string{BulkDelete, BulkReboot, BulkPowerOff, BulkSnapshot, BulkLabel, BulkRedeploy}
+
+// BulkActionDoneMsg is returned after the action ran on every selected server
+type BulkActionDoneMsg struct {
+	Action string
+	Done   int
+	Failed int
+}
+
+// RunBulkAction runs work for every server in parallel and reports the status per server index.
+// Every server gets its own timeout, a cancelled task reports the state the server was left in
+func RunBulkAction(ctx context.Context, hetzner_cloud_api_key string, action string, servers []*hcloud.Server, work func(context.Context, *hcloud.Server) error, progress func(index int, status string, err error)) tea.Cmd {
+	timeout := ActionTimeout
+	if action == BulkRedeploy {
+		timeout = DeployTimeout
+	}
+	return func() tea.Msg {
+		client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+		done, failed := runParallel(ctx, len(servers), func(index int) error {
+			taskCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			err := work(taskCtx, servers[index])
+			if err != nil && Cancelled(err) {
+				err = fmt.Errorf("%w, %s", err, serverState(client, servers[index].ID))
+			}
+			if err != nil {
+				log.Println(action, servers[index].Name, "failed", err)
+			}
+			return err
+		}, progress)
+		return BulkActionDoneMsg{Action: action, Done: done, Failed: failed}
+	}
+}
+
+// RunBulkRedeploy resolves the peers of the recipe once and deploys it on every server like RunBulkAction
+func RunBulkRedeploy(ctx context.Context, hetzner_cloud_api_key string, servers []*hcloud.Server, recipe sshconnector.Recipe, progress func(index int, status string, err error)) tea.Cmd {
+	return func() tea.Msg {
+		recipe, err := ResolvePeers(ctx, hetzner_cloud_api_key, recipe)
+		if err != nil {
+			log.Println(BulkRedeploy, "could not resolve the peers", err)
+			for index := range servers {
+				progress(index, TaskFailed, err)
+			}
+			return BulkActionDoneMsg{Action: BulkRedeploy, Failed: len(servers)}
+		}
+		work := func(ctx context.Context, server *hcloud.Server) error {
+			return DeployRecipe(ctx, hetzner_cloud_api_key, server, recipe)
+		}
+		return RunBulkAction(ctx, hetzner_cloud_api_key, BulkRedeploy, servers, work, progress)()
+	}
+}
+
+// ServerActionWork returns the hetzner call of the bulk action for one server, labels are only used by BulkLabel
+// and get merged into the existing labels. Redeploy runs over ssh with RunBulkRedeploy
+func ServerActionWork(hetzner_cloud_api_key string, action string, labels map[string]string) (func(context.Context, *hcloud.Server) error, error) {
+	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+	switch action {
+	case BulkDelete:
+		return func(ctx context.Context, server *hcloud.Server) error {
+			return deleteServerHetzner(ctx, hetzner_cloud_api_key, server.ID, nil)
+		}, nil
+	case BulkReboot:
+		return func(ctx context.Context, server *hcloud.Server) error {
+			action, _, err := client.Server.Reboot(ctx, server)
+			if err == nil {
+				err = waitForActions(ctx, client, action)
+			}
+			auditOperation("server.reboot", server.ID, map[string]string{"name": server.Name}, err)
+			return err
+		}, nil
+	case BulkPowerOff:
+		return func(ctx context.Context, server *hcloud.Server) error {
+			action, _, err := client.Server.Poweroff(ctx, server)
+			if err == nil {
+				err = waitForActions(ctx, client, action)
+			}
+			auditOperation("server.poweroff", server.ID, map[string]string{"name": server.Name}, err)
+			return err
+		}, nil
+	case BulkSnapshot:
+		return func(ctx context.Context, server *hcloud.Server) error {
+			description := fmt.Sprintf("%s-%s", server.Name, time.Now().Format("2006-01-02-1504"))
+			result, _, err := client.Server.CreateImage(ctx, server, &hcloud.ServerCreateImageOpts{
+				Type:        hcloud.ImageTypeSnapshot,
+				Description: &description,
+				Labels:      withManagedByLabel(nil),
+			})
+			if err == nil {
+				err = waitForActions(ctx, client, result.Action)
+			}
+			auditOperation("server.snapshot", server.ID, map[string]string{"name": server.Name, "description": description}, err)
+			return err
+		}, nil
+	case BulkLabel:
+		if len(labels) == 0 {
+			return nil, errors.New("no labels to add")
+		}
+		return func(ctx context.Context, server *hcloud.Server) error {
+			merged := make(map[string]string, len(server.Labels)+len(labels))
+			for key, value := range server.Labels {
+				merged[key] = value
+			}
+			for key, value := range labels {
+				merged[key] = value
+			}
+			_, _, err := client.Server.Update(ctx, server, hcloud.ServerUpdateOpts{Labels: merged})
+			auditOperation("server.label", server.ID, map[string]string{"name": server.Name, "labels": FormatLabels(labels)}, err)
+			return err
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown action %s", action)
+}
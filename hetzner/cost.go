@@ -0,0 +1,287 @@
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// kinds of a CostItem
+const (
+	CostKindServer       = "server"
+	CostKindVolume       = "volume"
+	CostKindPrimaryIP    = "primary ip"
+	CostKindFloatingIP   = "floating ip"
+	CostKindLoadBalancer = "load balancer"
+	CostKindSnapshot     = "snapshot"
+)
+
+// CostLine is one priced part of an estimate
+type CostLine struct {
+	Description string
+	Cost        Cost
+}
+
+// CostEstimate is what a create adds to the bill
+type CostEstimate struct {
+	Currency string
+	Lines    []CostLine
+	Total    Cost
+}
+
+func (e *CostEstimate) add(description string, cost Cost) {
+	e.Lines = append(e.Lines, CostLine{Description: description, Cost: cost})
+	e.Total = e.Total.Add(cost)
+}
+
+type CostEstimateMsg struct {
+	Estimate CostEstimate
+	// only loaded with a budget, Added is the monthly total of the estimate
+	Budget Budget
+	Err    error
+}
+
+// EstimateCreate prices count servers of the options with their new volume and public ipv4,
+// existing volumes and ips are already paid for
+func EstimateCreate(ctx context.Context, hetzner_cloud_api_key string, serverOption CreateServerModel, count int) (CostEstimate, error) {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+	prices, err := GetPrices(ctx, client, hetzner_cloud_api_key)
+	if err != nil {
+		return CostEstimate{}, err
+	}
+	if serverOption.DeployCountry == "" {
+		serverOption.DeployCountry = CountryGermany
+	}
+	serverType, err := serverTypeForServer(ctx, client, serverOption.ServerType)
+	if err != nil {
+		return CostEstimate{}, err
+	}
+	location, err := locationForEstimate(ctx, client, serverOption)
+	if err != nil {
+		return CostEstimate{}, err
+	}
+
+	estimate := CostEstimate{Currency: prices.Currency}
+	serverCost, err := prices.Server(serverType.Name, location)
+	if err != nil {
+		return CostEstimate{}, err
+	}
+	estimate.add(fmt.Sprintf("%d x server %s in %s", count, serverType.Name, location), serverCost.Times(float64(count)))
+	if serverOption.PrimaryIP == nil {
+		estimate.add(fmt.Sprintf("%d x public ipv4", count), prices.PrimaryIP("ipv4", location).Times(float64(count)))
+	}
+	if serverOption.Volume != nil && serverOption.Volume.VolumeID == 0 {
+		estimate.add(fmt.Sprintf("%d x volume of %d GB", count, serverOption.Volume.Size), prices.Volume(serverOption.Volume.Size).Times(float64(count)))
+	}
+	return estimate, nil
+}
+
+// locationForEstimate is the location createServer ends up in, an existing primary ip or volume decides it
+func locationForEstimate(ctx context.Context, client *hcloud.Client, serverOption CreateServerModel) (string, error) {
+	if serverOption.PrimaryIP != nil {
+		primaryIP, _, err := client.PrimaryIP.GetByID(ctx, serverOption.PrimaryIP.PrimaryIPID)
+		if err == nil && primaryIP == nil {
+			err = errors.New("primary ip not found")
+		}
+		if err != nil {
+			return "", err
+		}
+		return primaryIP.Datacenter.Location.Name, nil
+	}
+	if serverOption.Volume != nil && serverOption.Volume.VolumeID != 0 {
+		volume, _, err := client.Volume.GetByID(ctx, serverOption.Volume.VolumeID)
+		if err == nil && volume == nil {
+			err = errors.New("volume not found")
+		}
+		if err != nil {
+			return "", err
+		}
+		return volume.Location.Name, nil
+	}
+	datacenter, err := datacenterForServer(ctx, client, serverOption)
+	if err != nil {
+		return "", err
+	}
+	return datacenter.Location.Name, nil
+}
+
+// LoadCreateEstimate prices the create, a budget above 0 also loads the run-rate of the project
+func LoadCreateEstimate(ctx context.Context, hetzner_cloud_api_key string, serverOption CreateServerModel, count int, budget float64) tea.Cmd {
+	return func() tea.Msg {
+		estimate, err := EstimateCreate(ctx, hetzner_cloud_api_key, serverOption, count)
+		msg := CostEstimateMsg{Estimate: estimate, Budget: Budget{Limit: budget}, Err: err}
+		if err == nil && budget > 0 {
+			msg.Budget, msg.Err = CheckBudget(ctx, hetzner_cloud_api_key, budget, estimate.Total)
+		}
+		return msg
+	}
+}
+
+// CostItem is a billed resource of the project
+type CostItem struct {
+	Kind     string
+	ID       int64
+	Name     string
+	Location string
+	Labels   map[string]string
+	Created  time.Time
+	Cost     Cost
+}
+
+// MonthToDate is what the resource cost since the start of the month
+func (i CostItem) MonthToDate(now time.Time) float64 {
+	return i.Cost.Between(latest(i.Created, MonthStart(now)), now)
+}
+
+// ProjectedMonth is what the resource costs this month if it keeps running until the end of it
+func (i CostItem) ProjectedMonth(now time.Time) float64 {
+	return i.Cost.Between(latest(i.Created, MonthStart(now)), MonthStart(now).AddDate(0, 1, 0))
+}
+
+func MonthStart(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+}
+
+func latest(a time.Time, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+type CostsLoadedMsg struct {
+	Items    []CostItem
+	Currency string
+	Err      error
+}
+
+// ListCostItems prices all servers, volumes, ips, load balancers and snapshots of the project.
+// Networks, firewalls and placement groups are free, traffic and backups are not included
+func ListCostItems(ctx context.Context, hetzner_cloud_api_key string) ([]CostItem, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+	prices, err := GetPrices(ctx, client, hetzner_cloud_api_key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var items []CostItem
+	servers, err := client.Server.All(ctx)
+	if err != nil {
+		log.Println("could not get all servers", err)
+		return nil, "", err
+	}
+	for _, server := range servers {
+		location := server.Datacenter.Location.Name
+		cost, err := prices.Server(server.ServerType.Name, location)
+		if err != nil {
+			log.Println(err)
+		}
+		items = append(items, CostItem{Kind: CostKindServer, ID: server.ID, Name: server.Name, Location: location, Labels: server.Labels, Created: server.Created, Cost: cost})
+	}
+	volumes, err := client.Volume.All(ctx)
+	if err != nil {
+		log.Println("could not get all volumes", err)
+		return nil, "", err
+	}
+	for _, volume := range volumes {
+		items = append(items, CostItem{Kind: CostKindVolume, ID: volume.ID, Name: volume.Name, Location: volume.Location.Name, Labels: volume.Labels, Created: volume.Created, Cost: prices.Volume(volume.Size)})
+	}
+	primaryIPs, err := client.PrimaryIP.All(ctx)
+	if err != nil {
+		log.Println("could not get all primary ips", err)
+		return nil, "", err
+	}
+	for _, primaryIP := range primaryIPs {
+		location := primaryIP.Datacenter.Location.Name
+		items = append(items, CostItem{Kind: CostKindPrimaryIP, ID: primaryIP.ID, Name: primaryIP.Name, Location: location, Labels: primaryIP.Labels, Created: primaryIP.Created, Cost: prices.PrimaryIP(string(primaryIP.Type), location)})
+	}
+	floatingIPs, err := client.FloatingIP.All(ctx)
+	if err != nil {
+		log.Println("could not get all floating ips", err)
+		return nil, "", err
+	}
+	for _, floatingIP := range floatingIPs {
+		location := floatingIP.HomeLocation.Name
+		items = append(items, CostItem{Kind: CostKindFloatingIP, ID: floatingIP.ID, Name: floatingIP.Name, Location: location, Labels: floatingIP.Labels, Created: floatingIP.Created, Cost: prices.FloatingIP(string(floatingIP.Type), location)})
+	}
+	loadBalancers, err := client.LoadBalancer.All(ctx)
+	if err != nil {
+		log.Println("could not get all load balancers", err)
+		return nil, "", err
+	}
+	for _, loadBalancer := range loadBalancers {
+		location := loadBalancer.Location.Name
+		cost, err := prices.LoadBalancer(loadBalancer.LoadBalancerType.Name, location)
+		if err != nil {
+			log.Println(err)
+		}
+		items = append(items, CostItem{Kind: CostKindLoadBalancer, ID: loadBalancer.ID, Name: loadBalancer.Name, Location: location, Labels: loadBalancer.Labels, Created: loadBalancer.Created, Cost: cost})
+	}
+	snapshots, err := client.Image.AllWithOpts(ctx, hcloud.ImageListOpts{Type: []hcloud.ImageType{hcloud.ImageTypeSnapshot}})
+	if err != nil {
+		log.Println("could not get all snapshots", err)
+		return nil, "", err
+	}
+	for _, snapshot := range snapshots {
+		items = append(items, CostItem{Kind: CostKindSnapshot, ID: snapshot.ID, Name: snapshot.Description, Labels: snapshot.Labels, Created: snapshot.Created, Cost: prices.Snapshot(snapshot.ImageSize)})
+	}
+	return items, prices.Currency, nil
+}
+
+// CostGroup sums the items sharing the value of a label
+type CostGroup struct {
+	Name        string
+	Items       []CostItem
+	RunRate     Cost
+	MonthToDate float64
+	Projected   float64
+}
+
+func (g *CostGroup) add(item CostItem, now time.Time) {
+	g.Items = append(g.Items, item)
+	g.RunRate = g.RunRate.Add(item.Cost)
+	g.MonthToDate += item.MonthToDate(now)
+	g.Projected += item.ProjectedMonth(now)
+}
+
+// NoLabelGroup holds the items without the grouping label
+const NoLabelGroup = "(none)"
+
+// GroupCosts groups the items by the value of the label, the most expensive group first, and sums up all items
+func GroupCosts(items []CostItem, labelKey string, now time.Time) (groups []CostGroup, total CostGroup) {
+	index := make(map[string]int)
+	total.Name = "total"
+	for _, item := range items {
+		name, ok := item.Labels[labelKey]
+		if !ok || name == "" {
+			name = NoLabelGroup
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, CostGroup{Name: name})
+		}
+		groups[i].add(item, now)
+		total.add(item, now)
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Projected > groups[j].Projected })
+	return groups, total
+}
+
+func LoadCosts(ctx context.Context, hetzner_cloud_api_key string) tea.Cmd {
+	return func() tea.Msg {
+		items, currency, err := ListCostItems(ctx, hetzner_cloud_api_key)
+		return CostsLoadedMsg{Items: items, Currency: currency, Err: err}
+	}
+}
@@ -0,0 +1,168 @@
+package hetzner
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+func equalMoney(a float64, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestCostBetween(t *testing.T) {
+	cost := Cost{Hourly: 0.01, Monthly: 5}
+	start := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		from time.Time
+		to   time.Time
+		want float64
+	}{
+		{"no time passed", start, start, 0},
+		{"to before from", start, start.Add(-time.Hour), 0},
+		{"started hour is billed", start, start.Add(30 * time.Minute), 0.01},
+		{"full hours", start, start.Add(36 * time.Hour), 0.36},
+		{"one second into the next hour", start, start.Add(time.Hour + time.Second), 0.02},
+		{"capped at the monthly price", start, start.AddDate(0, 1, 0), 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cost.Between(tt.from, tt.to); !equalMoney(got, tt.want) {
+				t.Errorf("Between() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCostItemMonth(t *testing.T) {
+	now := time.Date(2024, time.March, 16, 12, 0, 0, 0, time.UTC)
+	cost := Cost{Hourly: 0.01, Monthly: 5}
+	tests := []struct {
+		name          string
+		created       time.Time
+		wantToDate    float64
+		wantProjected float64
+	}{
+		// 36 hours so far, 408 hours until april
+		{"created mid-month", time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC), 0.36, 4.08},
+		// 372 hours since the first, the 744 hours of march hit the monthly cap
+		{"created last month", time.Date(2024, time.February, 10, 0, 0, 0, 0, time.UTC), 3.72, 5},
+		// the started hour counts, 372 hours and a minute until april
+		{"created a minute ago", now.Add(-time.Minute), 0.01, 3.73},
+		{"created in the future", now.Add(time.Hour), 0, 3.71},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := CostItem{Created: tt.created, Cost: cost}
+			if got := item.MonthToDate(now); !equalMoney(got, tt.wantToDate) {
+				t.Errorf("MonthToDate() = %v, want %v", got, tt.wantToDate)
+			}
+			if got := item.ProjectedMonth(now); !equalMoney(got, tt.wantProjected) {
+				t.Errorf("ProjectedMonth() = %v, want %v", got, tt.wantProjected)
+			}
+		})
+	}
+}
+
+func TestGroupCosts(t *testing.T) {
+	now := time.Date(2024, time.March, 16, 12, 0, 0, 0, time.UTC)
+	lastMonth := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+	items := []CostItem{
+		{Name: "web-1", Labels: map[string]string{"project": "shop"}, Created: lastMonth, Cost: Cost{Hourly: 0.01, Monthly: 5}},
+		{Name: "db", Labels: map[string]string{"project": "blog"}, Created: lastMonth, Cost: Cost{Hourly: 0.1, Monthly: 20}},
+		{Name: "web-2", Labels: map[string]string{"project": "shop"}, Created: lastMonth, Cost: Cost{Hourly: 0.01, Monthly: 5}},
+		{Name: "unlabeled", Created: lastMonth, Cost: Cost{Hourly: 0.001, Monthly: 0.5}},
+		{Name: "empty label", Labels: map[string]string{"project": ""}, Created: lastMonth, Cost: Cost{Hourly: 0.001, Monthly: 0.5}},
+	}
+	groups, total := GroupCosts(items, "project", now)
+
+	want := []struct {
+		name      string
+		items     int
+		monthly   float64
+		projected float64
+	}{
+		{"blog", 1, 20, 20},
+		{"shop", 2, 10, 10},
+		{NoLabelGroup, 2, 1, 1},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("got %d groups, want %d", len(groups), len(want))
+	}
+	for i, w := range want {
+		group := groups[i]
+		if group.Name != w.name || len(group.Items) != w.items || !equalMoney(group.RunRate.Monthly, w.monthly) || !equalMoney(group.Projected, w.projected) {
+			t.Errorf("group %d = %s with %d items, %v monthly, %v projected, want %s with %d items, %v monthly, %v projected",
+				i, group.Name, len(group.Items), group.RunRate.Monthly, group.Projected, w.name, w.items, w.monthly, w.projected)
+		}
+	}
+	if total.Name != "total" || len(total.Items) != len(items) || !equalMoney(total.RunRate.Monthly, 31) || !equalMoney(total.Projected, 31) {
+		t.Errorf("total = %s with %d items, %v monthly, %v projected", total.Name, len(total.Items), total.RunRate.Monthly, total.Projected)
+	}
+}
+
+func TestPricesFromPricing(t *testing.T) {
+	fsn1 := &hcloud.Location{Name: "fsn1"}
+	pricing := hcloud.Pricing{
+		Volume: hcloud.VolumePricing{PerGBMonthly: hcloud.Price{Currency: "EUR", Gross: "0.0440"}},
+		Image:  hcloud.ImagePricing{PerGBMonth: hcloud.Price{Currency: "EUR", Gross: "0.0119"}},
+		ServerTypes: []hcloud.ServerTypePricing{{
+			ServerType: &hcloud.ServerType{Name: "cx22"},
+			Pricings: []hcloud.ServerTypeLocationPricing{{
+				Location: fsn1,
+				Hourly:   hcloud.Price{Gross: "0.0060"},
+				Monthly:  hcloud.Price{Gross: "3.79"},
+			}},
+		}, {
+			ServerType: &hcloud.ServerType{Name: "broken"},
+			Pricings: []hcloud.ServerTypeLocationPricing{{
+				Location: fsn1,
+				Hourly:   hcloud.Price{Gross: "not a price"},
+				Monthly:  hcloud.Price{Gross: "1.00"},
+			}},
+		}},
+		PrimaryIPs: []hcloud.PrimaryIPPricing{{
+			Type: "ipv4",
+			Pricings: []hcloud.PrimaryIPTypePricing{{
+				Location: "fsn1",
+				Hourly:   hcloud.PrimaryIPPrice{Gross: "0.0008"},
+				Monthly:  hcloud.PrimaryIPPrice{Gross: "0.50"},
+			}},
+		}},
+	}
+	prices := pricesFromPricing(pricing)
+
+	if prices.Currency != "EUR" {
+		t.Errorf("currency = %s, want EUR", prices.Currency)
+	}
+	tests := []struct {
+		name    string
+		cost    func() (Cost, error)
+		want    Cost
+		wantErr bool
+	}{
+		{"server", func() (Cost, error) { return prices.Server("cx22", "fsn1") }, Cost{Hourly: 0.006, Monthly: 3.79}, false},
+		{"server in a location without price", func() (Cost, error) { return prices.Server("cx22", "ash") }, Cost{}, true},
+		{"unknown server type", func() (Cost, error) { return prices.Server("cx99", "fsn1") }, Cost{}, true},
+		{"broken price counts as free", func() (Cost, error) { return prices.Server("broken", "fsn1") }, Cost{Hourly: 0, Monthly: 1}, false},
+		{"load balancer without price", func() (Cost, error) { return prices.LoadBalancer("lb11", "fsn1") }, Cost{}, true},
+		{"volume", func() (Cost, error) { return prices.Volume(10), nil }, Cost{Hourly: 0.44 / HoursPerMonth, Monthly: 0.44}, false},
+		{"snapshot", func() (Cost, error) { return prices.Snapshot(2), nil }, Cost{Hourly: 0.0238 / HoursPerMonth, Monthly: 0.0238}, false},
+		{"primary ipv4", func() (Cost, error) { return prices.PrimaryIP("ipv4", "fsn1"), nil }, Cost{Hourly: 0.0008, Monthly: 0.5}, false},
+		{"ipv6 is free", func() (Cost, error) { return prices.PrimaryIP("ipv6", "fsn1"), nil }, Cost{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cost()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if !equalMoney(got.Hourly, tt.want.Hourly) || !equalMoney(got.Monthly, tt.want.Monthly) {
+				t.Errorf("cost = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
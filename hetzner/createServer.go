@@ -2,10 +2,15 @@ package hetzner
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"strconv"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	cloudconfig "github.com/crabstars/liftoff/cloudConfig"
+	"github.com/crabstars/liftoff/inventory"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 )
 
@@ -19,84 +24,319 @@ type CreateServerModel struct {
 	ServerName    string `json:"serverName"`
 	DeployCountry string `json:"deployCountry"`
 	GithubLink    string `json:"githubLink"`
-	SshKeyName    string `json:"sshKeyName"`
+	// every key is added to authorized_keys of root
+	SshKeyNames []string `json:"sshKeyNames"`
+	// optional volume which gets attached and mounted via cloud-config
+	Volume *VolumeOption `json:"volume,omitempty"`
+	// optional firewall which gets applied to the server
+	Firewall *FirewallOption `json:"firewall,omitempty"`
+	// optional private network the server gets attached to
+	Network *NetworkOption `json:"network,omitempty"`
+	// optional existing primary ipv4 which the server keeps as public ip
+	PrimaryIP *PrimaryIPOption `json:"primaryIp,omitempty"`
+	// optional spread placement group, keeps the servers on different hosts
+	PlacementGroup *PlacementGroupOption `json:"placementGroup,omitempty"`
+	// the managed-by=liftoff label is always added
+	Labels map[string]string `json:"labels,omitempty"`
+	// defaults of the profile, empty ones use the smallest type, the docker-ce image and the datacenter of the country
+	ServerType string `json:"serverType,omitempty"`
+	Image      string `json:"image,omitempty"`
+	Location   string `json:"location,omitempty"`
+	// path of a cloud-config file merged into the generated one
+	CloudConfig string `json:"cloudConfig,omitempty"`
+	// profile of the config file, recorded in the inventory
+	Profile string `json:"profile,omitempty"`
 }
 
-func CreateServer(hetzner_cloud_api_key string, ssh_key_name string, serverName string) tea.Cmd {
+func CreateServer(ctx context.Context, hetzner_cloud_api_key string, serverOption CreateServerModel) tea.Cmd {
 	return func() tea.Msg {
-		return createHetznerServer(hetzner_cloud_api_key, ssh_key_name, serverName)
+		return createHetznerServer(ctx, hetzner_cloud_api_key, serverOption)
 	}
 }
 
-func createHetznerServer(hetzner_cloud_api_key string, ssh_key_name string, serverName string) tea.Msg {
+func createHetznerServer(ctx context.Context, hetzner_cloud_api_key string, serverOption CreateServerModel) tea.Msg {
 	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
-
-	serverOption := CreateServerModel{ServerName: serverName, DeployCountry: "germany", GithubLink: "", SshKeyName: ssh_key_name}
-	serverType, err := GetSmallestServer(client)
+	createCtx, cancel := context.WithTimeout(ctx, CreateTimeout)
+	defer cancel()
+	result, err := createServer(createCtx, client, serverOption)
+	if err != nil && Cancelled(err) {
+		log.Println("creating server cancelled", err)
+		state := "cancelled before the server was created"
+		if result.Server != nil {
+			state = serverState(client, result.Server.ID)
+		}
+		return OperationCancelledMsg{Operation: "create server " + serverOption.ServerName, State: state}
+	}
 	if err != nil {
 		log.Println(err.Error())
 		return SERVER_CREATED_Failed
 	}
+	// the tracker reports the progress, errors only end up in the activity panel and the log
+	go func() {
+		waitCtx, cancel := context.WithTimeout(ctx, ActionTimeout)
+		defer cancel()
+		if err := waitForActions(waitCtx, client, append(result.NextActions, result.Action)...); err != nil {
+			log.Println("create server action failed", err)
+		}
+	}()
+	return SERVER_CREATED_SUCCESS
+	//
+	// err = sshconnector.RunCommandsOnServer(serverCreateResult.Server.PublicNet.IPv4.IP.String(), []sshconnector.Command{})
+	// if err != nil {
+	// 	return
+	// }
+}
 
-	image, err := GetDockerCeImage(client)
+// createServer creates the server with all options, a network with a chosen ip is attached before it returns
+func createServer(ctx context.Context, client *hcloud.Client, serverOption CreateServerModel) (result hcloud.ServerCreateResult, err error) {
+	defer func() {
+		var id int64
+		if result.Server != nil {
+			id = result.Server.ID
+		}
+		auditOperation("server.create", id, createParams(serverOption), err)
+	}()
+	// a volume and firewall created for the server are removed again when there is no server
+	var createdVolume *hcloud.Volume
+	var createdFirewall *hcloud.Firewall
+	defer func() {
+		if err != nil && result.Server == nil {
+			rollbackCreated(client, createdVolume, createdFirewall)
+		}
+	}()
+	if serverOption.DeployCountry == "" {
+		serverOption.DeployCountry = CountryGermany
+	}
+	serverType, err := serverTypeForServer(ctx, client, serverOption.ServerType)
 	if err != nil {
-		log.Println(err.Error())
-		return SERVER_CREATED_Failed
+		return hcloud.ServerCreateResult{}, err
 	}
-	datacenter, err := GetDatacenter(client, serverOption.DeployCountry)
+
+	image, err := imageForServer(ctx, client, serverOption.Image, serverType.Architecture)
 	if err != nil {
-		log.Println(err.Error())
-		return SERVER_CREATED_Failed
+		return hcloud.ServerCreateResult{}, err
+	}
+	datacenter, err := datacenterForServer(ctx, client, serverOption)
+	if err != nil {
+		return hcloud.ServerCreateResult{}, err
 	}
 
-	sshKey, err := GetSshKey(client, ssh_key_name)
+	sshKeys, err := getSshKeys(ctx, client, serverOption.SshKeyNames)
 	if err != nil {
-		log.Println(err.Error())
-		return SERVER_CREATED_Failed
+		return hcloud.ServerCreateResult{}, err
 	}
 
 	automount := false
-	serverCreateResult, _, err := client.Server.Create(
-		context.Background(),
-		hcloud.ServerCreateOpts{
-			Name:       serverOption.ServerName,
-			Automount:  &automount, // volumes for mounting
-			Datacenter: datacenter,
-			Image:      image,
-			ServerType: serverType,
-			SSHKeys: []*hcloud.SSHKey{
-				sshKey,
-			},
-			// UserData: cloudConfig,
-		},
-	)
+	createOpts := hcloud.ServerCreateOpts{
+		Name:       serverOption.ServerName,
+		Automount:  &automount, // volumes are mounted by cloud-config
+		Datacenter: datacenter,
+		Image:      image,
+		ServerType: serverType,
+		SSHKeys:    sshKeys,
+		Labels:     withManagedByLabel(serverOption.Labels),
+	}
+	createOpts.Labels[LabelState] = StateCreated
+
+	var primaryIP *hcloud.PrimaryIP
+	if serverOption.PrimaryIP != nil {
+		primaryIP, err = primaryIPForServer(ctx, client, *serverOption.PrimaryIP)
+		if err != nil {
+			return hcloud.ServerCreateResult{}, err
+		}
+		// primary ips are bound to their datacenter
+		datacenter = primaryIP.Datacenter
+		createOpts.Datacenter = datacenter
+		createOpts.PublicNet = &hcloud.ServerCreatePublicNet{EnableIPv4: true, EnableIPv6: true, IPv4: primaryIP}
+	}
+
+	var cloudConfig cloudconfig.Config
+	if serverOption.CloudConfig != "" {
+		cloudConfig, err = cloudconfig.Load(serverOption.CloudConfig)
+		if err != nil {
+			return hcloud.ServerCreateResult{}, err
+		}
+	}
+	if serverOption.Volume != nil {
+		volume, created, err := volumeForServer(ctx, client, *serverOption.Volume, datacenter)
+		if created {
+			createdVolume = volume
+		}
+		if err != nil {
+			return hcloud.ServerCreateResult{}, err
+		}
+		// an existing volume decides where the server has to live
+		if volume.Location.Name != datacenter.Location.Name && primaryIP != nil {
+			return hcloud.ServerCreateResult{}, fmt.Errorf("volume is in %s but the primary ip in %s", volume.Location.Name, datacenter.Location.Name)
+		}
+		if volume.Location.Name != datacenter.Location.Name {
+			createOpts.Datacenter = nil
+			createOpts.Location = volume.Location
+		}
+		createOpts.Volumes = []*hcloud.Volume{volume}
+		cloudConfig.RunCmd = append(cloudConfig.RunCmd, cloudconfig.MountVolumeCommands(volume.ID, "/mnt/"+volume.Name, serverOption.Volume.Format)...)
+	}
+	if serverOption.Firewall != nil {
+		firewall, created, err := firewallForServer(ctx, client, *serverOption.Firewall)
+		if created {
+			createdFirewall = firewall
+		}
+		if err != nil {
+			return hcloud.ServerCreateResult{}, err
+		}
+		createOpts.Firewalls = []*hcloud.ServerCreateFirewall{{Firewall: *firewall}}
+	}
+	if serverOption.PlacementGroup != nil {
+		createOpts.PlacementGroup, err = placementGroupForServer(ctx, client, *serverOption.PlacementGroup)
+		if err != nil {
+			return hcloud.ServerCreateResult{}, err
+		}
+	}
+	var network *hcloud.Network
+	if serverOption.Network != nil {
+		network, _, err = client.Network.GetByID(ctx, serverOption.Network.NetworkID)
+		if err == nil && network == nil {
+			err = errors.New("network not found")
+		}
+		if err != nil {
+			return hcloud.ServerCreateResult{}, err
+		}
+		// a chosen ip can only be set by attaching after the creation
+		if serverOption.Network.IP == "" {
+			createOpts.Networks = []*hcloud.Network{network}
+		}
+	}
+	if serverOption.CloudConfig != "" || len(cloudConfig.RunCmd) > 0 {
+		createOpts.UserData, err = cloudconfig.UserData(cloudConfig)
+		if err != nil {
+			return hcloud.ServerCreateResult{}, err
+		}
+	}
+
+	serverCreateResult, _, err := client.Server.Create(ctx, createOpts)
 	if err != nil {
-		log.Println(err.Error())
-		return SERVER_CREATED_Failed
+		return hcloud.ServerCreateResult{}, err
 	}
+	recordCreated(serverCreateResult.Server, serverOption.Profile, createOpts.UserData)
 
-	go checkAction(client, serverCreateResult.Action.ID)
-	return SERVER_CREATED_SUCCESS
-	//
-	// RestartServer(client, serverCreateResult.Server.ID)
-	// err = sshconnector.RunCommandsOnServer(serverCreateResult.Server.PublicNet.IPv4.IP.String(), []sshconnector.Command{})
-	// if err != nil {
-	// 	return
-	// }
+	if serverOption.Network != nil && serverOption.Network.IP != "" {
+		err = waitForActions(ctx, client, serverCreateResult.Action)
+		if err == nil {
+			var action *hcloud.Action
+			action, err = attachServerToNetwork(ctx, client, serverCreateResult.Server, network, serverOption.Network.IP)
+			if err == nil {
+				err = waitForActions(ctx, client, action)
+			}
+		}
+		if err != nil {
+			return serverCreateResult, fmt.Errorf("server created but attaching to network failed: %w", err)
+		}
+	}
+	return serverCreateResult, nil
+}
+
+// recordCreated adds the server to the inventory, a failing inventory never fails the create
+func recordCreated(server *hcloud.Server, profile string, userData string) {
+	record := inventory.Server{ID: server.ID, Name: server.Name, Profile: profile, CloudConfig: userData, CreatedAt: server.Created}
+	if server.ServerType != nil {
+		record.ServerType = server.ServerType.Name
+	}
+	if server.Datacenter != nil && server.Datacenter.Location != nil {
+		record.Location = server.Datacenter.Location.Name
+	}
+	if err := inventory.DB.RecordCreated(record); err != nil {
+		log.Println("could not record server in the inventory", err)
+	}
+}
+
+func serverTypeForServer(ctx context.Context, client *hcloud.Client, name string) (*hcloud.ServerType, error) {
+	if name == "" {
+		return GetSmallestServer(ctx, client)
+	}
+	serverType, _, err := client.ServerType.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if serverType == nil {
+		return nil, fmt.Errorf("server type %s not found", name)
+	}
+	return serverType, nil
 }
 
-func checkAction(client *hcloud.Client, actionID int64) {
-	for {
-		action, _, err := client.Action.GetByID(context.Background(), actionID)
+func imageForServer(ctx context.Context, client *hcloud.Client, name string, architecture hcloud.Architecture) (*hcloud.Image, error) {
+	if name == "" {
+		return GetDockerCeImage(ctx, client)
+	}
+	image, _, err := client.Image.GetForArchitecture(ctx, name, architecture)
+	if err != nil {
+		return nil, err
+	}
+	if image == nil {
+		return nil, fmt.Errorf("image %s not found for %s", name, architecture)
+	}
+	return image, nil
+}
+
+// datacenterForServer picks the first datacenter of the location, without location the one of the country
+func datacenterForServer(ctx context.Context, client *hcloud.Client, serverOption CreateServerModel) (*hcloud.Datacenter, error) {
+	if serverOption.Location == "" {
+		return GetDatacenter(ctx, client, serverOption.DeployCountry)
+	}
+	datacenters, err := client.Datacenter.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, datacenter := range datacenters {
+		if datacenter.Location.Name == serverOption.Location {
+			return datacenter, nil
+		}
+	}
+	return nil, fmt.Errorf("no datacenter in location %s", serverOption.Location)
+}
+
+// volumeForServer returns the existing volume of the option or creates a new one next to the datacenter.
+// created is true for a new volume, it is returned with the error if waiting for it failed
+func volumeForServer(ctx context.Context, client *hcloud.Client, volumeOption VolumeOption, datacenter *hcloud.Datacenter) (volume *hcloud.Volume, created bool, err error) {
+	if volumeOption.VolumeID == 0 {
+		volume, err = createHetznerVolume(ctx, client, volumeOption, datacenter.Location)
+		var id int64
+		if volume != nil {
+			id = volume.ID
+		}
+		auditOperation("volume.create", id, map[string]string{"name": volumeOption.Name, "size": strconv.Itoa(volumeOption.Size), "location": datacenter.Location.Name}, err)
+		return volume, volume != nil, err
+	}
+	volume, _, err = client.Volume.GetByID(ctx, volumeOption.VolumeID)
+	if err != nil {
+		return nil, false, err
+	}
+	if volume == nil {
+		return nil, false, errors.New("volume not found")
+	}
+	if volume.Server != nil {
+		return nil, false, fmt.Errorf("volume %s is already attached to server %d", volume.Name, volume.Server.ID)
+	}
+	return volume, false, nil
+}
+
+// rollbackCreated removes the volume and the firewall a failed server create made. The context
+// of the create may be done already so a fresh one is used
+func rollbackCreated(client *hcloud.Client, volume *hcloud.Volume, firewall *hcloud.Firewall) {
+	ctx, cancel := context.WithTimeout(context.Background(), RequestTimeout)
+	defer cancel()
+	if volume != nil {
+		_, err := client.Volume.Delete(ctx, volume)
 		if err != nil {
-			log.Println("Checking action failed", actionID)
-			return
+			log.Println("could not remove the volume of the failed server create", err)
 		}
-		if action.Status == hcloud.ActionStatusSuccess || action.Status == hcloud.ActionStatusError {
-			log.Println("Action success")
-			return
+		auditOperation("volume.delete", volume.ID, map[string]string{"name": volume.Name, "reason": "server create failed"}, err)
+	}
+	if firewall != nil {
+		_, err := client.Firewall.Delete(ctx, firewall)
+		if err != nil {
+			log.Println("could not remove the firewall of the failed server create", err)
 		}
-		time.Sleep(5 * time.Second)
+		auditOperation("firewall.delete", firewall.ID, map[string]string{"name": firewall.Name, "reason": "server create failed"}, err)
 	}
 }
 
@@ -14,7 +14,7 @@ const (
 )
 
 // use interface types for country ???
-func GetDatacenter(client *hcloud.Client, country string) (*hcloud.Datacenter, error) {
+func GetDatacenter(ctx context.Context, client *hcloud.Client, country string) (*hcloud.Datacenter, error) {
 	var datacenterName string
 
 	switch country {
@@ -27,7 +27,7 @@ func GetDatacenter(client *hcloud.Client, country string) (*hcloud.Datacenter, e
 		return nil, errors.New("invalid country specified, must be 'us' or 'germany'")
 	}
 
-	datacenter, _, err := client.Datacenter.GetByName(context.Background(), datacenterName)
+	datacenter, _, err := client.Datacenter.GetByName(ctx, datacenterName)
 
 	if err != nil {
 		log.Println("error while getting datacenter")
@@ -2,47 +2,71 @@ package hetzner
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/inventory"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 )
 
-type ServerDeletedSuccessMsg struct{}
-type ServerDeletedErrorMsg struct{}
+type ServerDeletedSuccessMsg struct {
+	ServerID int64
+}
+type ServerDeletedErrorMsg struct {
+	ServerID int64
+	Err      error
+}
 
-func DeleteServer(hetzner_cloud_api_key string, serverID int64) tea.Cmd {
+// DeleteServer deletes the server and reports the progress of the delete action, progress may be nil
+func DeleteServer(ctx context.Context, hetzner_cloud_api_key string, serverID int64, progress func(int)) tea.Cmd {
 	return func() tea.Msg {
-		return deleteServerHetzner(hetzner_cloud_api_key, serverID)
+		ctx, cancel := context.WithTimeout(ctx, ActionTimeout)
+		defer cancel()
+		err := deleteServerHetzner(ctx, hetzner_cloud_api_key, serverID, progress)
+		if err != nil && Cancelled(err) {
+			client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+			err = fmt.Errorf("%w, %s", err, serverState(client, serverID))
+		}
+		if err != nil {
+			return ServerDeletedErrorMsg{ServerID: serverID, Err: err}
+		}
+		return ServerDeletedSuccessMsg{ServerID: serverID}
 	}
 }
-func deleteServerHetzner(hetzner_cloud_api_key string, serverID int64) tea.Msg {
+
+func deleteServerHetzner(ctx context.Context, hetzner_cloud_api_key string, serverID int64, progress func(int)) (err error) {
+	var params map[string]string
+	defer func() {
+		auditOperation("server.delete", serverID, params, err)
+	}()
 	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
-	server, _, err := client.Server.GetByID(context.Background(), serverID)
+	server, _, err := client.Server.GetByID(ctx, serverID)
 	if err != nil {
 		log.Println("could not get server for deleting", err)
-		return ServerDeletedErrorMsg{}
+		return err
 	}
-	_, _, err = client.Server.DeleteWithResult(context.Background(), server)
+	if server == nil {
+		return errors.New("server not found")
+	}
+	params = map[string]string{"name": server.Name}
+	if server.Protection.Delete {
+		return errors.New("delete protection is enabled")
+	}
+	result, _, err := client.Server.DeleteWithResult(ctx, server)
 	if err != nil {
 		log.Println("could not delete server", err)
-		return ServerDeletedErrorMsg{}
+		return err
 	}
 
-	return ServerDeletedSuccessMsg{}
-}
-func DeleteServer2(hetzner_cloud_api_key string, serverID int64) error {
-	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
-	server, _, err := client.Server.GetByID(context.Background(), serverID)
+	err = watchAction(ctx, client, result.Action, progress)
 	if err != nil {
-		log.Println("could not get server for deleting", err)
+		log.Println("delete action failed", err)
 		return err
 	}
-	_, _, err = client.Server.DeleteWithResult(context.Background(), server)
-	if err != nil {
-		log.Println("could not delete server", err)
-		return err
+	if err := inventory.DB.RecordDeleted(serverID); err != nil {
+		log.Println("could not record deletion in the inventory", err)
 	}
-
 	return nil
 }
@@ -0,0 +1,41 @@
+package hetzner
+
+import (
+	"context"
+	"log"
+
+	"github.com/crabstars/liftoff/inventory"
+	sshconnector "github.com/crabstars/liftoff/ssh"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// ResolvePeers replaces the {{peer:NAME}} references of the recipe with the private ips of the servers,
+// the servers are only listed when the recipe has a reference
+func ResolvePeers(ctx context.Context, hetzner_cloud_api_key string, recipe sshconnector.Recipe) (sshconnector.Recipe, error) {
+	if !recipe.HasPeers() {
+		return recipe, nil
+	}
+	peers, err := PeerPrivateIPs(ctx, hetzner_cloud_api_key, 0)
+	if err != nil {
+		return sshconnector.Recipe{}, err
+	}
+	return recipe.WithPeers(peers)
+}
+
+// DeployRecipe runs the recipe on the server, records the deployment in the audit log and the inventory
+// and sets the deployed labels. The peers of the recipe must be resolved with ResolvePeers already
+func DeployRecipe(ctx context.Context, hetzner_cloud_api_key string, server *hcloud.Server, recipe sshconnector.Recipe) error {
+	deployCtx, cancel := context.WithTimeout(ctx, DeployTimeout)
+	defer cancel()
+	commit, err := recipe.Deploy(deployCtx, server.PublicNet.IPv4.IP.String())
+	auditOperation("server.deploy", server.ID,
+		map[string]string{"name": server.Name, "recipe": recipe.Name, "repo": recipe.Repo, "commit": commit}, err)
+	if err != nil {
+		return err
+	}
+	err = inventory.DB.RecordDeployment(inventory.Deployment{ServerID: server.ID, Recipe: recipe.Name, Repo: recipe.Repo, Commit: commit})
+	if err != nil {
+		log.Println("could not record the deployment in the inventory", err)
+	}
+	return MarkDeployed(ctx, hetzner_cloud_api_key, server, recipe.App)
+}
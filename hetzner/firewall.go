@@ -0,0 +1,267 @@
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+const SshPort = "22"
+
+type FirewallsLoadedMsg struct {
+	Firewalls []*hcloud.Firewall
+	Err       error
+}
+
+// FirewallActionMsg is returned after a firewall was created, changed, applied or deleted
+type FirewallActionMsg struct {
+	Description string
+	Err         error
+}
+
+// FirewallOption describes the firewall which gets attached while creating a server.
+// If FirewallID is set the existing firewall is used, otherwise a new one is created
+// which allows ssh and the app port
+type FirewallOption struct {
+	FirewallID int64
+	Name       string
+	AppPort    string
+}
+
+func ListFirewalls(ctx context.Context, hetzner_cloud_api_key string) ([]*hcloud.Firewall, error) {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+	firewalls, err := client.Firewall.All(ctx)
+	if err != nil {
+		log.Println("could not get all firewalls", err)
+		return nil, err
+	}
+	return firewalls, nil
+}
+
+func LoadFirewalls(ctx context.Context, hetzner_cloud_api_key string) tea.Cmd {
+	return func() tea.Msg {
+		firewalls, err := ListFirewalls(ctx, hetzner_cloud_api_key)
+		return FirewallsLoadedMsg{Firewalls: firewalls, Err: err}
+	}
+}
+
+// ParseFirewallRule builds a rule from user input, cidrs are separated by comma or space
+func ParseFirewallRule(direction string, protocol string, port string, cidrs string) (hcloud.FirewallRule, error) {
+	rule := hcloud.FirewallRule{
+		Direction: hcloud.FirewallRuleDirection(strings.ToLower(direction)),
+		Protocol:  hcloud.FirewallRuleProtocol(strings.ToLower(protocol)),
+	}
+	if rule.Direction != hcloud.FirewallRuleDirectionIn && rule.Direction != hcloud.FirewallRuleDirectionOut {
+		return rule, errors.New("direction must be in or out")
+	}
+
+	switch rule.Protocol {
+	case hcloud.FirewallRuleProtocolTCP, hcloud.FirewallRuleProtocolUDP:
+		if err := validatePort(port); err != nil {
+			return rule, err
+		}
+		rule.Port = &port
+	case hcloud.FirewallRuleProtocolICMP, hcloud.FirewallRuleProtocolESP, hcloud.FirewallRuleProtocolGRE:
+		if port != "" {
+			return rule, fmt.Errorf("protocol %s has no port", rule.Protocol)
+		}
+	default:
+		return rule, errors.New("protocol must be tcp, udp, icmp, esp or gre")
+	}
+
+	var ipNets []net.IPNet
+	for _, cidr := range strings.FieldsFunc(cidrs, func(r rune) bool { return r == ',' || r == ' ' }) {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return rule, fmt.Errorf("invalid cidr %s", cidr)
+		}
+		ipNets = append(ipNets, *ipNet)
+	}
+	if len(ipNets) == 0 {
+		return rule, errors.New("at least one cidr is needed")
+	}
+	if rule.Direction == hcloud.FirewallRuleDirectionIn {
+		rule.SourceIPs = ipNets
+	} else {
+		rule.DestinationIPs = ipNets
+	}
+	return rule, nil
+}
+
+// validatePort accepts a single port or a range like 8000-8080
+func validatePort(port string) error {
+	parts := strings.Split(port, "-")
+	if len(parts) > 2 {
+		return fmt.Errorf("invalid port %s", port)
+	}
+	for _, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 1 || number > 65535 {
+			return fmt.Errorf("invalid port %s", port)
+		}
+	}
+	return nil
+}
+
+// FormatFirewallRule returns a short one line description of the rule
+func FormatFirewallRule(rule hcloud.FirewallRule) string {
+	port := "-"
+	if rule.Port != nil {
+		port = *rule.Port
+	}
+	ipNets := rule.SourceIPs
+	if rule.Direction == hcloud.FirewallRuleDirectionOut {
+		ipNets = rule.DestinationIPs
+	}
+	cidrs := make([]string, len(ipNets))
+	for i, ipNet := range ipNets {
+		cidrs[i] = ipNet.String()
+	}
+	return fmt.Sprintf("%s %s %s %s", rule.Direction, rule.Protocol, port, strings.Join(cidrs, ","))
+}
+
+// SshAndAppRules opens ssh and the app port for everyone
+func SshAndAppRules(appPort string) []hcloud.FirewallRule {
+	var rules []hcloud.FirewallRule
+	for _, port := range []string{SshPort, appPort} {
+		if port == "" {
+			continue
+		}
+		rule, err := ParseFirewallRule("in", "tcp", port, "0.0.0.0/0,::/0")
+		if err != nil {
+			log.Println("skipping firewall rule", err)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func CreateFirewall(ctx context.Context, hetzner_cloud_api_key string, name string, rules []hcloud.FirewallRule) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, ActionTimeout)
+		defer cancel()
+		client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+		firewall, err := createHetznerFirewall(ctx, client, name, rules)
+		params := map[string]string{"name": name, "rules": strconv.Itoa(len(rules))}
+		if err != nil {
+			auditOperation("firewall.create", 0, params, err)
+			return FirewallActionMsg{Description: "create firewall", Err: err}
+		}
+		auditOperation("firewall.create", firewall.ID, params, nil)
+		return FirewallActionMsg{Description: fmt.Sprintf("firewall %s created", firewall.Name)}
+	}
+}
+
+func createHetznerFirewall(ctx context.Context, client *hcloud.Client, name string, rules []hcloud.FirewallRule) (*hcloud.Firewall, error) {
+	result, _, err := client.Firewall.Create(ctx, hcloud.FirewallCreateOpts{Name: name, Rules: rules})
+	if err != nil {
+		log.Println("could not create firewall", err)
+		return nil, err
+	}
+	// the firewall exists even if waiting failed, the caller may remove it again
+	if err := waitForActions(ctx, client, result.Actions...); err != nil {
+		log.Println("creating firewall failed", err)
+		return result.Firewall, err
+	}
+	return result.Firewall, nil
+}
+
+// firewallForServer returns the existing firewall of the option or creates a new one for ssh and the app port.
+// created is true for a new firewall, it is returned with the error if waiting for it failed
+func firewallForServer(ctx context.Context, client *hcloud.Client, firewallOption FirewallOption) (firewall *hcloud.Firewall, created bool, err error) {
+	if firewallOption.FirewallID == 0 {
+		rules := SshAndAppRules(firewallOption.AppPort)
+		firewall, err = createHetznerFirewall(ctx, client, firewallOption.Name, rules)
+		var id int64
+		if firewall != nil {
+			id = firewall.ID
+		}
+		auditOperation("firewall.create", id, map[string]string{"name": firewallOption.Name, "rules": strconv.Itoa(len(rules))}, err)
+		return firewall, firewall != nil, err
+	}
+	firewall, _, err = client.Firewall.GetByID(ctx, firewallOption.FirewallID)
+	if err != nil {
+		return nil, false, err
+	}
+	if firewall == nil {
+		return nil, false, errors.New("firewall not found")
+	}
+	return firewall, false, nil
+}
+
+func SetFirewallRules(ctx context.Context, hetzner_cloud_api_key string, firewallID int64, rules []hcloud.FirewallRule) tea.Cmd {
+	return firewallAction(ctx, hetzner_cloud_api_key, firewallID, "firewall.set_rules", nil, "set rules of firewall", func(ctx context.Context, client *hcloud.Client, firewall *hcloud.Firewall) ([]*hcloud.Action, error) {
+		actions, _, err := client.Firewall.SetRules(ctx, firewall, hcloud.FirewallSetRulesOpts{Rules: rules})
+		return actions, err
+	})
+}
+
+func ApplyFirewallToServer(ctx context.Context, hetzner_cloud_api_key string, firewallID int64, serverID int64) tea.Cmd {
+	return applyFirewall(ctx, hetzner_cloud_api_key, firewallID, hcloud.FirewallResource{
+		Type:   hcloud.FirewallResourceTypeServer,
+		Server: &hcloud.FirewallResourceServer{ID: serverID},
+	})
+}
+
+func ApplyFirewallToLabelSelector(ctx context.Context, hetzner_cloud_api_key string, firewallID int64, selector string) tea.Cmd {
+	return applyFirewall(ctx, hetzner_cloud_api_key, firewallID, hcloud.FirewallResource{
+		Type:          hcloud.FirewallResourceTypeLabelSelector,
+		LabelSelector: &hcloud.FirewallResourceLabelSelector{Selector: selector},
+	})
+}
+
+func applyFirewall(ctx context.Context, hetzner_cloud_api_key string, firewallID int64, resource hcloud.FirewallResource) tea.Cmd {
+	return firewallAction(ctx, hetzner_cloud_api_key, firewallID, "firewall.apply", nil, "apply firewall", func(ctx context.Context, client *hcloud.Client, firewall *hcloud.Firewall) ([]*hcloud.Action, error) {
+		actions, _, err := client.Firewall.ApplyResources(ctx, firewall, []hcloud.FirewallResource{resource})
+		return actions, err
+	})
+}
+
+// RemoveFirewallResources removes the firewall from everything it is applied to
+func RemoveFirewallResources(ctx context.Context, hetzner_cloud_api_key string, firewallID int64) tea.Cmd {
+	return firewallAction(ctx, hetzner_cloud_api_key, firewallID, "firewall.remove_resources", nil, "remove resources of firewall", func(ctx context.Context, client *hcloud.Client, firewall *hcloud.Firewall) ([]*hcloud.Action, error) {
+		if len(firewall.AppliedTo) == 0 {
+			return nil, nil
+		}
+		actions, _, err := client.Firewall.RemoveResources(ctx, firewall, firewall.AppliedTo)
+		return actions, err
+	})
+}
+
+func DeleteFirewall(ctx context.Context, hetzner_cloud_api_key string, firewallID int64) tea.Cmd {
+	return firewallAction(ctx, hetzner_cloud_api_key, firewallID, "firewall.delete", nil, "delete firewall", func(ctx context.Context, client *hcloud.Client, firewall *hcloud.Firewall) ([]*hcloud.Action, error) {
+		if len(firewall.AppliedTo) > 0 {
+			return nil, errors.New("firewall is still applied, remove its resources first")
+		}
+		_, err := client.Firewall.Delete(ctx, firewall)
+		return nil, err
+	})
+}
+
+// firewallAction loads the firewall and runs the call on it with runAction
+func firewallAction(ctx context.Context, hetzner_cloud_api_key string, firewallID int64, operation string, params map[string]string, description string, call func(context.Context, *hcloud.Client, *hcloud.Firewall) ([]*hcloud.Action, error)) tea.Cmd {
+	return func() tea.Msg {
+		description, err := runAction(ctx, hetzner_cloud_api_key, firewallID, operation, params, description, func(ctx context.Context, client *hcloud.Client) (string, []*hcloud.Action, error) {
+			firewall, _, err := client.Firewall.GetByID(ctx, firewallID)
+			if err == nil && firewall == nil {
+				err = errors.New("firewall not found")
+			}
+			if err != nil {
+				return "", nil, err
+			}
+			actions, err := call(ctx, client, firewall)
+			return firewall.Name, actions, err
+		})
+		return FirewallActionMsg{Description: description, Err: err}
+	}
+}
@@ -0,0 +1,103 @@
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// FleetNumberPlaceholder is replaced with 1..count in the name pattern
+const FleetNumberPlaceholder = "{n}"
+
+// FleetCreatedMsg is returned after every server of the fleet was created or failed
+type FleetCreatedMsg struct {
+	Created int
+	Failed  int
+	// shared resources could not be prepared, no server was created
+	Err error
+}
+
+// FleetNames expands a pattern like api-{n} to api-1 ... api-count
+func FleetNames(pattern string, count int) ([]string, error) {
+	if count < 1 {
+		return nil, errors.New("count must be at least 1")
+	}
+	if count > 1 && !strings.Contains(pattern, FleetNumberPlaceholder) {
+		return nil, fmt.Errorf("name pattern needs %s to create more than one server", FleetNumberPlaceholder)
+	}
+	names := make([]string, count)
+	for i := range names {
+		names[i] = strings.ReplaceAll(pattern, FleetNumberPlaceholder, strconv.Itoa(i+1))
+	}
+	return names, nil
+}
+
+// CreateFleet creates one server per name with the same options. progress is called with the index of the name
+// and the new status, a failing server does not stop the others
+func CreateFleet(ctx context.Context, hetzner_cloud_api_key string, serverOption CreateServerModel, names []string, progress func(index int, status string, err error)) tea.Cmd {
+	return func() tea.Msg {
+		client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+		serverOption, err := prepareFleetOptions(ctx, client, serverOption)
+		if err != nil {
+			log.Println("could not prepare fleet", err)
+			return FleetCreatedMsg{Err: err}
+		}
+
+		created, failed := runParallel(ctx, len(names), func(index int) error {
+			err := createFleetServer(ctx, client, serverOption, names[index])
+			if err != nil {
+				log.Println("creating", names[index], "failed", err)
+			}
+			return err
+		}, progress)
+		return FleetCreatedMsg{Created: created, Failed: failed}
+	}
+}
+
+// prepareFleetOptions creates the shared firewall once and rejects options which can only belong to one server
+func prepareFleetOptions(ctx context.Context, client *hcloud.Client, serverOption CreateServerModel) (CreateServerModel, error) {
+	if serverOption.PrimaryIP != nil {
+		return serverOption, errors.New("a primary ip can not be shared by a fleet")
+	}
+	if serverOption.Volume != nil && serverOption.Volume.VolumeID != 0 {
+		return serverOption, errors.New("an existing volume can not be shared by a fleet")
+	}
+	if serverOption.Network != nil && serverOption.Network.IP != "" {
+		return serverOption, errors.New("a fixed private ip can not be shared by a fleet")
+	}
+	if serverOption.Firewall != nil && serverOption.Firewall.FirewallID == 0 {
+		firewall, _, err := firewallForServer(ctx, client, *serverOption.Firewall)
+		if err != nil {
+			return serverOption, err
+		}
+		serverOption.Firewall = &FirewallOption{FirewallID: firewall.ID, Name: firewall.Name}
+	}
+	return serverOption, nil
+}
+
+// createFleetServer creates the server and waits until it is running, every server gets its own new volume.
+// A cancelled creation reports the state the server was left in
+func createFleetServer(ctx context.Context, client *hcloud.Client, serverOption CreateServerModel, name string) error {
+	serverOption.ServerName = name
+	if serverOption.Volume != nil {
+		volume := *serverOption.Volume
+		volume.Name = name + "-data"
+		serverOption.Volume = &volume
+	}
+	ctx, cancel := context.WithTimeout(ctx, CreateTimeout)
+	defer cancel()
+	result, err := createServer(ctx, client, serverOption)
+	if err == nil {
+		err = waitForActions(ctx, client, append(result.NextActions, result.Action)...)
+	}
+	if err != nil && Cancelled(err) && result.Server != nil {
+		return fmt.Errorf("%w, %s", err, serverState(client, result.Server.ID))
+	}
+	return err
+}
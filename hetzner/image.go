@@ -7,9 +7,9 @@ import (
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 )
 
-func GetDockerCeImage(client *hcloud.Client) (*hcloud.Image, error) {
+func GetDockerCeImage(ctx context.Context, client *hcloud.Client) (*hcloud.Image, error) {
 
-	image, _, err := client.Image.GetByID(context.Background(), 40093247) // for intel, use 105888141 for amd
+	image, _, err := client.Image.GetByID(ctx, 40093247) // for intel, use 105888141 for amd
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,265 @@
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+const (
+	IPKindPrimary  = "primary"
+	IPKindFloating = "floating"
+)
+
+// IP is a primary or a floating ip, both are shown in one list
+type IP struct {
+	Kind     string
+	ID       int64
+	Name     string
+	IP       string
+	Type     string
+	Location string
+	// 0 if the ip is not assigned
+	ServerID int64
+	DNSPtr   string
+}
+
+type IPsLoadedMsg struct {
+	IPs []IP
+	Err error
+}
+
+// IPActionMsg is returned after an ip was allocated, assigned, unassigned, changed or deleted
+type IPActionMsg struct {
+	Description string
+	Err         error
+}
+
+// PrimaryIPOption reuses an existing unassigned primary ipv4 for a new server, so its dns keeps working
+type PrimaryIPOption struct {
+	PrimaryIPID int64
+	IP          string
+}
+
+func ListIPs(ctx context.Context, hetzner_cloud_api_key string) ([]IP, error) {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+	primaryIPs, err := client.PrimaryIP.All(ctx)
+	if err != nil {
+		log.Println("could not get all primary ips", err)
+		return nil, err
+	}
+	floatingIPs, err := client.FloatingIP.All(ctx)
+	if err != nil {
+		log.Println("could not get all floating ips", err)
+		return nil, err
+	}
+
+	ips := make([]IP, 0, len(primaryIPs)+len(floatingIPs))
+	for _, primaryIP := range primaryIPs {
+		ip := IP{
+			Kind:     IPKindPrimary,
+			ID:       primaryIP.ID,
+			Name:     primaryIP.Name,
+			IP:       primaryIP.IP.String(),
+			Type:     string(primaryIP.Type),
+			Location: primaryIP.Datacenter.Location.Name,
+			DNSPtr:   primaryIP.DNSPtr[primaryIP.IP.String()],
+		}
+		if primaryIP.AssigneeType == "server" {
+			ip.ServerID = primaryIP.AssigneeID
+		}
+		ips = append(ips, ip)
+	}
+	for _, floatingIP := range floatingIPs {
+		ip := IP{
+			Kind:     IPKindFloating,
+			ID:       floatingIP.ID,
+			Name:     floatingIP.Name,
+			IP:       floatingIP.IP.String(),
+			Type:     string(floatingIP.Type),
+			Location: floatingIP.HomeLocation.Name,
+			DNSPtr:   floatingIP.DNSPtr[floatingIP.IP.String()],
+		}
+		if floatingIP.Server != nil {
+			ip.ServerID = floatingIP.Server.ID
+		}
+		ips = append(ips, ip)
+	}
+	return ips, nil
+}
+
+func LoadIPs(ctx context.Context, hetzner_cloud_api_key string) tea.Cmd {
+	return func() tea.Msg {
+		ips, err := ListIPs(ctx, hetzner_cloud_api_key)
+		return IPsLoadedMsg{IPs: ips, Err: err}
+	}
+}
+
+// AllocateIP creates a new primary or floating ip of type ipv4 or ipv6 in the country,
+// a budget above 0 fails the allocation with a *BudgetError if the ip exceeds it
+func AllocateIP(ctx context.Context, hetzner_cloud_api_key string, kind string, name string, ipType string, country string, budget float64) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, ActionTimeout)
+		defer cancel()
+		description := fmt.Sprintf("allocate %s ip", kind)
+		params := map[string]string{"kind": kind, "type": ipType, "name": name, "country": country}
+		if ipType != string(hcloud.PrimaryIPTypeIPv4) && ipType != string(hcloud.PrimaryIPTypeIPv6) {
+			err := errors.New("type must be ipv4 or ipv6")
+			auditOperation("ip.allocate", 0, params, err)
+			return IPActionMsg{Description: description, Err: err}
+		}
+		client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+		datacenter, err := GetDatacenter(ctx, client, country)
+		if err == nil && budget > 0 {
+			var prices Prices
+			prices, err = GetPrices(ctx, client, hetzner_cloud_api_key)
+			if err == nil {
+				cost := prices.PrimaryIP(ipType, datacenter.Location.Name)
+				if kind == IPKindFloating {
+					cost = prices.FloatingIP(ipType, datacenter.Location.Name)
+				}
+				err = checkAdded(ctx, hetzner_cloud_api_key, budget, cost)
+			}
+		}
+		if err != nil {
+			auditOperation("ip.allocate", 0, params, err)
+			return IPActionMsg{Description: description, Err: err}
+		}
+
+		var action *hcloud.Action
+		var ipID int64
+		switch kind {
+		case IPKindPrimary:
+			autoDelete := false
+			var result *hcloud.PrimaryIPCreateResult
+			result, _, err = client.PrimaryIP.Create(ctx, hcloud.PrimaryIPCreateOpts{
+				Name:         name,
+				Type:         hcloud.PrimaryIPType(ipType),
+				AssigneeType: "server",
+				Datacenter:   datacenter.Name,
+				AutoDelete:   &autoDelete,
+			})
+			if err == nil {
+				action, ipID = result.Action, result.PrimaryIP.ID
+			}
+		case IPKindFloating:
+			var result hcloud.FloatingIPCreateResult
+			result, _, err = client.FloatingIP.Create(ctx, hcloud.FloatingIPCreateOpts{
+				Name:         &name,
+				Type:         hcloud.FloatingIPType(ipType),
+				HomeLocation: datacenter.Location,
+			})
+			action = result.Action
+			if err == nil {
+				ipID = result.FloatingIP.ID
+			}
+		default:
+			err = errors.New("kind must be primary or floating")
+		}
+		if err == nil {
+			err = waitForActions(ctx, client, action)
+		}
+		if err != nil {
+			log.Println(description, "failed", err)
+		}
+		auditOperation("ip.allocate", ipID, params, err)
+		return IPActionMsg{Description: description + " " + name, Err: err}
+	}
+}
+
+func AssignIP(ctx context.Context, hetzner_cloud_api_key string, ip IP, serverID int64) tea.Cmd {
+	return ipAction(ctx, hetzner_cloud_api_key, ip, "ip.assign", nil, "assign", func(ctx context.Context, client *hcloud.Client) (*hcloud.Action, error) {
+		if ip.Kind == IPKindPrimary {
+			// hetzner only assigns primary ips to powered off servers
+			action, _, err := client.PrimaryIP.Assign(ctx, hcloud.PrimaryIPAssignOpts{ID: ip.ID, AssigneeID: serverID, AssigneeType: "server"})
+			return action, err
+		}
+		action, _, err := client.FloatingIP.Assign(ctx, &hcloud.FloatingIP{ID: ip.ID}, &hcloud.Server{ID: serverID})
+		return action, err
+	})
+}
+
+func UnassignIP(ctx context.Context, hetzner_cloud_api_key string, ip IP) tea.Cmd {
+	return ipAction(ctx, hetzner_cloud_api_key, ip, "ip.unassign", nil, "unassign", func(ctx context.Context, client *hcloud.Client) (*hcloud.Action, error) {
+		if ip.ServerID == 0 {
+			return nil, errors.New("ip is not assigned")
+		}
+		if ip.Kind == IPKindPrimary {
+			action, _, err := client.PrimaryIP.Unassign(ctx, ip.ID)
+			return action, err
+		}
+		action, _, err := client.FloatingIP.Unassign(ctx, &hcloud.FloatingIP{ID: ip.ID})
+		return action, err
+	})
+}
+
+// SetReverseDNS sets the ptr record of address, which has to be the ip itself or an ip of the ipv6 network.
+// An empty ptr resets the record to the hetzner default
+func SetReverseDNS(ctx context.Context, hetzner_cloud_api_key string, ip IP, address string, ptr string) tea.Cmd {
+	return ipAction(ctx, hetzner_cloud_api_key, ip, "ip.set_reverse_dns", map[string]string{"ptr": ptr}, "set reverse dns of", func(ctx context.Context, client *hcloud.Client) (*hcloud.Action, error) {
+		if net.ParseIP(address) == nil {
+			return nil, fmt.Errorf("invalid ip %s", address)
+		}
+		if ip.Kind == IPKindPrimary {
+			action, _, err := client.PrimaryIP.ChangeDNSPtr(ctx, hcloud.PrimaryIPChangeDNSPtrOpts{ID: ip.ID, IP: address, DNSPtr: ptr})
+			return action, err
+		}
+		var dnsPtr *string
+		if ptr != "" {
+			dnsPtr = &ptr
+		}
+		action, _, err := client.FloatingIP.ChangeDNSPtr(ctx, &hcloud.FloatingIP{ID: ip.ID}, address, dnsPtr)
+		return action, err
+	})
+}
+
+func DeleteIP(ctx context.Context, hetzner_cloud_api_key string, ip IP) tea.Cmd {
+	return ipAction(ctx, hetzner_cloud_api_key, ip, "ip.delete", nil, "delete", func(ctx context.Context, client *hcloud.Client) (*hcloud.Action, error) {
+		if ip.ServerID != 0 {
+			return nil, errors.New("ip is still assigned, unassign it first")
+		}
+		var err error
+		if ip.Kind == IPKindPrimary {
+			_, err = client.PrimaryIP.Delete(ctx, &hcloud.PrimaryIP{ID: ip.ID})
+		} else {
+			_, err = client.FloatingIP.Delete(ctx, &hcloud.FloatingIP{ID: ip.ID})
+		}
+		return nil, err
+	})
+}
+
+// ipAction runs the call on the ip with runAction
+func ipAction(ctx context.Context, hetzner_cloud_api_key string, ip IP, operation string, params map[string]string, description string, call func(context.Context, *hcloud.Client) (*hcloud.Action, error)) tea.Cmd {
+	return func() tea.Msg {
+		description, err := runAction(ctx, hetzner_cloud_api_key, ip.ID, operation, params, description, func(ctx context.Context, client *hcloud.Client) (string, []*hcloud.Action, error) {
+			action, err := call(ctx, client)
+			return fmt.Sprintf("%s ip %s", ip.Kind, ip.IP), []*hcloud.Action{action}, err
+		})
+		return IPActionMsg{Description: description, Err: err}
+	}
+}
+
+// primaryIPForServer loads the primary ip of the option and makes sure it is free
+func primaryIPForServer(ctx context.Context, client *hcloud.Client, primaryIPOption PrimaryIPOption) (*hcloud.PrimaryIP, error) {
+	primaryIP, _, err := client.PrimaryIP.GetByID(ctx, primaryIPOption.PrimaryIPID)
+	if err != nil {
+		return nil, err
+	}
+	if primaryIP == nil {
+		return nil, errors.New("primary ip not found")
+	}
+	if primaryIP.AssigneeID != 0 {
+		return nil, fmt.Errorf("primary ip %s is already assigned to server %d", primaryIP.IP, primaryIP.AssigneeID)
+	}
+	if primaryIP.Type != hcloud.PrimaryIPTypeIPv4 {
+		return nil, errors.New("only ipv4 primary ips can be reused")
+	}
+	return primaryIP, nil
+}
@@ -0,0 +1,153 @@
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// every server created by liftoff gets this label. It and the liftoff-* labels are reserved, see IsReservedLabel
+const (
+	LabelManagedBy      = "managed-by"
+	LabelManagedByValue = "liftoff"
+)
+
+// liftoff tracks the provisioning of its servers and the deployed app in labels
+const (
+	LabelState    = "liftoff-state"
+	LabelApp      = "liftoff-app"
+	StateCreated  = "created"
+	StateDeployed = "deployed"
+)
+
+type ServerLabelsUpdatedMsg struct {
+	ServerID int64
+	Err      error
+}
+
+// IsReservedLabel is true for the labels liftoff sets itself. ParseLabels rejects them,
+// FormatLabels hides them and the label editor keeps them
+func IsReservedLabel(key string) bool {
+	return key == LabelManagedBy || strings.HasPrefix(key, "liftoff-")
+}
+
+// ParseLabels reads labels in the form "env=staging, project=api" which are set on a server
+func ParseLabels(input string) (map[string]string, error) {
+	labels, err := ParseLabelFilter(input)
+	if err != nil {
+		return nil, err
+	}
+	for key := range labels {
+		if IsReservedLabel(key) {
+			return nil, fmt.Errorf("label %s is reserved for liftoff", key)
+		}
+	}
+	return labels, nil
+}
+
+// ParseLabelFilter reads labels like ParseLabels, a filter may select by the reserved labels too
+func ParseLabelFilter(input string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, pair := range strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == ' ' }) {
+		key, value, found := strings.Cut(pair, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("label %s must look like key=value", pair)
+		}
+		labels[key] = value
+	}
+
+	validate := make(map[string]interface{}, len(labels))
+	for key, value := range labels {
+		validate[key] = value
+	}
+	if _, err := hcloud.ValidateResourceLabels(validate); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// FormatLabels is the counterpart of ParseLabels, the keys are sorted and the reserved ones hidden
+func FormatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		if IsReservedLabel(key) {
+			continue
+		}
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+// withManagedByLabel returns a copy of the labels with the liftoff label added
+func withManagedByLabel(labels map[string]string) map[string]string {
+	result := make(map[string]string, len(labels)+1)
+	for key, value := range labels {
+		result[key] = value
+	}
+	result[LabelManagedBy] = LabelManagedByValue
+	return result
+}
+
+// UpdateServerLabels replaces the labels of the server, the reserved ones are kept
+func UpdateServerLabels(ctx context.Context, hetzner_cloud_api_key string, serverID int64, labels map[string]string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, ActionTimeout)
+		defer cancel()
+		client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+		server, _, err := client.Server.GetByID(ctx, serverID)
+		if err == nil && server == nil {
+			err = errors.New("server not found")
+		}
+		params := map[string]string{"labels": FormatLabels(labels)}
+		if err == nil {
+			params["name"] = server.Name
+			updated := make(map[string]string, len(labels)+len(server.Labels))
+			for key, value := range server.Labels {
+				if IsReservedLabel(key) {
+					updated[key] = value
+				}
+			}
+			for key, value := range labels {
+				updated[key] = value
+			}
+			_, _, err = client.Server.Update(ctx, server, hcloud.ServerUpdateOpts{Labels: updated})
+		}
+		if err != nil {
+			log.Println("could not update labels", err)
+		}
+		// same operation as the bulk label change
+		auditOperation("server.label", serverID, params, err)
+		return ServerLabelsUpdatedMsg{ServerID: serverID, Err: err}
+	}
+}
+
+// ProvisioningState is the state hetzner reports while the server changes, otherwise the liftoff state label
+func ProvisioningState(server *hcloud.Server) string {
+	switch server.Status {
+	case hcloud.ServerStatusInitializing, hcloud.ServerStatusStarting, hcloud.ServerStatusRebuilding, hcloud.ServerStatusMigrating, hcloud.ServerStatusDeleting:
+		return string(server.Status)
+	}
+	return server.Labels[LabelState]
+}
+
+// MarkDeployed sets the state and app labels after a recipe ran on the server, the other labels are kept
+func MarkDeployed(ctx context.Context, hetzner_cloud_api_key string, server *hcloud.Server, app string) error {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+	labels := make(map[string]string, len(server.Labels)+2)
+	for key, value := range server.Labels {
+		labels[key] = value
+	}
+	labels[LabelState] = StateDeployed
+	labels[LabelApp] = app
+	_, _, err := client.Server.Update(ctx, server, hcloud.ServerUpdateOpts{Labels: labels})
+	return err
+}
@@ -0,0 +1,52 @@
+package hetzner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLabels(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		want       map[string]string
+		wantErr    bool
+		wantFilter bool
+	}{
+		{"empty", "", map[string]string{}, false, true},
+		{"comma and space separated", "env=staging, project=api", map[string]string{"env": "staging", "project": "api"}, false, true},
+		{"empty value", "env=", map[string]string{"env": ""}, false, true},
+		{"missing value", "env", nil, true, false},
+		{"invalid key", "-env=staging", nil, true, false},
+		{"state is reserved", "liftoff-state=deployed", nil, true, true},
+		{"app is reserved", "env=staging, liftoff-app=web", nil, true, true},
+		{"managed-by is reserved", "managed-by=me", nil, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLabels(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLabels(%q) error = %v, want an error %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLabels(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+			if _, err := ParseLabelFilter(tt.input); (err == nil) != tt.wantFilter {
+				t.Errorf("ParseLabelFilter(%q) error = %v, want it to parse %v", tt.input, err, tt.wantFilter)
+			}
+		})
+	}
+}
+
+func TestFormatLabels(t *testing.T) {
+	labels := map[string]string{
+		"project":      "shop",
+		"env":          "staging",
+		LabelManagedBy: LabelManagedByValue,
+		LabelState:     StateDeployed,
+		LabelApp:       "web",
+	}
+	if got, want := FormatLabels(labels), "env=staging, project=shop"; got != want {
+		t.Errorf("FormatLabels() = %q, want %q", got, want)
+	}
+}
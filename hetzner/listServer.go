@@ -3,7 +3,10 @@ package hetzner
 import (
 	"context"
 	"log"
+	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/inventory"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 )
 
@@ -18,9 +21,12 @@ import (
 // 	ServerTypeCores
 // }
 
-func ListServer(hetzner_cloud_api_key string) ([]*hcloud.Server, error) {
+// ListServer returns all servers matching the label selector, an empty selector matches all servers
+func ListServer(ctx context.Context, hetzner_cloud_api_key string, labelSelector string) ([]*hcloud.Server, error) {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
 	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
-	servers, err := client.Server.All(context.Background())
+	servers, err := client.Server.AllWithOpts(ctx, hcloud.ServerListOpts{ListOpts: hcloud.ListOpts{LabelSelector: labelSelector}})
 	if err != nil {
 		log.Println("could not get all server", err)
 		return nil, err
@@ -28,6 +34,18 @@ func ListServer(hetzner_cloud_api_key string) ([]*hcloud.Server, error) {
 	return servers, nil
 }
 
+// RecordMissingServers marks the servers of the inventory as deleted which are not in the complete server
+// list of the profile, e.g. because they were deleted in the hetzner console
+func RecordMissingServers(profile string, servers []*hcloud.Server, listedAt time.Time) {
+	ids := make([]int64, len(servers))
+	for i, server := range servers {
+		ids[i] = server.ID
+	}
+	if err := inventory.DB.RecordMissing(profile, ids, listedAt); err != nil {
+		log.Println("could not update the inventory", err)
+	}
+}
+
 // func ListServer(hetzner_cloud_api_key string) []table.Row {
 // 	var rows []table.Row
 // 	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
@@ -42,3 +60,15 @@ func ListServer(hetzner_cloud_api_key string) ([]*hcloud.Server, error) {
 // 	}
 // 	return rows
 // }
+
+type ServersLoadedMsg struct {
+	Servers []*hcloud.Server
+	Err     error
+}
+
+func LoadServers(ctx context.Context, hetzner_cloud_api_key string) tea.Cmd {
+	return func() tea.Msg {
+		servers, err := ListServer(ctx, hetzner_cloud_api_key, "")
+		return ServersLoadedMsg{Servers: servers, Err: err}
+	}
+}
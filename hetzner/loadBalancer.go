@@ -0,0 +1,324 @@
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// smallest load balancer type, enough for a few app servers
+const DefaultLoadBalancerType = "lb11"
+
+type LoadBalancersLoadedMsg struct {
+	LoadBalancers []*hcloud.LoadBalancer
+	Err           error
+}
+
+// LoadBalancerActionMsg is returned after a load balancer, target or service was created, changed or deleted
+type LoadBalancerActionMsg struct {
+	Description string
+	Err         error
+}
+
+// LoadBalancerServiceOption describes a http or https service forwarding to the app port of the targets.
+// https uses a managed certificate for Domain, which is created if it does not exist yet
+type LoadBalancerServiceOption struct {
+	Protocol        string
+	ListenPort      string
+	DestinationPort string
+	HealthPath      string
+	Domain          string
+}
+
+func ListLoadBalancers(ctx context.Context, hetzner_cloud_api_key string) ([]*hcloud.LoadBalancer, error) {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+	loadBalancers, err := client.LoadBalancer.All(ctx)
+	if err != nil {
+		log.Println("could not get all load balancers", err)
+		return nil, err
+	}
+	return loadBalancers, nil
+}
+
+func LoadLoadBalancers(ctx context.Context, hetzner_cloud_api_key string) tea.Cmd {
+	return func() tea.Msg {
+		loadBalancers, err := ListLoadBalancers(ctx, hetzner_cloud_api_key)
+		return LoadBalancersLoadedMsg{LoadBalancers: loadBalancers, Err: err}
+	}
+}
+
+// CreateLoadBalancer creates a load balancer without targets, a budget above 0 fails the create with a *BudgetError if it exceeds it
+func CreateLoadBalancer(ctx context.Context, hetzner_cloud_api_key string, name string, loadBalancerType string, country string, budget float64) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, ActionTimeout)
+		defer cancel()
+		description := "create load balancer"
+		params := map[string]string{"name": name, "type": loadBalancerType, "country": country}
+		client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+		datacenter, err := GetDatacenter(ctx, client, country)
+		if err != nil {
+			auditOperation("load_balancer.create", 0, params, err)
+			return LoadBalancerActionMsg{Description: description, Err: err}
+		}
+		lbType, _, err := client.LoadBalancerType.GetByName(ctx, loadBalancerType)
+		if err == nil && lbType == nil {
+			err = fmt.Errorf("load balancer type %s not found", loadBalancerType)
+		}
+		if err == nil && budget > 0 {
+			var prices Prices
+			var cost Cost
+			prices, err = GetPrices(ctx, client, hetzner_cloud_api_key)
+			if err == nil {
+				cost, err = prices.LoadBalancer(lbType.Name, datacenter.Location.Name)
+			}
+			if err == nil {
+				err = checkAdded(ctx, hetzner_cloud_api_key, budget, cost)
+			}
+		}
+		if err != nil {
+			auditOperation("load_balancer.create", 0, params, err)
+			return LoadBalancerActionMsg{Description: description, Err: err}
+		}
+		result, _, err := client.LoadBalancer.Create(ctx, hcloud.LoadBalancerCreateOpts{
+			Name:             name,
+			LoadBalancerType: lbType,
+			Location:         datacenter.Location,
+			Labels:           withManagedByLabel(nil),
+		})
+		if err == nil {
+			err = waitForActions(ctx, client, result.Action)
+		}
+		if err != nil {
+			log.Println("could not create load balancer", err)
+			auditOperation("load_balancer.create", 0, params, err)
+			return LoadBalancerActionMsg{Description: description, Err: err}
+		}
+		auditOperation("load_balancer.create", result.LoadBalancer.ID, params, nil)
+		return LoadBalancerActionMsg{Description: fmt.Sprintf("load balancer %s created", name)}
+	}
+}
+
+// AddServerTarget adds the server over its public ip
+func AddServerTarget(ctx context.Context, hetzner_cloud_api_key string, loadBalancerID int64, serverID int64) tea.Cmd {
+	return loadBalancerAction(ctx, hetzner_cloud_api_key, loadBalancerID, "load_balancer.add_target", map[string]string{"server": strconv.FormatInt(serverID, 10)}, "add server target to", func(ctx context.Context, client *hcloud.Client, loadBalancer *hcloud.LoadBalancer) (*hcloud.Action, error) {
+		action, _, err := client.LoadBalancer.AddServerTarget(ctx, loadBalancer, hcloud.LoadBalancerAddServerTargetOpts{Server: &hcloud.Server{ID: serverID}})
+		return action, err
+	})
+}
+
+// AddLabelSelectorTarget adds all servers matching the selector, new servers with the labels are picked up automatically
+func AddLabelSelectorTarget(ctx context.Context, hetzner_cloud_api_key string, loadBalancerID int64, selector string) tea.Cmd {
+	return loadBalancerAction(ctx, hetzner_cloud_api_key, loadBalancerID, "load_balancer.add_target", map[string]string{"selector": selector}, "add label selector target to", func(ctx context.Context, client *hcloud.Client, loadBalancer *hcloud.LoadBalancer) (*hcloud.Action, error) {
+		if strings.TrimSpace(selector) == "" {
+			return nil, errors.New("label selector is required")
+		}
+		action, _, err := client.LoadBalancer.AddLabelSelectorTarget(ctx, loadBalancer, hcloud.LoadBalancerAddLabelSelectorTargetOpts{Selector: selector})
+		return action, err
+	})
+}
+
+func RemoveLoadBalancerTarget(ctx context.Context, hetzner_cloud_api_key string, loadBalancerID int64, target hcloud.LoadBalancerTarget) tea.Cmd {
+	return loadBalancerAction(ctx, hetzner_cloud_api_key, loadBalancerID, "load_balancer.remove_target", nil, "remove target from", func(ctx context.Context, client *hcloud.Client, loadBalancer *hcloud.LoadBalancer) (*hcloud.Action, error) {
+		var action *hcloud.Action
+		var err error
+		switch target.Type {
+		case hcloud.LoadBalancerTargetTypeServer:
+			action, _, err = client.LoadBalancer.RemoveServerTarget(ctx, loadBalancer, target.Server.Server)
+		case hcloud.LoadBalancerTargetTypeLabelSelector:
+			action, _, err = client.LoadBalancer.RemoveLabelSelectorTarget(ctx, loadBalancer, target.LabelSelector.Selector)
+		case hcloud.LoadBalancerTargetTypeIP:
+			action, _, err = client.LoadBalancer.RemoveIPTarget(ctx, loadBalancer, net.ParseIP(target.IP.IP))
+		default:
+			err = fmt.Errorf("unknown target type %s", target.Type)
+		}
+		return action, err
+	})
+}
+
+// AddLoadBalancerService adds a http or https service with a http health check on the destination port
+func AddLoadBalancerService(ctx context.Context, hetzner_cloud_api_key string, loadBalancerID int64, serviceOption LoadBalancerServiceOption) tea.Cmd {
+	return loadBalancerAction(ctx, hetzner_cloud_api_key, loadBalancerID, "load_balancer.add_service", nil, "add service to", func(ctx context.Context, client *hcloud.Client, loadBalancer *hcloud.LoadBalancer) (*hcloud.Action, error) {
+		opts, err := serviceOpts(ctx, client, serviceOption)
+		if err != nil {
+			return nil, err
+		}
+		action, _, err := client.LoadBalancer.AddService(ctx, loadBalancer, opts)
+		return action, err
+	})
+}
+
+func DeleteLoadBalancerService(ctx context.Context, hetzner_cloud_api_key string, loadBalancerID int64, listenPort int) tea.Cmd {
+	return loadBalancerAction(ctx, hetzner_cloud_api_key, loadBalancerID, "load_balancer.delete_service", nil, "delete service of", func(ctx context.Context, client *hcloud.Client, loadBalancer *hcloud.LoadBalancer) (*hcloud.Action, error) {
+		action, _, err := client.LoadBalancer.DeleteService(ctx, loadBalancer, listenPort)
+		return action, err
+	})
+}
+
+func DeleteLoadBalancer(ctx context.Context, hetzner_cloud_api_key string, loadBalancerID int64) tea.Cmd {
+	return loadBalancerAction(ctx, hetzner_cloud_api_key, loadBalancerID, "load_balancer.delete", nil, "delete load balancer", func(ctx context.Context, client *hcloud.Client, loadBalancer *hcloud.LoadBalancer) (*hcloud.Action, error) {
+		_, err := client.LoadBalancer.Delete(ctx, loadBalancer)
+		return nil, err
+	})
+}
+
+// loadBalancerAction loads the load balancer and runs the call on it with runAction
+func loadBalancerAction(ctx context.Context, hetzner_cloud_api_key string, loadBalancerID int64, operation string, params map[string]string, description string, call func(context.Context, *hcloud.Client, *hcloud.LoadBalancer) (*hcloud.Action, error)) tea.Cmd {
+	return func() tea.Msg {
+		description, err := runAction(ctx, hetzner_cloud_api_key, loadBalancerID, operation, params, description, func(ctx context.Context, client *hcloud.Client) (string, []*hcloud.Action, error) {
+			loadBalancer, _, err := client.LoadBalancer.GetByID(ctx, loadBalancerID)
+			if err == nil && loadBalancer == nil {
+				err = errors.New("load balancer not found")
+			}
+			if err != nil {
+				return "", nil, err
+			}
+			action, err := call(ctx, client, loadBalancer)
+			return loadBalancer.Name, []*hcloud.Action{action}, err
+		})
+		return LoadBalancerActionMsg{Description: description, Err: err}
+	}
+}
+
+func serviceOpts(ctx context.Context, client *hcloud.Client, serviceOption LoadBalancerServiceOption) (hcloud.LoadBalancerAddServiceOpts, error) {
+	protocol := hcloud.LoadBalancerServiceProtocol(serviceOption.Protocol)
+	if protocol != hcloud.LoadBalancerServiceProtocolHTTP && protocol != hcloud.LoadBalancerServiceProtocolHTTPS {
+		return hcloud.LoadBalancerAddServiceOpts{}, errors.New("protocol must be http or https")
+	}
+	listenPort, err := parsePort(serviceOption.ListenPort)
+	if err != nil {
+		return hcloud.LoadBalancerAddServiceOpts{}, err
+	}
+	destinationPort, err := parsePort(serviceOption.DestinationPort)
+	if err != nil {
+		return hcloud.LoadBalancerAddServiceOpts{}, err
+	}
+	healthPath := serviceOption.HealthPath
+	if healthPath == "" {
+		healthPath = "/"
+	}
+	interval, timeout, retries := 15*time.Second, 10*time.Second, 3
+	opts := hcloud.LoadBalancerAddServiceOpts{
+		Protocol:        protocol,
+		ListenPort:      &listenPort,
+		DestinationPort: &destinationPort,
+		HealthCheck: &hcloud.LoadBalancerAddServiceOptsHealthCheck{
+			Protocol: hcloud.LoadBalancerServiceProtocolHTTP,
+			Port:     &destinationPort,
+			Interval: &interval,
+			Timeout:  &timeout,
+			Retries:  &retries,
+			HTTP: &hcloud.LoadBalancerAddServiceOptsHealthCheckHTTP{
+				Path:        &healthPath,
+				StatusCodes: []string{"2??", "3??"},
+			},
+		},
+	}
+	if protocol == hcloud.LoadBalancerServiceProtocolHTTPS {
+		certificate, err := managedCertificate(ctx, client, serviceOption.Domain)
+		if err != nil {
+			return hcloud.LoadBalancerAddServiceOpts{}, err
+		}
+		redirectHTTP := true
+		opts.HTTP = &hcloud.LoadBalancerAddServiceOptsHTTP{Certificates: []*hcloud.Certificate{certificate}, RedirectHTTP: &redirectHTTP}
+	}
+	return opts, nil
+}
+
+func parsePort(port string) (int, error) {
+	if err := validatePort(port); err != nil {
+		return 0, err
+	}
+	if strings.Contains(port, "-") {
+		return 0, fmt.Errorf("port ranges are not supported for services")
+	}
+	return strconv.Atoi(port)
+}
+
+// managedCertificate returns the certificate covering the domain or lets hetzner issue a new one with lets encrypt.
+// The dns record of the domain has to point to the load balancer for the issuance to succeed
+func managedCertificate(ctx context.Context, client *hcloud.Client, domain string) (*hcloud.Certificate, error) {
+	if domain == "" {
+		return nil, errors.New("https needs a domain for the certificate")
+	}
+	certificates, err := client.Certificate.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, certificate := range certificates {
+		for _, domainName := range certificate.DomainNames {
+			if domainName == domain {
+				return certificate, nil
+			}
+		}
+	}
+	result, _, err := client.Certificate.CreateCertificate(ctx, hcloud.CertificateCreateOpts{
+		Name:        domain,
+		Type:        hcloud.CertificateTypeManaged,
+		DomainNames: []string{domain},
+		Labels:      withManagedByLabel(nil),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.Certificate, nil
+}
+
+// TargetName is the server name, label selector or ip of the target
+func TargetName(target hcloud.LoadBalancerTarget, serverNames map[int64]string) string {
+	switch target.Type {
+	case hcloud.LoadBalancerTargetTypeServer:
+		if name, ok := serverNames[target.Server.Server.ID]; ok {
+			return name
+		}
+		return fmt.Sprintf("server %d", target.Server.Server.ID)
+	case hcloud.LoadBalancerTargetTypeLabelSelector:
+		return "selector " + target.LabelSelector.Selector
+	case hcloud.LoadBalancerTargetTypeIP:
+		return target.IP.IP
+	}
+	return string(target.Type)
+}
+
+// TargetHealth formats the health of the target per service like "80 healthy, 443 unhealthy"
+func TargetHealth(target hcloud.LoadBalancerTarget) string {
+	health := make([]string, len(target.HealthStatus))
+	for i, status := range target.HealthStatus {
+		health[i] = fmt.Sprintf("%d %s", status.ListenPort, status.Status)
+	}
+	return strings.Join(health, ", ")
+}
+
+// HealthyTargets counts the server targets which are healthy for every service, label selector targets count their servers
+func HealthyTargets(loadBalancer *hcloud.LoadBalancer) (healthy int, total int) {
+	var count func(targets []hcloud.LoadBalancerTarget)
+	count = func(targets []hcloud.LoadBalancerTarget) {
+		for _, target := range targets {
+			if target.Type == hcloud.LoadBalancerTargetTypeLabelSelector {
+				count(target.Targets)
+				continue
+			}
+			total++
+			ok := len(target.HealthStatus) > 0
+			for _, status := range target.HealthStatus {
+				if status.Status != hcloud.LoadBalancerTargetHealthStatusStatusHealthy {
+					ok = false
+				}
+			}
+			if ok {
+				healthy++
+			}
+		}
+	}
+	count(loadBalancer.Targets)
+	return healthy, total
+}
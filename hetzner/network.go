@@ -0,0 +1,205 @@
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+type NetworksLoadedMsg struct {
+	Networks []*hcloud.Network
+	Err      error
+}
+
+// NetworkActionMsg is returned after a network or subnet was created, changed or deleted
+type NetworkActionMsg struct {
+	Description string
+	Err         error
+}
+
+// NetworkOption describes the private network a new server gets attached to.
+// An empty IP lets hetzner pick the next free one
+type NetworkOption struct {
+	NetworkID int64
+	Name      string
+	IP        string
+}
+
+func ListNetworks(ctx context.Context, hetzner_cloud_api_key string) ([]*hcloud.Network, error) {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+	networks, err := client.Network.All(ctx)
+	if err != nil {
+		log.Println("could not get all networks", err)
+		return nil, err
+	}
+	return networks, nil
+}
+
+func LoadNetworks(ctx context.Context, hetzner_cloud_api_key string) tea.Cmd {
+	return func() tea.Msg {
+		networks, err := ListNetworks(ctx, hetzner_cloud_api_key)
+		return NetworksLoadedMsg{Networks: networks, Err: err}
+	}
+}
+
+func parseSubnet(ipRange string, zone string) (hcloud.NetworkSubnet, error) {
+	_, ipNet, err := net.ParseCIDR(ipRange)
+	if err != nil {
+		return hcloud.NetworkSubnet{}, fmt.Errorf("invalid ip range %s", ipRange)
+	}
+	networkZone := hcloud.NetworkZone(zone)
+	switch networkZone {
+	case hcloud.NetworkZoneEUCentral, hcloud.NetworkZoneUSEast, hcloud.NetworkZoneUSWest:
+	default:
+		return hcloud.NetworkSubnet{}, errors.New("network zone must be eu-central, us-east or us-west")
+	}
+	return hcloud.NetworkSubnet{Type: hcloud.NetworkSubnetTypeCloud, IPRange: ipNet, NetworkZone: networkZone}, nil
+}
+
+// CreateNetwork creates a network with one cloud subnet inside of ipRange
+func CreateNetwork(ctx context.Context, hetzner_cloud_api_key string, name string, ipRange string, subnetRange string, zone string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, ActionTimeout)
+		defer cancel()
+		_, ipNet, err := net.ParseCIDR(ipRange)
+		if err != nil {
+			return NetworkActionMsg{Description: "create network", Err: fmt.Errorf("invalid ip range %s", ipRange)}
+		}
+		subnet, err := parseSubnet(subnetRange, zone)
+		if err != nil {
+			return NetworkActionMsg{Description: "create network", Err: err}
+		}
+		client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+		network, _, err := client.Network.Create(ctx, hcloud.NetworkCreateOpts{
+			Name:    name,
+			IPRange: ipNet,
+			Subnets: []hcloud.NetworkSubnet{subnet},
+		})
+		params := map[string]string{"name": name, "ip_range": ipRange, "subnet": subnetRange, "zone": zone}
+		if err != nil {
+			log.Println("could not create network", err)
+			auditOperation("network.create", 0, params, err)
+			return NetworkActionMsg{Description: "create network", Err: err}
+		}
+		auditOperation("network.create", network.ID, params, nil)
+		return NetworkActionMsg{Description: fmt.Sprintf("network %s created", network.Name)}
+	}
+}
+
+func AddSubnet(ctx context.Context, hetzner_cloud_api_key string, networkID int64, subnetRange string, zone string) tea.Cmd {
+	return networkAction(ctx, hetzner_cloud_api_key, networkID, "network.add_subnet", map[string]string{"subnet": subnetRange, "zone": zone}, "add subnet to", func(ctx context.Context, client *hcloud.Client, network *hcloud.Network) (*hcloud.Action, error) {
+		subnet, err := parseSubnet(subnetRange, zone)
+		if err != nil {
+			return nil, err
+		}
+		action, _, err := client.Network.AddSubnet(ctx, network, hcloud.NetworkAddSubnetOpts{Subnet: subnet})
+		return action, err
+	})
+}
+
+func DeleteSubnet(ctx context.Context, hetzner_cloud_api_key string, networkID int64, subnetRange string) tea.Cmd {
+	return networkAction(ctx, hetzner_cloud_api_key, networkID, "network.delete_subnet", nil, "delete subnet of", func(ctx context.Context, client *hcloud.Client, network *hcloud.Network) (*hcloud.Action, error) {
+		for _, subnet := range network.Subnets {
+			if subnet.IPRange.String() == subnetRange {
+				action, _, err := client.Network.DeleteSubnet(ctx, network, hcloud.NetworkDeleteSubnetOpts{Subnet: subnet})
+				return action, err
+			}
+		}
+		return nil, fmt.Errorf("subnet %s not found", subnetRange)
+	})
+}
+
+func AttachServerToNetwork(ctx context.Context, hetzner_cloud_api_key string, networkID int64, serverID int64, ip string) tea.Cmd {
+	return networkAction(ctx, hetzner_cloud_api_key, networkID, "network.attach_server", map[string]string{"server": strconv.FormatInt(serverID, 10)}, "attach server to", func(ctx context.Context, client *hcloud.Client, network *hcloud.Network) (*hcloud.Action, error) {
+		server, _, err := client.Server.GetByID(ctx, serverID)
+		if err != nil {
+			return nil, err
+		}
+		if server == nil {
+			return nil, errors.New("server not found")
+		}
+		return attachServerToNetwork(ctx, client, server, network, ip)
+	})
+}
+
+func attachServerToNetwork(ctx context.Context, client *hcloud.Client, server *hcloud.Server, network *hcloud.Network, ip string) (*hcloud.Action, error) {
+	opts := hcloud.ServerAttachToNetworkOpts{Network: network}
+	if ip != "" {
+		opts.IP = net.ParseIP(ip)
+		if opts.IP == nil {
+			return nil, fmt.Errorf("invalid ip %s", ip)
+		}
+	}
+	action, _, err := client.Server.AttachToNetwork(ctx, server, opts)
+	return action, err
+}
+
+func DetachServerFromNetwork(ctx context.Context, hetzner_cloud_api_key string, networkID int64, serverID int64) tea.Cmd {
+	return networkAction(ctx, hetzner_cloud_api_key, networkID, "network.detach_server", map[string]string{"server": strconv.FormatInt(serverID, 10)}, "detach server from", func(ctx context.Context, client *hcloud.Client, network *hcloud.Network) (*hcloud.Action, error) {
+		action, _, err := client.Server.DetachFromNetwork(ctx, &hcloud.Server{ID: serverID}, hcloud.ServerDetachFromNetworkOpts{Network: network})
+		return action, err
+	})
+}
+
+func DeleteNetwork(ctx context.Context, hetzner_cloud_api_key string, networkID int64) tea.Cmd {
+	return networkAction(ctx, hetzner_cloud_api_key, networkID, "network.delete", nil, "delete network", func(ctx context.Context, client *hcloud.Client, network *hcloud.Network) (*hcloud.Action, error) {
+		if len(network.Servers) > 0 {
+			return nil, errors.New("network has attached servers, detach them first")
+		}
+		_, err := client.Network.Delete(ctx, network)
+		return nil, err
+	})
+}
+
+// networkAction loads the network and runs the call on it with runAction
+func networkAction(ctx context.Context, hetzner_cloud_api_key string, networkID int64, operation string, params map[string]string, description string, call func(context.Context, *hcloud.Client, *hcloud.Network) (*hcloud.Action, error)) tea.Cmd {
+	return func() tea.Msg {
+		description, err := runAction(ctx, hetzner_cloud_api_key, networkID, operation, params, description, func(ctx context.Context, client *hcloud.Client) (string, []*hcloud.Action, error) {
+			network, _, err := client.Network.GetByID(ctx, networkID)
+			if err == nil && network == nil {
+				err = errors.New("network not found")
+			}
+			if err != nil {
+				return "", nil, err
+			}
+			action, err := call(ctx, client, network)
+			return network.Name, []*hcloud.Action{action}, err
+		})
+		return NetworkActionMsg{Description: description, Err: err}
+	}
+}
+
+// PrivateIP returns the first private ip of the server or an empty string
+func PrivateIP(server *hcloud.Server) string {
+	if len(server.PrivateNet) == 0 {
+		return ""
+	}
+	return server.PrivateNet[0].IP.String()
+}
+
+// PeerPrivateIPs maps the server names to their private ip inside of the network, so recipes
+// can reach other servers without the public internet. networkID 0 uses the first private ip of every server
+func PeerPrivateIPs(ctx context.Context, hetzner_cloud_api_key string, networkID int64) (map[string]string, error) {
+	servers, err := ListServer(ctx, hetzner_cloud_api_key, "")
+	if err != nil {
+		return nil, err
+	}
+	peers := make(map[string]string)
+	for _, server := range servers {
+		for _, privateNet := range server.PrivateNet {
+			if networkID == 0 || privateNet.Network.ID == networkID {
+				peers[server.Name] = privateNet.IP.String()
+				break
+			}
+		}
+	}
+	return peers, nil
+}
@@ -0,0 +1,64 @@
+package hetzner
+
+import (
+	"context"
+	"sync"
+)
+
+// MaxParallelTasks limits how many servers are created or changed at the same time, keeps us away from the api rate limit
+const MaxParallelTasks = 3
+
+const (
+	TaskWaiting = "waiting"
+	TaskRunning = "running"
+	TaskDone    = "done"
+	TaskFailed  = "failed"
+	// the task was not started or aborted because the context was cancelled
+	TaskCancelled = "cancelled"
+)
+
+// runParallel calls work for every index with at most MaxParallelTasks at the same time.
+// progress gets every status change, a failing task does not stop the others.
+// After ctx is cancelled no new task gets started, the waiting ones count as failed
+func runParallel(ctx context.Context, count int, work func(index int) error, progress func(index int, status string, err error)) (done int, failed int) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	slots := make(chan struct{}, MaxParallelTasks)
+	for index := 0; index < count; index++ {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+			}
+			if ctx.Err() != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				failed++
+				progress(index, TaskCancelled, ctx.Err())
+				return
+			}
+
+			progress(index, TaskRunning, nil)
+			err := work(index)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil && ctx.Err() != nil {
+				failed++
+				progress(index, TaskCancelled, err)
+				return
+			}
+			if err != nil {
+				failed++
+				progress(index, TaskFailed, err)
+				return
+			}
+			done++
+			progress(index, TaskDone, nil)
+		}(index)
+	}
+	wg.Wait()
+	return done, failed
+}
@@ -0,0 +1,132 @@
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// hetzner allows at most 10 servers in a spread placement group
+const MaxServersPerSpreadGroup = 10
+
+type PlacementGroupsLoadedMsg struct {
+	PlacementGroups []*hcloud.PlacementGroup
+	Err             error
+}
+
+// PlacementGroupActionMsg is returned after a placement group was created, changed or deleted
+type PlacementGroupActionMsg struct {
+	Description string
+	Err         error
+}
+
+// PlacementGroupOption puts a new server into an existing spread placement group
+type PlacementGroupOption struct {
+	PlacementGroupID int64
+	Name             string
+}
+
+func ListPlacementGroups(ctx context.Context, hetzner_cloud_api_key string) ([]*hcloud.PlacementGroup, error) {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+	placementGroups, err := client.PlacementGroup.All(ctx)
+	if err != nil {
+		log.Println("could not get all placement groups", err)
+		return nil, err
+	}
+	return placementGroups, nil
+}
+
+func LoadPlacementGroups(ctx context.Context, hetzner_cloud_api_key string) tea.Cmd {
+	return func() tea.Msg {
+		placementGroups, err := ListPlacementGroups(ctx, hetzner_cloud_api_key)
+		return PlacementGroupsLoadedMsg{PlacementGroups: placementGroups, Err: err}
+	}
+}
+
+// CreatePlacementGroup creates a spread group, its servers never share a physical host
+func CreatePlacementGroup(ctx context.Context, hetzner_cloud_api_key string, name string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, ActionTimeout)
+		defer cancel()
+		client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+		result, _, err := client.PlacementGroup.Create(ctx, hcloud.PlacementGroupCreateOpts{Name: name, Type: hcloud.PlacementGroupTypeSpread})
+		if err == nil {
+			err = waitForActions(ctx, client, result.Action)
+		}
+		if err != nil {
+			log.Println("could not create placement group", err)
+			auditOperation("placement_group.create", 0, map[string]string{"name": name}, err)
+			return PlacementGroupActionMsg{Description: "create placement group", Err: err}
+		}
+		auditOperation("placement_group.create", result.PlacementGroup.ID, map[string]string{"name": name}, nil)
+		return PlacementGroupActionMsg{Description: fmt.Sprintf("placement group %s created", name)}
+	}
+}
+
+// AddServerToPlacementGroup only works for powered off servers
+func AddServerToPlacementGroup(ctx context.Context, hetzner_cloud_api_key string, placementGroupID int64, serverID int64) tea.Cmd {
+	return placementGroupAction(ctx, hetzner_cloud_api_key, placementGroupID, "placement_group.add_server", map[string]string{"server": strconv.FormatInt(serverID, 10)}, "add server to", func(ctx context.Context, client *hcloud.Client, placementGroup *hcloud.PlacementGroup) (*hcloud.Action, error) {
+		if len(placementGroup.Servers) >= MaxServersPerSpreadGroup {
+			return nil, fmt.Errorf("placement group already has %d servers", MaxServersPerSpreadGroup)
+		}
+		action, _, err := client.Server.AddToPlacementGroup(ctx, &hcloud.Server{ID: serverID}, placementGroup)
+		return action, err
+	})
+}
+
+func RemoveServerFromPlacementGroup(ctx context.Context, hetzner_cloud_api_key string, placementGroupID int64, serverID int64) tea.Cmd {
+	return placementGroupAction(ctx, hetzner_cloud_api_key, placementGroupID, "placement_group.remove_server", map[string]string{"server": strconv.FormatInt(serverID, 10)}, "remove server from", func(ctx context.Context, client *hcloud.Client, placementGroup *hcloud.PlacementGroup) (*hcloud.Action, error) {
+		action, _, err := client.Server.RemoveFromPlacementGroup(ctx, &hcloud.Server{ID: serverID})
+		return action, err
+	})
+}
+
+func DeletePlacementGroup(ctx context.Context, hetzner_cloud_api_key string, placementGroupID int64) tea.Cmd {
+	return placementGroupAction(ctx, hetzner_cloud_api_key, placementGroupID, "placement_group.delete", nil, "delete placement group", func(ctx context.Context, client *hcloud.Client, placementGroup *hcloud.PlacementGroup) (*hcloud.Action, error) {
+		if len(placementGroup.Servers) > 0 {
+			return nil, errors.New("placement group still has servers")
+		}
+		_, err := client.PlacementGroup.Delete(ctx, placementGroup)
+		return nil, err
+	})
+}
+
+// placementGroupAction loads the placement group and runs the call on it with runAction
+func placementGroupAction(ctx context.Context, hetzner_cloud_api_key string, placementGroupID int64, operation string, params map[string]string, description string, call func(context.Context, *hcloud.Client, *hcloud.PlacementGroup) (*hcloud.Action, error)) tea.Cmd {
+	return func() tea.Msg {
+		description, err := runAction(ctx, hetzner_cloud_api_key, placementGroupID, operation, params, description, func(ctx context.Context, client *hcloud.Client) (string, []*hcloud.Action, error) {
+			placementGroup, _, err := client.PlacementGroup.GetByID(ctx, placementGroupID)
+			if err == nil && placementGroup == nil {
+				err = errors.New("placement group not found")
+			}
+			if err != nil {
+				return "", nil, err
+			}
+			action, err := call(ctx, client, placementGroup)
+			return placementGroup.Name, []*hcloud.Action{action}, err
+		})
+		return PlacementGroupActionMsg{Description: description, Err: err}
+	}
+}
+
+// placementGroupForServer loads the placement group of the option and makes sure it has space left
+func placementGroupForServer(ctx context.Context, client *hcloud.Client, placementGroupOption PlacementGroupOption) (*hcloud.PlacementGroup, error) {
+	placementGroup, _, err := client.PlacementGroup.GetByID(ctx, placementGroupOption.PlacementGroupID)
+	if err != nil {
+		return nil, err
+	}
+	if placementGroup == nil {
+		return nil, errors.New("placement group not found")
+	}
+	if len(placementGroup.Servers) >= MaxServersPerSpreadGroup {
+		return nil, fmt.Errorf("placement group %s already has %d servers", placementGroup.Name, MaxServersPerSpreadGroup)
+	}
+	return placementGroup, nil
+}
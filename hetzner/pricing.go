@@ -0,0 +1,160 @@
+package hetzner
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// HoursPerMonth converts monthly prices without an hourly price, hetzner bills by the hour up to the monthly price
+const HoursPerMonth = 730
+
+// PricesMaxAge is how long fetched prices are reused, they rarely change
+const PricesMaxAge = time.Hour
+
+// Cost is the gross price of a resource, the monthly price caps what the hours of one month cost
+type Cost struct {
+	Hourly  float64
+	Monthly float64
+}
+
+func (c Cost) Add(other Cost) Cost {
+	return Cost{Hourly: c.Hourly + other.Hourly, Monthly: c.Monthly + other.Monthly}
+}
+
+func (c Cost) Times(n float64) Cost {
+	return Cost{Hourly: c.Hourly * n, Monthly: c.Monthly * n}
+}
+
+// Between is what the resource costs from one point in time to another inside the same month,
+// started hours are billed
+func (c Cost) Between(from time.Time, to time.Time) float64 {
+	if !to.After(from) {
+		return 0
+	}
+	hours := math.Ceil(to.Sub(from).Hours())
+	return math.Min(hours*c.Hourly, c.Monthly)
+}
+
+// Prices are the gross prices of the project, they include the vat of the account
+type Prices struct {
+	Currency string
+	// keys are name/location
+	servers       map[string]Cost
+	loadBalancers map[string]Cost
+	// keys are type/location
+	primaryIPs    map[string]Cost
+	floatingIPs   map[string]Cost
+	volumePerGB   Cost
+	snapshotPerGB Cost
+}
+
+var pricesCache struct {
+	mu      sync.Mutex
+	apiKey  string
+	prices  Prices
+	fetched time.Time
+}
+
+// GetPrices returns the prices of the pricing api, they are cached for PricesMaxAge
+func GetPrices(ctx context.Context, client *hcloud.Client, hetzner_cloud_api_key string) (Prices, error) {
+	pricesCache.mu.Lock()
+	defer pricesCache.mu.Unlock()
+	if pricesCache.apiKey == hetzner_cloud_api_key && time.Since(pricesCache.fetched) < PricesMaxAge {
+		return pricesCache.prices, nil
+	}
+	pricing, _, err := client.Pricing.Get(ctx)
+	if err != nil {
+		log.Println("could not get prices", err)
+		return Prices{}, err
+	}
+	prices := pricesFromPricing(pricing)
+	pricesCache.apiKey, pricesCache.prices, pricesCache.fetched = hetzner_cloud_api_key, prices, time.Now()
+	return prices, nil
+}
+
+func pricesFromPricing(pricing hcloud.Pricing) Prices {
+	prices := Prices{
+		Currency:      pricing.Volume.PerGBMonthly.Currency,
+		servers:       make(map[string]Cost),
+		loadBalancers: make(map[string]Cost),
+		primaryIPs:    make(map[string]Cost),
+		floatingIPs:   make(map[string]Cost),
+		volumePerGB:   monthlyCost(pricing.Volume.PerGBMonthly.Gross),
+		snapshotPerGB: monthlyCost(pricing.Image.PerGBMonth.Gross),
+	}
+	for _, serverType := range pricing.ServerTypes {
+		for _, price := range serverType.Pricings {
+			prices.servers[serverType.ServerType.Name+"/"+price.Location.Name] = Cost{Hourly: parsePrice(price.Hourly.Gross), Monthly: parsePrice(price.Monthly.Gross)}
+		}
+	}
+	for _, loadBalancerType := range pricing.LoadBalancerTypes {
+		for _, price := range loadBalancerType.Pricings {
+			prices.loadBalancers[loadBalancerType.LoadBalancerType.Name+"/"+price.Location.Name] = Cost{Hourly: parsePrice(price.Hourly.Gross), Monthly: parsePrice(price.Monthly.Gross)}
+		}
+	}
+	for _, primaryIP := range pricing.PrimaryIPs {
+		for _, price := range primaryIP.Pricings {
+			prices.primaryIPs[primaryIP.Type+"/"+price.Location] = Cost{Hourly: parsePrice(price.Hourly.Gross), Monthly: parsePrice(price.Monthly.Gross)}
+		}
+	}
+	for _, floatingIP := range pricing.FloatingIPs {
+		for _, price := range floatingIP.Pricings {
+			prices.floatingIPs[string(floatingIP.Type)+"/"+price.Location.Name] = monthlyCost(price.Monthly.Gross)
+		}
+	}
+	return prices
+}
+
+// parsePrice reads the decimal strings of the api, a broken price counts as free and ends up in the log
+func parsePrice(price string) float64 {
+	value, err := strconv.ParseFloat(price, 64)
+	if err != nil {
+		log.Println("could not parse price", price, err)
+	}
+	return value
+}
+
+func monthlyCost(monthly string) Cost {
+	value := parsePrice(monthly)
+	return Cost{Hourly: value / HoursPerMonth, Monthly: value}
+}
+
+func (p Prices) Server(serverType string, location string) (Cost, error) {
+	cost, ok := p.servers[serverType+"/"+location]
+	if !ok {
+		return Cost{}, fmt.Errorf("no price for server type %s in %s", serverType, location)
+	}
+	return cost, nil
+}
+
+func (p Prices) LoadBalancer(loadBalancerType string, location string) (Cost, error) {
+	cost, ok := p.loadBalancers[loadBalancerType+"/"+location]
+	if !ok {
+		return Cost{}, fmt.Errorf("no price for load balancer type %s in %s", loadBalancerType, location)
+	}
+	return cost, nil
+}
+
+// PrimaryIP is the price of an ipv4 or ipv6, ipv6 is free
+func (p Prices) PrimaryIP(ipType string, location string) Cost {
+	return p.primaryIPs[ipType+"/"+location]
+}
+
+func (p Prices) FloatingIP(ipType string, location string) Cost {
+	return p.floatingIPs[ipType+"/"+location]
+}
+
+func (p Prices) Volume(sizeGB int) Cost {
+	return p.volumePerGB.Times(float64(sizeGB))
+}
+
+func (p Prices) Snapshot(sizeGB float32) Cost {
+	return p.snapshotPerGB.Times(float64(sizeGB))
+}
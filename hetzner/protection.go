@@ -0,0 +1,43 @@
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+type ServerProtectionChangedMsg struct {
+	ServerID int64
+	Enabled  bool
+	Err      error
+}
+
+// SetServerProtection enables or disables delete and rebuild protection,
+// hetzner only accepts both with the same value
+func SetServerProtection(ctx context.Context, hetzner_cloud_api_key string, serverID int64, enabled bool) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, ActionTimeout)
+		defer cancel()
+		client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+		server, _, err := client.Server.GetByID(ctx, serverID)
+		if err == nil && server == nil {
+			err = errors.New("server not found")
+		}
+		if err == nil {
+			var action *hcloud.Action
+			action, _, err = client.Server.ChangeProtection(ctx, server, hcloud.ServerChangeProtectionOpts{Delete: &enabled, Rebuild: &enabled})
+			if err == nil {
+				err = waitForActions(ctx, client, action)
+			}
+		}
+		if err != nil {
+			log.Println("could not change protection", err)
+		}
+		auditOperation("server.protection", serverID, map[string]string{"enabled": strconv.FormatBool(enabled)}, err)
+		return ServerProtectionChangedMsg{ServerID: serverID, Enabled: enabled, Err: err}
+	}
+}
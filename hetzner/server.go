@@ -0,0 +1,124 @@
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// blocking variants of the server commands, used by the cli
+
+// FindServer returns the server with the given name or id
+func FindServer(ctx context.Context, hetzner_cloud_api_key string, nameOrID string) (*hcloud.Server, error) {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+	var server *hcloud.Server
+	var err error
+	if id, parseErr := strconv.ParseInt(nameOrID, 10, 64); parseErr == nil {
+		server, _, err = client.Server.GetByID(ctx, id)
+	} else {
+		server, _, err = client.Server.GetByName(ctx, nameOrID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if server == nil {
+		return nil, fmt.Errorf("server %s not found", nameOrID)
+	}
+	return server, nil
+}
+
+// NewServer creates the server like CreateServer but returns the result instead of a message,
+// the create action is not awaited
+func NewServer(ctx context.Context, hetzner_cloud_api_key string, serverOption CreateServerModel) (hcloud.ServerCreateResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, CreateTimeout)
+	defer cancel()
+	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+	return createServer(ctx, client, serverOption)
+}
+
+// RemoveServer deletes the server and waits for the delete action, progress may be nil
+func RemoveServer(ctx context.Context, hetzner_cloud_api_key string, serverID int64, progress func(int)) error {
+	ctx, cancel := context.WithTimeout(ctx, ActionTimeout)
+	defer cancel()
+	return deleteServerHetzner(ctx, hetzner_cloud_api_key, serverID, progress)
+}
+
+// RebootServer reboots the server and waits until the reboot action is done
+func RebootServer(ctx context.Context, hetzner_cloud_api_key string, serverID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, ActionTimeout)
+	defer cancel()
+	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+	action, _, err := client.Server.Reboot(ctx, &hcloud.Server{ID: serverID})
+	if err == nil {
+		err = waitForActions(ctx, client, action)
+	}
+	auditOperation("server.reboot", serverID, nil, err)
+	return err
+}
+
+// WaitForServer waits for the actions of a new server and returns the server in its current state
+func WaitForServer(ctx context.Context, hetzner_cloud_api_key string, result hcloud.ServerCreateResult) (*hcloud.Server, error) {
+	ctx, cancel := context.WithTimeout(ctx, CreateTimeout)
+	defer cancel()
+	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+	if err := waitForActions(ctx, client, append(result.NextActions, result.Action)...); err != nil {
+		if Cancelled(err) {
+			return nil, fmt.Errorf("%w, %s", err, serverState(client, result.Server.ID))
+		}
+		return nil, err
+	}
+	server, _, err := client.Server.GetByID(ctx, result.Server.ID)
+	if err == nil && server == nil {
+		err = errors.New("server not found")
+	}
+	return server, err
+}
+
+// WaitForAction waits until the action with the given id is done, progress may be nil
+func WaitForAction(ctx context.Context, hetzner_cloud_api_key string, actionID int64, progress func(int)) error {
+	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+	action, _, err := client.Action.GetByID(ctx, actionID)
+	if err != nil {
+		return err
+	}
+	if action == nil {
+		return errors.New("action not found")
+	}
+	return watchAction(ctx, client, action, progress)
+}
+
+func PublicIPv4(server *hcloud.Server) string {
+	if server.PublicNet.IPv4.IsUnspecified() {
+		return ""
+	}
+	return server.PublicNet.IPv4.IP.String()
+}
+
+func PublicIPv6(server *hcloud.Server) string {
+	if server.PublicNet.IPv6.IsUnspecified() || server.PublicNet.IPv6.Network == nil {
+		return ""
+	}
+	return server.PublicNet.IPv6.Network.String()
+}
+
+// MonthlyCost is the gross monthly price of the server type in the location of the server, 0 if unknown
+func MonthlyCost(server *hcloud.Server) float64 {
+	if server.ServerType == nil || server.Datacenter == nil {
+		return 0
+	}
+	for _, pricing := range server.ServerType.Pricings {
+		if pricing.Location != nil && pricing.Location.Name == server.Datacenter.Location.Name {
+			cost, err := strconv.ParseFloat(pricing.Monthly.Gross, 64)
+			if err != nil {
+				return 0
+			}
+			return cost
+		}
+	}
+	return 0
+}
@@ -7,9 +7,9 @@ import (
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 )
 
-func GetSmallestServer(client *hcloud.Client) (*hcloud.ServerType, error) {
+func GetSmallestServer(ctx context.Context, client *hcloud.Client) (*hcloud.ServerType, error) {
 
-	serverType, _, err := client.ServerType.GetByName(context.Background(), "cx22") // shared cpu intel, if u want amd use cax11
+	serverType, _, err := client.ServerType.GetByName(ctx, "cx22") // shared cpu intel, if u want amd use cax11
 	//serverType, _, err := client.ServerType.GetByID(context.Background(), 104)
 	if err != nil {
 		return nil, err
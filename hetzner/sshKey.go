@@ -3,20 +3,136 @@ package hetzner
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"strconv"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 )
 
-func GetSshKey(client *hcloud.Client, name string) (*hcloud.SSHKey, error) {
+type SshKeysLoadedMsg struct {
+	Keys []*hcloud.SSHKey
+	Err  error
+}
+
+// SshKeyActionMsg is returned after a key was uploaded or deleted
+type SshKeyActionMsg struct {
+	Description string
+	Err         error
+}
 
-	sshKey, _, err := client.SSHKey.GetByName(context.Background(), name)
+func GetSshKey(ctx context.Context, client *hcloud.Client, name string) (*hcloud.SSHKey, error) {
+
+	sshKey, _, err := client.SSHKey.GetByName(ctx, name)
 	if err != nil {
 		return nil, err
 	}
 	if sshKey == nil {
-		return nil, errors.New("SshKey and error are nil")
+		return nil, fmt.Errorf("ssh key %s not found", name)
 	}
 
 	return sshKey, nil
 
 }
+
+// getSshKeys resolves all names, a server needs at least one key
+func getSshKeys(ctx context.Context, client *hcloud.Client, names []string) ([]*hcloud.SSHKey, error) {
+	if len(names) == 0 {
+		return nil, errors.New("no ssh key selected")
+	}
+	sshKeys := make([]*hcloud.SSHKey, 0, len(names))
+	for _, name := range names {
+		sshKey, err := GetSshKey(ctx, client, name)
+		if err != nil {
+			return nil, err
+		}
+		sshKeys = append(sshKeys, sshKey)
+	}
+	return sshKeys, nil
+}
+
+func ListSshKeys(ctx context.Context, hetzner_cloud_api_key string) ([]*hcloud.SSHKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+	sshKeys, err := client.SSHKey.All(ctx)
+	if err != nil {
+		log.Println("could not get all ssh keys", err)
+		return nil, err
+	}
+	return sshKeys, nil
+}
+
+func LoadSshKeys(ctx context.Context, hetzner_cloud_api_key string) tea.Cmd {
+	return func() tea.Msg {
+		keys, err := ListSshKeys(ctx, hetzner_cloud_api_key)
+		return SshKeysLoadedMsg{Keys: keys, Err: err}
+	}
+}
+
+// FindSshKey looks the key up by id or name
+func FindSshKey(ctx context.Context, hetzner_cloud_api_key string, nameOrID string) (*hcloud.SSHKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+	if id, err := strconv.ParseInt(nameOrID, 10, 64); err == nil {
+		sshKey, _, err := client.SSHKey.GetByID(ctx, id)
+		if err != nil || sshKey != nil {
+			return sshKey, err
+		}
+	}
+	return GetSshKey(ctx, client, nameOrID)
+}
+
+// UploadSshKey adds a public key in authorized_keys format to the project
+func UploadSshKey(ctx context.Context, hetzner_cloud_api_key string, name string, publicKey string) (*hcloud.SSHKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+	sshKey, _, err := client.SSHKey.Create(ctx, hcloud.SSHKeyCreateOpts{
+		Name:      name,
+		PublicKey: publicKey,
+		Labels:    withManagedByLabel(nil),
+	})
+	if err != nil {
+		log.Println("could not upload ssh key", err)
+	}
+	var id int64
+	if sshKey != nil {
+		id = sshKey.ID
+	}
+	auditOperation("ssh_key.upload", id, map[string]string{"name": name}, err)
+	return sshKey, err
+}
+
+// RemoveSshKey deletes the key from the project, servers created with it keep it in authorized_keys
+func RemoveSshKey(ctx context.Context, hetzner_cloud_api_key string, id int64) error {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+	_, err := client.SSHKey.Delete(ctx, &hcloud.SSHKey{ID: id})
+	if err != nil {
+		log.Println("could not delete ssh key", err)
+	}
+	auditOperation("ssh_key.delete", id, nil, err)
+	return err
+}
+
+func CreateSshKey(ctx context.Context, hetzner_cloud_api_key string, name string, publicKey string) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := UploadSshKey(ctx, hetzner_cloud_api_key, name, publicKey); err != nil {
+			return SshKeyActionMsg{Description: "upload ssh key", Err: err}
+		}
+		return SshKeyActionMsg{Description: fmt.Sprintf("ssh key %s uploaded", name)}
+	}
+}
+
+func DeleteSshKey(ctx context.Context, hetzner_cloud_api_key string, id int64, name string) tea.Cmd {
+	return func() tea.Msg {
+		if err := RemoveSshKey(ctx, hetzner_cloud_api_key, id); err != nil {
+			return SshKeyActionMsg{Description: "delete ssh key", Err: err}
+		}
+		return SshKeyActionMsg{Description: fmt.Sprintf("ssh key %s deleted", name)}
+	}
+}
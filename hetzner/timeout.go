@@ -0,0 +1,48 @@
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+const (
+	// RequestTimeout limits loading resources and calls which return at once
+	RequestTimeout = 30 * time.Second
+	// ActionTimeout limits calls which wait for a hetzner action, e.g. attaching a volume or rebooting
+	ActionTimeout = 5 * time.Minute
+	// CreateTimeout limits the creation of one server including its volume, firewall and network
+	CreateTimeout = 10 * time.Minute
+	// DeployTimeout limits running a recipe over ssh on one server
+	DeployTimeout = 15 * time.Minute
+)
+
+// OperationCancelledMsg is returned when a running operation was cancelled or timed out,
+// State describes what it left behind
+type OperationCancelledMsg struct {
+	Operation string
+	State     string
+}
+
+// Cancelled reports whether err comes from a cancelled or timed out context
+func Cancelled(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// serverState looks up the server after a cancelled operation, the context of the
+// operation is already done so a fresh one is used
+func serverState(client *hcloud.Client, serverID int64) string {
+	ctx, cancel := context.WithTimeout(context.Background(), RequestTimeout)
+	defer cancel()
+	server, _, err := client.Server.GetByID(ctx, serverID)
+	if err != nil {
+		return fmt.Sprintf("state of server %d unknown: %s", serverID, err)
+	}
+	if server == nil {
+		return fmt.Sprintf("server %d does not exist", serverID)
+	}
+	return fmt.Sprintf("server %s is %s", server.Name, server.Status)
+}
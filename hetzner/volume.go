@@ -0,0 +1,184 @@
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+const (
+	VolumeFormatExt4 = "ext4"
+	VolumeFormatXfs  = "xfs"
+)
+
+type VolumesLoadedMsg struct {
+	Volumes []*hcloud.Volume
+	Err     error
+}
+
+// VolumeActionMsg is returned after a volume was created, attached, detached, resized or deleted
+type VolumeActionMsg struct {
+	Description string
+	Err         error
+}
+
+// VolumeOption describes the volume which gets attached while creating a server.
+// If VolumeID is set the existing volume is used, otherwise a new one is created
+type VolumeOption struct {
+	VolumeID int64
+	Name     string
+	Size     int
+	Format   string
+}
+
+func ListVolumes(ctx context.Context, hetzner_cloud_api_key string) ([]*hcloud.Volume, error) {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+	volumes, err := client.Volume.All(ctx)
+	if err != nil {
+		log.Println("could not get all volumes", err)
+		return nil, err
+	}
+	return volumes, nil
+}
+
+func LoadVolumes(ctx context.Context, hetzner_cloud_api_key string) tea.Cmd {
+	return func() tea.Msg {
+		volumes, err := ListVolumes(ctx, hetzner_cloud_api_key)
+		return VolumesLoadedMsg{Volumes: volumes, Err: err}
+	}
+}
+
+// CreateVolume creates a volume which is not attached, a budget above 0 fails the create with a *BudgetError if the volume exceeds it
+func CreateVolume(ctx context.Context, hetzner_cloud_api_key string, volumeOption VolumeOption, country string, budget float64) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, ActionTimeout)
+		defer cancel()
+		client := hcloud.NewClient(hcloud.WithToken(hetzner_cloud_api_key))
+		params := map[string]string{"name": volumeOption.Name, "size": strconv.Itoa(volumeOption.Size), "country": country}
+		datacenter, err := GetDatacenter(ctx, client, country)
+		if err == nil && budget > 0 {
+			var prices Prices
+			prices, err = GetPrices(ctx, client, hetzner_cloud_api_key)
+			if err == nil {
+				err = checkAdded(ctx, hetzner_cloud_api_key, budget, prices.Volume(volumeOption.Size))
+			}
+		}
+		if err != nil {
+			auditOperation("volume.create", 0, params, err)
+			return VolumeActionMsg{Description: "create volume", Err: err}
+		}
+		volume, err := createHetznerVolume(ctx, client, volumeOption, datacenter.Location)
+		if err != nil {
+			auditOperation("volume.create", 0, params, err)
+			return VolumeActionMsg{Description: "create volume", Err: err}
+		}
+		auditOperation("volume.create", volume.ID, params, nil)
+		return VolumeActionMsg{Description: fmt.Sprintf("volume %s created", volume.Name)}
+	}
+}
+
+func createHetznerVolume(ctx context.Context, client *hcloud.Client, volumeOption VolumeOption, location *hcloud.Location) (*hcloud.Volume, error) {
+	if volumeOption.Size < 10 {
+		return nil, errors.New("volume size must be at least 10 GB")
+	}
+	format := volumeOption.Format
+	if format == "" {
+		format = VolumeFormatExt4
+	}
+	result, _, err := client.Volume.Create(ctx, hcloud.VolumeCreateOpts{
+		Name:     volumeOption.Name,
+		Size:     volumeOption.Size,
+		Location: location,
+		Format:   &format,
+	})
+	if err != nil {
+		log.Println("could not create volume", err)
+		return nil, err
+	}
+	// the volume exists even if waiting failed, the caller may remove it again
+	if err := waitForActions(ctx, client, append(result.NextActions, result.Action)...); err != nil {
+		log.Println("creating volume failed", err)
+		return result.Volume, err
+	}
+	return result.Volume, nil
+}
+
+func AttachVolume(ctx context.Context, hetzner_cloud_api_key string, volumeID int64, serverID int64) tea.Cmd {
+	return volumeAction(ctx, hetzner_cloud_api_key, volumeID, "volume.attach", map[string]string{"server": strconv.FormatInt(serverID, 10)}, "attach volume", func(ctx context.Context, client *hcloud.Client, volume *hcloud.Volume) (*hcloud.Action, error) {
+		server, _, err := client.Server.GetByID(ctx, serverID)
+		if err != nil {
+			return nil, err
+		}
+		if server == nil {
+			return nil, errors.New("server not found")
+		}
+		if server.Datacenter.Location.Name != volume.Location.Name {
+			return nil, fmt.Errorf("volume is in %s but server is in %s", volume.Location.Name, server.Datacenter.Location.Name)
+		}
+		automount := true
+		action, _, err := client.Volume.AttachWithOpts(ctx, volume, hcloud.VolumeAttachOpts{Server: server, Automount: &automount})
+		return action, err
+	})
+}
+
+func DetachVolume(ctx context.Context, hetzner_cloud_api_key string, volumeID int64) tea.Cmd {
+	return volumeAction(ctx, hetzner_cloud_api_key, volumeID, "volume.detach", nil, "detach volume", func(ctx context.Context, client *hcloud.Client, volume *hcloud.Volume) (*hcloud.Action, error) {
+		action, _, err := client.Volume.Detach(ctx, volume)
+		return action, err
+	})
+}
+
+// ResizeVolume grows the volume, a budget above 0 fails the resize with a *BudgetError if the bigger volume exceeds it
+func ResizeVolume(ctx context.Context, hetzner_cloud_api_key string, volumeID int64, size int, budget float64) tea.Cmd {
+	return volumeAction(ctx, hetzner_cloud_api_key, volumeID, "volume.resize", map[string]string{"size": strconv.Itoa(size)}, "resize volume", func(ctx context.Context, client *hcloud.Client, volume *hcloud.Volume) (*hcloud.Action, error) {
+		if size <= volume.Size {
+			return nil, fmt.Errorf("volumes can only grow, current size is %d GB", volume.Size)
+		}
+		if budget > 0 {
+			prices, err := GetPrices(ctx, client, hetzner_cloud_api_key)
+			if err != nil {
+				return nil, err
+			}
+			if err := checkAdded(ctx, hetzner_cloud_api_key, budget, prices.Volume(size-volume.Size)); err != nil {
+				return nil, err
+			}
+		}
+		action, _, err := client.Volume.Resize(ctx, volume, size)
+		return action, err
+	})
+}
+
+func DeleteVolume(ctx context.Context, hetzner_cloud_api_key string, volumeID int64) tea.Cmd {
+	return volumeAction(ctx, hetzner_cloud_api_key, volumeID, "volume.delete", nil, "delete volume", func(ctx context.Context, client *hcloud.Client, volume *hcloud.Volume) (*hcloud.Action, error) {
+		if volume.Server != nil {
+			return nil, errors.New("volume is still attached, detach it first")
+		}
+		_, err := client.Volume.Delete(ctx, volume)
+		return nil, err
+	})
+}
+
+// volumeAction loads the volume and runs the call on it with runAction
+func volumeAction(ctx context.Context, hetzner_cloud_api_key string, volumeID int64, operation string, params map[string]string, description string, call func(context.Context, *hcloud.Client, *hcloud.Volume) (*hcloud.Action, error)) tea.Cmd {
+	return func() tea.Msg {
+		description, err := runAction(ctx, hetzner_cloud_api_key, volumeID, operation, params, description, func(ctx context.Context, client *hcloud.Client) (string, []*hcloud.Action, error) {
+			volume, _, err := client.Volume.GetByID(ctx, volumeID)
+			if err == nil && volume == nil {
+				err = errors.New("volume not found")
+			}
+			if err != nil {
+				return "", nil, err
+			}
+			action, err := call(ctx, client, volume)
+			return volume.Name, []*hcloud.Action{action}, err
+		})
+		return VolumeActionMsg{Description: description, Err: err}
+	}
+}
@@ -0,0 +1,236 @@
+package inventory
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS servers (
+	id           INTEGER PRIMARY KEY,
+	name         TEXT NOT NULL,
+	profile      TEXT NOT NULL DEFAULT '',
+	server_type  TEXT NOT NULL DEFAULT '',
+	location     TEXT NOT NULL DEFAULT '',
+	cloud_config TEXT NOT NULL DEFAULT '',
+	created_at   DATETIME NOT NULL,
+	deleted_at   DATETIME
+);
+CREATE TABLE IF NOT EXISTS deployments (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	server_id   INTEGER NOT NULL,
+	recipe      TEXT NOT NULL,
+	repo        TEXT NOT NULL DEFAULT '',
+	commit_hash TEXT NOT NULL DEFAULT '',
+	deployed_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS deployments_server ON deployments (server_id, deployed_at);
+`
+
+// Server is a server liftoff created, the deployment fields hold the last deployment
+type Server struct {
+	ID          int64
+	Name        string
+	Profile     string
+	ServerType  string
+	Location    string
+	CloudConfig string
+	CreatedAt   time.Time
+	// zero while the server exists
+	DeletedAt  time.Time
+	Recipe     string
+	Repo       string
+	Commit     string
+	DeployedAt time.Time
+}
+
+// Deleted reports whether the server was deleted, by liftoff or outside of it and found missing by RecordMissing
+func (s Server) Deleted() bool {
+	return !s.DeletedAt.IsZero()
+}
+
+// Deployment is one recipe run on a server
+type Deployment struct {
+	ServerID   int64
+	Recipe     string
+	Repo       string
+	Commit     string
+	DeployedAt time.Time
+}
+
+// Store is the local sqlite database of created servers and their deployments.
+// The methods of a nil store do nothing, liftoff works without the inventory
+type Store struct {
+	db *sql.DB
+}
+
+// DB is the store the hetzner calls and deploys record to, nil until Open succeeded
+var DB *Store
+
+// Path is the database next to the config file
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "liftoff", "inventory.db"), nil
+}
+
+// Open creates the database and its tables if they do not exist
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// RecordCreated adds the server, an existing record of the same id is replaced
+func (s *Store) RecordCreated(server Server) error {
+	if s == nil {
+		return nil
+	}
+	if server.CreatedAt.IsZero() {
+		server.CreatedAt = time.Now()
+	}
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO servers (id, name, profile, server_type, location, cloud_config, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		server.ID, server.Name, server.Profile, server.ServerType, server.Location, server.CloudConfig, server.CreatedAt.UTC())
+	return err
+}
+
+func (s *Store) RecordDeployment(deployment Deployment) error {
+	if s == nil {
+		return nil
+	}
+	if deployment.DeployedAt.IsZero() {
+		deployment.DeployedAt = time.Now()
+	}
+	_, err := s.db.Exec(`INSERT INTO deployments (server_id, recipe, repo, commit_hash, deployed_at) VALUES (?, ?, ?, ?, ?)`,
+		deployment.ServerID, deployment.Recipe, deployment.Repo, deployment.Commit, deployment.DeployedAt.UTC())
+	return err
+}
+
+// RecordDeleted sets the deletion time, servers liftoff did not create are ignored
+func (s *Store) RecordDeleted(serverID int64) error {
+	if s == nil {
+		return nil
+	}
+	_, err := s.db.Exec(`UPDATE servers SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`, time.Now().UTC(), serverID)
+	return err
+}
+
+// RecordMissing marks the servers of the profile as deleted which are not in existing, e.g. because they were
+// deleted in the hetzner console. existing has to be the unfiltered server list of the project, loaded at listedAt,
+// servers created after it can not be in the list yet
+func (s *Store) RecordMissing(profile string, existing []int64, listedAt time.Time) error {
+	if s == nil {
+		return nil
+	}
+	servers, err := s.servers("WHERE s.deleted_at IS NULL AND s.profile = ?", profile)
+	if err != nil {
+		return err
+	}
+	exists := make(map[int64]bool, len(existing))
+	for _, id := range existing {
+		exists[id] = true
+	}
+	for _, server := range servers {
+		if exists[server.ID] || !server.CreatedAt.Before(listedAt) {
+			continue
+		}
+		if err := s.RecordDeleted(server.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Servers returns the recorded servers with their last deployment, the newest first
+func (s *Store) Servers(includeDeleted bool) ([]Server, error) {
+	if includeDeleted {
+		return s.servers("")
+	}
+	return s.servers("WHERE s.deleted_at IS NULL")
+}
+
+// Server returns the record of one server, false if liftoff did not create it
+func (s *Store) Server(serverID int64) (Server, bool) {
+	servers, err := s.servers("WHERE s.id = ?", serverID)
+	if err != nil || len(servers) == 0 {
+		return Server{}, false
+	}
+	return servers[0], true
+}
+
+func (s *Store) servers(where string, args ...any) ([]Server, error) {
+	if s == nil {
+		return nil, errors.New("inventory is not available")
+	}
+	rows, err := s.db.Query(`SELECT s.id, s.name, s.profile, s.server_type, s.location, s.cloud_config, s.created_at, s.deleted_at,
+		d.recipe, d.repo, d.commit_hash, d.deployed_at
+		FROM servers s
+		LEFT JOIN deployments d ON d.id = (SELECT id FROM deployments WHERE server_id = s.id ORDER BY deployed_at DESC, id DESC LIMIT 1)
+		`+where+` ORDER BY s.created_at DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var servers []Server
+	for rows.Next() {
+		var server Server
+		var deletedAt, deployedAt sql.NullTime
+		var recipe, repo, commit sql.NullString
+		err := rows.Scan(&server.ID, &server.Name, &server.Profile, &server.ServerType, &server.Location, &server.CloudConfig,
+			&server.CreatedAt, &deletedAt, &recipe, &repo, &commit, &deployedAt)
+		if err != nil {
+			return nil, err
+		}
+		server.DeletedAt = deletedAt.Time
+		server.Recipe, server.Repo, server.Commit = recipe.String, repo.String, commit.String
+		server.DeployedAt = deployedAt.Time
+		servers = append(servers, server)
+	}
+	return servers, rows.Err()
+}
+
+// Deployments returns all deployments of the server, the newest first
+func (s *Store) Deployments(serverID int64) ([]Deployment, error) {
+	if s == nil {
+		return nil, errors.New("inventory is not available")
+	}
+	rows, err := s.db.Query(`SELECT server_id, recipe, repo, commit_hash, deployed_at FROM deployments WHERE server_id = ? ORDER BY deployed_at DESC, id DESC`, serverID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deployments []Deployment
+	for rows.Next() {
+		var deployment Deployment
+		if err := rows.Scan(&deployment.ServerID, &deployment.Recipe, &deployment.Repo, &deployment.Commit, &deployment.DeployedAt); err != nil {
+			return nil, err
+		}
+		deployments = append(deployments, deployment)
+	}
+	return deployments, rows.Err()
+}
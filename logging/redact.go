@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"io"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces a secret in the log
+const Redacted = "[REDACTED]"
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+)
+
+// Redact registers a secret, e.g. an api token, which never shows up in the log
+func Redact(secret string) {
+	if secret == "" {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, known := range secrets {
+		if known == secret {
+			return
+		}
+	}
+	secrets = append(secrets, secret)
+}
+
+// RedactString replaces all registered secrets
+func RedactString(s string) string {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	return s
+}
+
+// RedactWriter removes the registered secrets before writing to W, used as log output
+type RedactWriter struct {
+	W io.Writer
+}
+
+func (r RedactWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.W, RedactString(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"bytes"
+	"testing"
+)
+
+// resetSecrets clears the registered secrets, they are global to the process
+func resetSecrets(t *testing.T) {
+	t.Helper()
+	secretsMu.Lock()
+	secrets = nil
+	secretsMu.Unlock()
+	t.Cleanup(func() {
+		secretsMu.Lock()
+		secrets = nil
+		secretsMu.Unlock()
+	})
+}
+
+func TestRedactString(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets []string
+		input   string
+		want    string
+	}{
+		{"no secrets", nil, "token abc", "token abc"},
+		{"secret replaced", []string{"abc"}, "token abc", "token " + Redacted},
+		{"every occurrence", []string{"abc"}, "abc and abc", Redacted + " and " + Redacted},
+		{"secret registered twice", []string{"abc", "abc"}, "token abc", "token " + Redacted},
+		{"empty secret is ignored", []string{""}, "token abc", "token abc"},
+		{"several secrets", []string{"abc", "xyz"}, "abc xyz", Redacted + " " + Redacted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetSecrets(t)
+			for _, secret := range tt.secrets {
+				Redact(secret)
+			}
+			if got := RedactString(tt.input); got != tt.want {
+				t.Errorf("RedactString(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactRegistersOnce(t *testing.T) {
+	resetSecrets(t)
+	Redact("abc")
+	Redact("abc")
+	Redact("")
+	if len(secrets) != 1 {
+		t.Errorf("got %d registered secrets, want 1", len(secrets))
+	}
+}
+
+func TestRedactWriter(t *testing.T) {
+	resetSecrets(t)
+	Redact("a-long-api-token")
+	var out bytes.Buffer
+	input := []byte("using a-long-api-token\n")
+	n, err := RedactWriter{W: &out}.Write(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the caller wrote all of p, even though less ended up in W
+	if n != len(input) {
+		t.Errorf("Write() = %d, want %d", n, len(input))
+	}
+	if want := "using " + Redacted + "\n"; out.String() != want {
+		t.Errorf("wrote %q, want %q", out.String(), want)
+	}
+}
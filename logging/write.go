@@ -3,6 +3,6 @@ package logging
 import "fmt"
 
 func (w LogWriter) Write(p []byte) (n int, err error) {
-	fmt.Print(string(p))
+	fmt.Print(RedactString(string(p)))
 	return len(p), nil
 }
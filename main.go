@@ -1,26 +1,32 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
+	"os/signal"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/audit"
+	"github.com/crabstars/liftoff/commands"
+	"github.com/crabstars/liftoff/config"
+	"github.com/crabstars/liftoff/inventory"
+	"github.com/crabstars/liftoff/logging"
 	"github.com/crabstars/liftoff/model"
+	"github.com/crabstars/liftoff/secrets"
+	sshconnector "github.com/crabstars/liftoff/ssh"
 	"github.com/joho/godotenv"
 )
 
 func init() {
+	// an optional .env overrides the profile like the environment, e.g. in ci
 	err := godotenv.Load()
-	if err != nil {
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		log.Fatalf("Error loading .env file")
 	}
-	hetznerKey := os.Getenv("HETZNER_CLOUD_API_KEY")
-	sshKeyName := os.Getenv("SSH_KEY_NAME")
-	if hetznerKey == "" || sshKeyName == "" {
-		panic("Add HETZNER_CLOUD_API_KEY and SSH_KEY_NAME to .env file, see .env.example")
-	}
-
 }
 
 func main() {
@@ -33,12 +39,62 @@ func main() {
 		}
 		defer f.Close()
 	}
+	log.SetOutput(logging.RedactWriter{W: log.Writer()})
+
+	// liftoff works without the inventory, only the history is missing then
+	path, err := inventory.Path()
+	if err == nil {
+		inventory.DB, err = inventory.Open(path)
+	}
+	if err != nil {
+		log.Println("inventory not available", err)
+	}
+	defer inventory.DB.Close()
+
+	// like the inventory the audit log is optional
+	path, err = audit.Path()
+	if err == nil {
+		audit.Log, err = audit.Open(path)
+	}
+	if err != nil {
+		log.Println("audit log not available", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := commands.NewApp(runTUI).RunContext(ctx, os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func runTUI(ctx context.Context, conf config.Config, profileName string, profile config.Profile) error {
+	// a locked token file can not ask for the passphrase while the tui owns the terminal
+	secrets.DisablePrompt()
+
+	if profile.Token == "" || profile.SSHKeyName == "" {
+		onboarding := model.NewOnboarding(ctx, conf, profileName, profile)
+		result, err := tea.NewProgram(onboarding, tea.WithAltScreen()).Run()
+		if err != nil {
+			return err
+		}
+		onboarding = result.(model.Onboarding)
+		if !onboarding.Done {
+			path, _ := config.Path()
+			return fmt.Errorf("profile %s needs a token and an ssh key name, add them to %s or set HETZNER_CLOUD_API_KEY and SSH_KEY_NAME", profileName, path)
+		}
+		conf, profileName, profile = onboarding.Config, onboarding.ProfileName, onboarding.Profile
+		audit.Log.SetProfile(profileName)
+		logging.Redact(profile.Token)
+		sshconnector.KeyPath = profile.SSHKeyPath
+	}
 
-	model := model.InitialModel()
+	model := model.InitialModel(ctx, conf, profileName, profile)
 	p := tea.NewProgram(&model, tea.WithAltScreen())
 	model.Program = p
 
 	if _, err := p.Run(); err != nil {
-		log.Fatal("Error while starting %v", err)
+		log.Fatalf("Error while starting %v", err)
 	}
+	return nil
 }
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/crabstars/liftoff/hetzner"
+)
+
+// finished actions shown below the running ones in the activity panel
+const activityFinishedShown = 5
+
+// ViewActivity is the panel below every screen which lists the hetzner actions liftoff started
+func (m Model) ViewActivity() string {
+	if len(m.Activity) == 0 {
+		return ""
+	}
+	var running, finished []hetzner.TrackedAction
+	for _, action := range m.Activity {
+		if action.Running() {
+			running = append(running, action)
+		} else {
+			finished = append(finished, action)
+		}
+	}
+	if len(finished) > activityFinishedShown {
+		finished = finished[len(finished)-activityFinishedShown:]
+	}
+
+	var builder strings.Builder
+	builder.WriteString("\n Activity\n")
+	for _, action := range running {
+		builder.WriteString(fmt.Sprintf(" %s %-24s %3d%%  %s  %s\n", m.Spinner.View(), action.Command, action.Progress, action.Resources, time.Since(action.Started).Round(time.Second)))
+	}
+	// newest first
+	for i := len(finished) - 1; i >= 0; i-- {
+		action := finished[i]
+		line := fmt.Sprintf(" ✓ %-24s done  %s  %s", action.Command, action.Resources, action.Finished.Sub(action.Started).Round(time.Second))
+		if action.Err != "" {
+			line = errorStyle.Render(fmt.Sprintf(" ✗ %-24s failed  %s: %s", action.Command, action.Resources, action.Err))
+		}
+		builder.WriteString(line + "\n")
+	}
+	return builder.String()
+}
@@ -0,0 +1,193 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/audit"
+)
+
+func (m *Model) showAudit() {
+	m.AuditState.ShowAudit = true
+	m.AuditState.Filtering = false
+	m.loadAuditTable()
+}
+
+// loadAuditTable reads the audit file with the current filter, like the inventory it is read without a command
+func (m *Model) loadAuditTable() {
+	state := &m.AuditState
+	state.Status = ""
+	filter, err := audit.ParseFilter(state.Filter)
+	var records []audit.Record
+	if err == nil {
+		records, err = audit.Log.Records(filter)
+	}
+	if err != nil {
+		state.Status = errorStyle.Render(fmt.Sprintf("could not read the audit log: %s", err))
+	}
+
+	columns := []table.Column{
+		{Title: "Time", Width: 16},
+		{Title: "User", Width: 18},
+		{Title: "Profile", Width: 10},
+		{Title: "Operation", Width: 24},
+		{Title: "Targets", Width: 12},
+		{Title: "Result", Width: 9},
+		{Title: "Error", Width: 30},
+	}
+	rows := make([]table.Row, len(records))
+	for i, record := range records {
+		rows[i] = table.Row{
+			formatDate(record.Time), record.User, record.Profile, record.Operation,
+			strings.Join(record.Targets, ","), record.Result, record.Error,
+		}
+	}
+	state.Records = records
+	state.Table = newStyledTable(columns, rows, state.Table.Cursor())
+}
+
+func (m Model) selectedAuditRecord() *audit.Record {
+	index := m.AuditState.Table.Cursor()
+	if index < 0 || index >= len(m.AuditState.Records) {
+		return nil
+	}
+	return &m.AuditState.Records[index]
+}
+
+// exportAudit writes the shown records as json lines into the working directory
+func (m *Model) exportAudit() {
+	state := &m.AuditState
+	path, err := filepath.Abs(fmt.Sprintf("liftoff-audit-%s.jsonl", time.Now().Format("2006-01-02-150405")))
+	if err != nil {
+		state.Status = errorStyle.Render(err.Error())
+		return
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		state.Status = errorStyle.Render(fmt.Sprintf("could not export: %s", err))
+		return
+	}
+	err = audit.Export(file, state.Records)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		state.Status = errorStyle.Render(fmt.Sprintf("could not export: %s", err))
+		return
+	}
+	state.Status = fmt.Sprintf("%d records exported to %s", len(state.Records), path)
+}
+
+func (m Model) updateAuditState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var submitted bool
+	state := &m.AuditState
+
+	if state.ShowRecord {
+		if msg.Type == tea.KeyEsc || msg.Type == tea.KeyEnter {
+			state.ShowRecord = false
+		}
+		return m, nil
+	}
+	if state.Filtering {
+		if msg.Type == tea.KeyEsc {
+			state.Filtering = false
+			return m, nil
+		}
+		state.Form, cmd, submitted = state.Form.Update(msg)
+		if !submitted {
+			return m, cmd
+		}
+		if _, err := audit.ParseFilter(state.Form.Value(0)); err != nil {
+			state.Form.Err = err.Error()
+			return m, nil
+		}
+		state.Filter = state.Form.Value(0)
+		state.Filtering = false
+		m.loadAuditTable()
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		state.ShowAudit = false
+		return m, nil
+	case "/":
+		state.Filtering = true
+		state.Form = newForm("Filter the audit log, e.g. op=server.delete profile=prod since=24h or free text",
+			formField{Label: "Filter", Value: state.Filter},
+		)
+		return m, nil
+	case "c":
+		state.Filter = ""
+		m.loadAuditTable()
+		return m, nil
+	case "r":
+		m.loadAuditTable()
+		return m, nil
+	case "e":
+		m.exportAudit()
+		return m, nil
+	case "enter":
+		if m.selectedAuditRecord() != nil {
+			state.ShowRecord = true
+		}
+		return m, nil
+	}
+	state.Table, cmd = state.Table.Update(msg)
+	return m, cmd
+}
+
+func (m Model) ViewAudit() string {
+	state := m.AuditState
+	if state.Filtering {
+		return state.Form.View()
+	}
+	if state.ShowRecord {
+		return m.viewAuditRecord()
+	}
+	s := baseStyle.Render(state.Table.View()) + "\n"
+	if state.Filter != "" {
+		s += fmt.Sprintf(" filter: %s\n", state.Filter)
+	}
+	s += " enter details • / filter • c clear filter • r reload • e export • esc back\n"
+	if state.Status != "" {
+		s += "\n " + state.Status + "\n"
+	}
+	return s
+}
+
+func (m Model) viewAuditRecord() string {
+	record := m.selectedAuditRecord()
+	if record == nil {
+		return "Record not found\n\n esc back\n"
+	}
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf(" %s %s\n\n", record.Operation, record.Result))
+	builder.WriteString(fmt.Sprintf(" Time:    %s\n", record.Time.Local().Format(time.RFC3339)))
+	builder.WriteString(fmt.Sprintf(" User:    %s\n", record.User))
+	builder.WriteString(fmt.Sprintf(" Profile: %s\n", record.Profile))
+	builder.WriteString(fmt.Sprintf(" Targets: %s\n", strings.Join(record.Targets, ", ")))
+	if record.Error != "" {
+		builder.WriteString(" Error:   " + errorStyle.Render(record.Error) + "\n")
+	}
+	if len(record.Params) > 0 {
+		builder.WriteString("\n Parameters\n")
+		keys := make([]string, 0, len(record.Params))
+		for key := range record.Params {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			builder.WriteString(fmt.Sprintf("   %s = %s\n", key, record.Params[key]))
+		}
+	}
+	builder.WriteString("\n esc back\n")
+	return builder.String()
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/hetzner"
+)
+
+// budgetOverride is an operation which the budget of the profile blocked,
+// Unchecked runs it again without the check once the user overrides the budget
+type budgetOverride struct {
+	// audit operation, e.g. volume.resize
+	Operation string
+	// what the confirmation asks for, e.g. "Creating volume data"
+	Description string
+	Budget      hetzner.Budget
+	Unchecked   tea.Cmd
+}
+
+// blockedBy stores the budget of a *hetzner.BudgetError and reports whether err was one
+func (o *budgetOverride) blockedBy(err error) bool {
+	var budgetErr *hetzner.BudgetError
+	if !errors.As(err, &budgetErr) {
+		return false
+	}
+	o.Budget = budgetErr.Budget
+	return true
+}
+
+// override writes the override to the audit log and returns the unchecked operation
+func (o budgetOverride) override() tea.Cmd {
+	o.Budget.Override(o.Operation)
+	return o.Unchecked
+}
+
+// view places the confirmation of the override over s
+func (o budgetOverride) view(s string) string {
+	budget := o.Budget
+	return PlaceOverlay(80, 6, fmt.Sprintf("%s raises the monthly run-rate to %s,\nthe budget is %s.\n\nPress 'o' to continue anyway, 'n' to cancel.",
+		o.Description, formatMoney(budget.RunRate+budget.Added, budget.Currency), formatMoney(budget.Limit, budget.Currency)), s)
+}
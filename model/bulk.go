@@ -0,0 +1,277 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/hetzner"
+	sshconnector "github.com/crabstars/liftoff/ssh"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+type BulkProgressMsg struct {
+	Index  int
+	Status string
+	Err    error
+}
+
+func (m *Model) toggleSelection() {
+	server := m.selectedServer()
+	if server == nil {
+		return
+	}
+	if m.TableState.Selected[server.ID] {
+		delete(m.TableState.Selected, server.ID)
+	} else {
+		m.TableState.Selected[server.ID] = true
+	}
+	m.refreshServerTable()
+}
+
+// toggleSelectAll selects every listed server, if all are selected already the selection is cleared
+func (m *Model) toggleSelectAll() {
+	if len(m.TableState.Selected) == len(m.TableState.Servers) {
+		m.TableState.Selected = make(map[int64]bool)
+	} else {
+		for _, server := range m.TableState.Servers {
+			m.TableState.Selected[server.ID] = true
+		}
+	}
+	m.refreshServerTable()
+}
+
+func (m Model) selectedServers() []*hcloud.Server {
+	var servers []*hcloud.Server
+	for _, server := range m.TableState.Servers {
+		if m.TableState.Selected[server.ID] {
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+// serverMatches is true if the name contains the filter or the labels match all key=value pairs of it
+func serverMatches(server *hcloud.Server, filter string) bool {
+	if !strings.Contains(filter, "=") {
+		return strings.Contains(server.Name, filter)
+	}
+	labels, err := hetzner.ParseLabelFilter(filter)
+	if err != nil {
+		return false
+	}
+	for key, value := range labels {
+		if server.Labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Model) startBulkMenu() {
+	count := len(m.selectedServers())
+	if count == 0 {
+		m.TableState.Status = errorStyle.Render("no servers selected, press space to select")
+		return
+	}
+	m.TableState.BulkMode = bulkModeChoose
+	m.TableState.BulkChoice = newChoiceList(fmt.Sprintf("Run on %d selected servers", count), hetzner.BulkActions...)
+}
+
+func (m *Model) startBulkFilter() {
+	m.TableState.BulkMode = bulkModeFilter
+	m.TableState.BulkForm = newForm("Select servers",
+		formField{Label: "Name contains or labels (key=value, comma separated)", Placeholder: "api or env=staging"},
+	)
+}
+
+func (m Model) updateBulkState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var submitted, selected bool
+	state := &m.TableState
+
+	if state.BulkMode == bulkModeProgress {
+		if state.BulkRunning && msg.String() == "x" && state.BulkCancel != nil {
+			state.BulkCancel()
+			state.BulkCancel = nil
+		}
+		if !state.BulkRunning && (msg.Type == tea.KeyEnter || msg.Type == tea.KeyEsc) {
+			state.BulkMode = bulkModeNone
+			state.BulkProgress = nil
+		}
+		return m, nil
+	}
+	if msg.Type == tea.KeyEsc {
+		state.BulkMode = bulkModeNone
+		return m, nil
+	}
+
+	switch state.BulkMode {
+	case bulkModeFilter:
+		state.BulkForm, cmd, submitted = state.BulkForm.Update(msg)
+		if !submitted {
+			return m, cmd
+		}
+		filter := state.BulkForm.Value(0)
+		if strings.Contains(filter, "=") {
+			if _, err := hetzner.ParseLabelFilter(filter); err != nil {
+				state.BulkForm.Err = err.Error()
+				return m, nil
+			}
+		}
+		for _, server := range state.Servers {
+			if serverMatches(server, filter) {
+				state.Selected[server.ID] = true
+			}
+		}
+		state.BulkMode = bulkModeNone
+		m.refreshServerTable()
+		return m, nil
+
+	case bulkModeChoose:
+		state.BulkChoice, selected = state.BulkChoice.Update(msg.String())
+		if !selected {
+			return m, nil
+		}
+		state.BulkAction = hetzner.BulkActions[state.BulkChoice.Cursor]
+		if state.BulkAction == hetzner.BulkLabel {
+			state.BulkMode = bulkModeLabels
+			state.BulkForm = newForm("Add labels to the selected servers, existing keys are overwritten",
+				formField{Label: "Labels (key=value, comma separated)", Placeholder: "env=staging"},
+			)
+			return m, nil
+		}
+		state.BulkMode = bulkModeConfirm
+		return m, nil
+
+	case bulkModeLabels:
+		state.BulkForm, cmd, submitted = state.BulkForm.Update(msg)
+		if !submitted {
+			return m, cmd
+		}
+		labels, err := hetzner.ParseLabels(state.BulkForm.Value(0))
+		if err == nil && len(labels) == 0 {
+			err = fmt.Errorf("no labels to add")
+		}
+		if err != nil {
+			state.BulkForm.Err = err.Error()
+			return m, nil
+		}
+		state.BulkLabels = labels
+		state.BulkMode = bulkModeConfirm
+		return m, nil
+
+	case bulkModeConfirm:
+		switch msg.String() {
+		case "y":
+			return m, m.startBulkAction()
+		case "n":
+			state.BulkMode = bulkModeNone
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) startBulkAction() tea.Cmd {
+	state := &m.TableState
+	servers := m.selectedServers()
+	var work func(context.Context, *hcloud.Server) error
+	if state.BulkAction != hetzner.BulkRedeploy {
+		var err error
+		work, err = hetzner.ServerActionWork(m.EnvValues.HetznerApiKey, state.BulkAction, state.BulkLabels)
+		if err != nil {
+			state.BulkMode = bulkModeNone
+			state.Status = errorStyle.Render(err.Error())
+			return nil
+		}
+	}
+
+	state.BulkMode = bulkModeProgress
+	state.BulkRunning = true
+	state.BulkResult = ""
+	state.BulkProgress = make([]taskProgress, len(servers))
+	for i, server := range servers {
+		state.BulkProgress[i] = taskProgress{Name: server.Name, Status: hetzner.TaskWaiting}
+	}
+	program := m.Program
+	progress := func(index int, status string, err error) {
+		program.Send(BulkProgressMsg{Index: index, Status: status, Err: err})
+	}
+	ctx, cancel := context.WithCancel(m.Ctx)
+	state.BulkCancel = cancel
+	if state.BulkAction == hetzner.BulkRedeploy {
+		return tea.Batch(m.Spinner.Tick, hetzner.RunBulkRedeploy(ctx, m.EnvValues.HetznerApiKey, servers, sshconnector.ExampleCSharpWeatherRedeploy, progress))
+	}
+	return tea.Batch(m.Spinner.Tick, hetzner.RunBulkAction(ctx, m.EnvValues.HetznerApiKey, state.BulkAction, servers, work, progress))
+}
+
+func (m *Model) setBulkProgress(msg BulkProgressMsg) {
+	if msg.Index < 0 || msg.Index >= len(m.TableState.BulkProgress) {
+		return
+	}
+	m.TableState.BulkProgress[msg.Index].Status = msg.Status
+	m.TableState.BulkProgress[msg.Index].Err = msg.Err
+}
+
+func (m *Model) finishBulkAction(msg hetzner.BulkActionDoneMsg) {
+	state := &m.TableState
+	state.BulkRunning = false
+	state.BulkCancel = nil
+	state.BulkLabels = nil
+	state.Selected = make(map[int64]bool)
+	if msg.Failed > 0 {
+		state.BulkResult = errorStyle.Render(fmt.Sprintf("%s done on %d servers, %d failed", msg.Action, msg.Done, msg.Failed))
+	} else {
+		state.BulkResult = fmt.Sprintf("%s done on %d servers", msg.Action, msg.Done)
+	}
+	m.requestRefresh()
+}
+
+func (m Model) ViewBulk() string {
+	state := m.TableState
+	switch state.BulkMode {
+	case bulkModeFilter, bulkModeLabels:
+		return state.BulkForm.View()
+	case bulkModeChoose:
+		return state.BulkChoice.View()
+	}
+
+	done, failed := 0, 0
+	for _, task := range state.BulkProgress {
+		switch task.Status {
+		case hetzner.TaskDone:
+			done++
+		case hetzner.TaskFailed, hetzner.TaskCancelled:
+			failed++
+		}
+	}
+	s := fmt.Sprintf(" %s: %d/%d done, %d failed\n\n", state.BulkAction, done, len(state.BulkProgress), failed)
+	s += m.viewTasks(state.BulkProgress)
+	if state.BulkRunning && state.BulkCancel == nil {
+		s += "\n cancelling, running servers report the state they were left in\n"
+	} else if state.BulkRunning {
+		s += "\n x cancel\n"
+	}
+	if state.BulkResult != "" {
+		s += "\n " + state.BulkResult + "\n\n press enter to continue\n"
+	}
+	return s
+}
+
+// ViewBulkConfirm lists the servers the bulk action runs on
+func (m Model) ViewBulkConfirm() string {
+	servers := m.selectedServers()
+	names := make([]string, len(servers))
+	for i, server := range servers {
+		names[i] = server.Name
+	}
+	s := fmt.Sprintf("Run %s on %d servers?\n\n%s\n", m.TableState.BulkAction, len(servers), strings.Join(names, ", "))
+	if m.TableState.BulkAction == hetzner.BulkLabel {
+		s += fmt.Sprintf("\nlabels: %s\n", hetzner.FormatLabels(m.TableState.BulkLabels))
+	}
+	if m.TableState.BulkAction == hetzner.BulkDelete {
+		s += "\nservers with delete protection are skipped and reported as failed\n"
+	}
+	return s + "\nPress 'y' to confirm, 'n' to cancel."
+}
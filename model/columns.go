@@ -0,0 +1,184 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/config"
+	"github.com/crabstars/liftoff/hetzner"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// serverColumn describes one column of the server table
+type serverColumn struct {
+	Title string
+	// minimum width, flexible columns share the free space of the terminal
+	Width int
+	Flex  bool
+	Value func(*hcloud.Server) string
+	// optional, columns without compare their values as text
+	Less func(a, b *hcloud.Server) bool
+}
+
+// columnRegistry holds every column the server table can show, the key is stored in the config file
+var columnRegistry = map[string]serverColumn{
+	"name":     {Title: "Name", Width: 20, Flex: true, Value: func(s *hcloud.Server) string { return s.Name }},
+	"ipv4":     {Title: "IPv4", Width: 15, Value: hetzner.PublicIPv4},
+	"ipv6":     {Title: "IPv6", Width: 22, Value: hetzner.PublicIPv6},
+	"status":   {Title: "Status", Width: 10, Value: func(s *hcloud.Server) string { return string(s.Status) }},
+	"location": {Title: "Location", Width: 10, Value: func(s *hcloud.Server) string { return s.Datacenter.Location.City }},
+	"type":     {Title: "Server Type", Width: 11, Value: func(s *hcloud.Server) string { return s.ServerType.Name }},
+	"cores": {Title: "Cores", Width: 5, Value: func(s *hcloud.Server) string { return fmt.Sprintf("%d", s.ServerType.Cores) },
+		Less: func(a, b *hcloud.Server) bool { return a.ServerType.Cores < b.ServerType.Cores }},
+	"memory": {Title: "Memory", Width: 6, Value: func(s *hcloud.Server) string { return fmt.Sprintf("%.0f GB", s.ServerType.Memory) },
+		Less: func(a, b *hcloud.Server) bool { return a.ServerType.Memory < b.ServerType.Memory }},
+	"disk": {Title: "Disk", Width: 6, Value: func(s *hcloud.Server) string { return fmt.Sprintf("%d GB", s.ServerType.Disk) },
+		Less: func(a, b *hcloud.Server) bool { return a.ServerType.Disk < b.ServerType.Disk }},
+	"labels": {Title: "Labels", Width: 15, Flex: true, Value: func(s *hcloud.Server) string { return hetzner.FormatLabels(s.Labels) }},
+	"created": {Title: "Created", Width: 16, Value: func(s *hcloud.Server) string { return s.Created.Format("2006-01-02 15:04") },
+		Less: func(a, b *hcloud.Server) bool { return a.Created.Before(b.Created) }},
+	"cost": {Title: "Monthly", Width: 10, Value: monthlyCostText,
+		Less: func(a, b *hcloud.Server) bool { return hetzner.MonthlyCost(a) < hetzner.MonthlyCost(b) }},
+	"provisioning": {Title: "Provisioning", Width: 12, Value: hetzner.ProvisioningState},
+	"app":          {Title: "App", Width: 12, Flex: true, Value: func(s *hcloud.Server) string { return s.Labels[hetzner.LabelApp] }},
+	"image":        {Title: "Image", Width: 12, Flex: true, Value: imageName},
+	"cpu":          {Title: "CPU Type", Width: 8, Value: func(s *hcloud.Server) string { return string(s.ServerType.CPUType) }},
+	"private-ip":   {Title: "Private IP", Width: 11, Value: hetzner.PrivateIP},
+	"protected":    {Title: "Protected", Width: 9, Value: protectionText},
+}
+
+// columnOrder is the order of the registry in the column editor
+var columnOrder = []string{"name", "ipv4", "ipv6", "status", "location", "type", "cores", "memory", "disk", "labels", "created", "cost", "provisioning", "app", "image", "cpu", "private-ip", "protected"}
+
+var defaultColumns = []string{"name", "ipv4", "status", "location", "type", "cores", "memory", "disk", "private-ip", "labels", "protected"}
+
+// columnKeys returns the configured columns without unknown keys, the defaults if none are left
+func columnKeys(conf config.Config) []string {
+	var keys []string
+	for _, key := range conf.Columns {
+		if _, ok := columnRegistry[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return defaultColumns
+	}
+	return keys
+}
+
+func (m Model) visibleColumns() []serverColumn {
+	columns := make([]serverColumn, len(m.TableState.Columns))
+	for i, key := range m.TableState.Columns {
+		columns[i] = columnRegistry[key]
+	}
+	return columns
+}
+
+func monthlyCostText(server *hcloud.Server) string {
+	cost := hetzner.MonthlyCost(server)
+	if cost == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.2f €", cost)
+}
+
+// columnChoice is one line of the column editor
+type columnChoice struct {
+	Key     string
+	Visible bool
+}
+
+// startColumnEditor lists the visible columns in their order followed by the hidden ones
+func (m *Model) startColumnEditor() {
+	visible := make(map[string]bool, len(m.TableState.Columns))
+	var choices []columnChoice
+	for _, key := range m.TableState.Columns {
+		visible[key] = true
+		choices = append(choices, columnChoice{Key: key, Visible: true})
+	}
+	for _, key := range columnOrder {
+		if !visible[key] {
+			choices = append(choices, columnChoice{Key: key})
+		}
+	}
+	m.TableState.ColumnChoices = choices
+	m.TableState.ColumnCursor = 0
+	m.TableState.EditingColumns = true
+}
+
+// updateColumnEditor toggles with space, moves a column with K and J and saves to the config file with enter
+func (m Model) updateColumnEditor(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	state := &m.TableState
+	choices := state.ColumnChoices
+	cursor := state.ColumnCursor
+
+	switch msg.String() {
+	case "esc":
+		state.EditingColumns = false
+	case "up", "k":
+		if cursor > 0 {
+			state.ColumnCursor--
+		}
+	case "down", "j":
+		if cursor < len(choices)-1 {
+			state.ColumnCursor++
+		}
+	case "K", "shift+up":
+		if cursor > 0 {
+			choices[cursor-1], choices[cursor] = choices[cursor], choices[cursor-1]
+			state.ColumnCursor--
+		}
+	case "J", "shift+down":
+		if cursor < len(choices)-1 {
+			choices[cursor+1], choices[cursor] = choices[cursor], choices[cursor+1]
+			state.ColumnCursor++
+		}
+	case " ":
+		choices[cursor].Visible = !choices[cursor].Visible
+	case "enter":
+		var keys []string
+		for _, choice := range choices {
+			if choice.Visible {
+				keys = append(keys, choice.Key)
+			}
+		}
+		if len(keys) == 0 {
+			state.Status = errorStyle.Render("at least one column has to be visible")
+			return m, nil
+		}
+		state.Columns = keys
+		state.SortColumn = -1
+		state.EditingColumns = false
+		m.Config.Columns = keys
+		if err := config.Save(m.Config); err != nil {
+			state.Status = errorStyle.Render(fmt.Sprintf("columns could not be saved: %s", err))
+		} else {
+			state.Status = "columns saved"
+		}
+		m.refreshServerTable()
+	}
+	return m, nil
+}
+
+func (m Model) ViewColumnEditor() string {
+	state := m.TableState
+	var builder strings.Builder
+	builder.WriteString("Columns of the server table\n\n")
+	for i, choice := range state.ColumnChoices {
+		cursor := " "
+		if i == state.ColumnCursor {
+			cursor = ">"
+		}
+		checked := " "
+		if choice.Visible {
+			checked = "x"
+		}
+		builder.WriteString(fmt.Sprintf("%s [%s] %s\n", cursor, checked, columnRegistry[choice.Key].Title))
+	}
+	builder.WriteString("\n space show/hide • K/J move up/down • enter save • esc cancel\n")
+	if state.Status != "" {
+		builder.WriteString("\n " + state.Status + "\n")
+	}
+	return builder.String()
+}
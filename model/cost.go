@@ -0,0 +1,190 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/hetzner"
+)
+
+// defaultCostLabel groups the cost screen until another label is chosen
+const defaultCostLabel = "project"
+
+func (m *Model) showCosts() tea.Cmd {
+	state := &m.CostState
+	state.ShowCosts = true
+	state.EditingGroup = false
+	state.ShowItems = false
+	state.Loading = true
+	state.Status = ""
+	if state.GroupBy == "" {
+		state.GroupBy = defaultCostLabel
+	}
+	m.loadCostTable()
+	return tea.Batch(m.Spinner.Tick, hetzner.LoadCosts(m.Ctx, m.EnvValues.HetznerApiKey))
+}
+
+func (m *Model) setCosts(msg hetzner.CostsLoadedMsg) {
+	state := &m.CostState
+	state.Loading = false
+	if msg.Err != nil {
+		state.Status = errorStyle.Render(fmt.Sprintf("could not load costs: %s", msg.Err))
+		return
+	}
+	state.Items = msg.Items
+	state.Currency = msg.Currency
+	m.loadCostTable()
+}
+
+// loadCostTable groups the loaded items by the label, the total row of the project comes last
+func (m *Model) loadCostTable() {
+	state := &m.CostState
+	state.Groups, state.Total = hetzner.GroupCosts(state.Items, state.GroupBy, time.Now())
+
+	columns := []table.Column{
+		{Title: state.GroupBy, Width: 24},
+		{Title: "Resources", Width: 10},
+		{Title: "Per hour", Width: 12},
+		{Title: "Per month", Width: 14},
+		{Title: "This month", Width: 14},
+		{Title: "Month end", Width: 14},
+	}
+	rows := make([]table.Row, 0, len(state.Groups)+1)
+	for _, group := range state.Groups {
+		rows = append(rows, costRow(group, state.Currency))
+	}
+	rows = append(rows, costRow(state.Total, state.Currency))
+	state.Table = newStyledTable(columns, rows, state.Table.Cursor())
+}
+
+func costRow(group hetzner.CostGroup, currency string) table.Row {
+	return table.Row{
+		group.Name, fmt.Sprintf("%d", len(group.Items)), formatHourly(group.RunRate.Hourly, currency),
+		formatMoney(group.RunRate.Monthly, currency), formatMoney(group.MonthToDate, currency), formatMoney(group.Projected, currency),
+	}
+}
+
+// selectedCostGroup returns the group of the cursor, the last row is the total
+func (m Model) selectedCostGroup() *hetzner.CostGroup {
+	index := m.CostState.Table.Cursor()
+	if index == len(m.CostState.Groups) {
+		return &m.CostState.Total
+	}
+	if index < 0 || index > len(m.CostState.Groups) {
+		return nil
+	}
+	return &m.CostState.Groups[index]
+}
+
+func (m Model) updateCostState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var submitted bool
+	state := &m.CostState
+
+	if state.ShowItems {
+		if msg.Type == tea.KeyEsc || msg.Type == tea.KeyEnter {
+			state.ShowItems = false
+		}
+		return m, nil
+	}
+	if state.EditingGroup {
+		if msg.Type == tea.KeyEsc {
+			state.EditingGroup = false
+			return m, nil
+		}
+		state.GroupForm, cmd, submitted = state.GroupForm.Update(msg)
+		if !submitted {
+			return m, cmd
+		}
+		if state.GroupForm.Value(0) == "" {
+			state.GroupForm.Err = "label key is required"
+			return m, nil
+		}
+		state.GroupBy = state.GroupForm.Value(0)
+		state.EditingGroup = false
+		m.loadCostTable()
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		state.ShowCosts = false
+		return m, nil
+	case "g":
+		state.EditingGroup = true
+		state.GroupForm = newForm("Group the costs by a label",
+			formField{Label: "Label key", Value: state.GroupBy},
+		)
+		return m, nil
+	case "r":
+		if state.Loading {
+			return m, nil
+		}
+		return m, m.showCosts()
+	case "enter":
+		if m.selectedCostGroup() != nil {
+			state.ShowItems = true
+		}
+		return m, nil
+	}
+	state.Table, cmd = state.Table.Update(msg)
+	return m, cmd
+}
+
+func (m Model) ViewCosts() string {
+	state := m.CostState
+	if state.EditingGroup {
+		return state.GroupForm.View()
+	}
+	if state.ShowItems {
+		return m.viewCostItems()
+	}
+	s := baseStyle.Render(state.Table.View()) + "\n"
+	s += fmt.Sprintf(" gross prices of profile %s, traffic and backups are not included\n", m.ProfileName)
+	s += " enter resources • g group by label • r reload • esc back\n"
+	if state.Loading {
+		s += fmt.Sprintf("\n %s loading prices...\n", m.Spinner.View())
+	}
+	if state.Status != "" {
+		s += "\n " + state.Status + "\n"
+	}
+	return s
+}
+
+func (m Model) viewCostItems() string {
+	group := m.selectedCostGroup()
+	if group == nil {
+		return "Group not found\n\n esc back\n"
+	}
+	currency := m.CostState.Currency
+	now := time.Now()
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf(" Resources of %s=%s\n\n", m.CostState.GroupBy, group.Name))
+	for _, item := range group.Items {
+		builder.WriteString(fmt.Sprintf(" %-14s %-30s %-6s %14s %14s %14s\n", item.Kind, item.Name, item.Location,
+			formatMoney(item.Cost.Monthly, currency), formatMoney(item.MonthToDate(now), currency), formatMoney(item.ProjectedMonth(now), currency)))
+	}
+	builder.WriteString("\n columns: per month, this month, month end\n\n esc back\n")
+	return builder.String()
+}
+
+// viewEstimate lists the lines of an estimate with their hourly and monthly price
+func viewEstimate(estimate hetzner.CostEstimate) string {
+	var builder strings.Builder
+	for _, line := range estimate.Lines {
+		builder.WriteString(fmt.Sprintf("  %-36s %12s %14s\n", line.Description, formatHourly(line.Cost.Hourly, estimate.Currency), formatMoney(line.Cost.Monthly, estimate.Currency)))
+	}
+	builder.WriteString(fmt.Sprintf("  %-36s %12s %14s\n", "total per hour / month", formatHourly(estimate.Total.Hourly, estimate.Currency), formatMoney(estimate.Total.Monthly, estimate.Currency)))
+	return builder.String()
+}
+
+func formatMoney(amount float64, currency string) string {
+	return fmt.Sprintf("%.2f %s", amount, currency)
+}
+
+func formatHourly(amount float64, currency string) string {
+	return fmt.Sprintf("%.4f %s", amount, currency)
+}
@@ -0,0 +1,637 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/hetzner"
+	sshconnector "github.com/crabstars/liftoff/ssh"
+)
+
+const (
+	volumeChoiceNone = iota
+	volumeChoiceNew
+	volumeChoiceExisting
+)
+
+const (
+	firewallChoiceNone = iota
+	firewallChoiceNew
+	firewallChoiceExisting
+)
+
+func (m *Model) startCreateWizard() {
+	m.CreateServerState.Options = hetzner.CreateServerModel{
+		DeployCountry: hetzner.CountryGermany,
+		SshKeyNames:   []string{m.EnvValues.SshKeyName},
+		ServerType:    m.Profile.ServerType,
+		Image:         m.Profile.Image,
+		Location:      m.Profile.Location,
+		CloudConfig:   m.Profile.CloudConfig,
+		Profile:       m.ProfileName,
+	}
+	m.CreateServerState.Step = createStepName
+	m.CreateServerState.Count = 1
+	m.CreateServerState.ServerNameInput.Focus()
+}
+
+func (m *Model) cancelCreateWizard() {
+	m.CreateServerState.Step = createStepNone
+	m.CreateServerState.ServerNameInput.Reset()
+}
+
+func (m Model) updateCreateServerState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	state := &m.CreateServerState
+
+	if msg.Type == tea.KeyEsc {
+		m.cancelCreateWizard()
+		return m, nil
+	}
+
+	switch state.Step {
+	case createStepName:
+		if msg.Type == tea.KeyEnter {
+			if state.ServerNameInput.Value() == "" {
+				return m, nil
+			}
+			state.Options.ServerName = state.ServerNameInput.Value()
+			if strings.Contains(state.Options.ServerName, hetzner.FleetNumberPlaceholder) {
+				state.Step = createStepCount
+				state.CountForm = newForm(fmt.Sprintf("How many servers named %s?", state.Options.ServerName),
+					formField{Label: "Count", Value: "3"},
+				)
+				return m, nil
+			}
+			state.Count = 1
+			return m, m.nextCreateStep(createStepName)
+		}
+		state.ServerNameInput, cmd = state.ServerNameInput.Update(msg)
+		return m, cmd
+
+	case createStepCount:
+		var submitted bool
+		state.CountForm, cmd, submitted = state.CountForm.Update(msg)
+		if !submitted {
+			return m, cmd
+		}
+		count, err := strconv.Atoi(state.CountForm.Value(0))
+		if err == nil {
+			_, err = hetzner.FleetNames(state.Options.ServerName, count)
+		}
+		if err != nil {
+			state.CountForm.Err = "count must be a number of at least 1"
+			return m, nil
+		}
+		state.Count = count
+		return m, m.nextCreateStep(createStepName)
+
+	case createStepSshKeys:
+		var submitted bool
+		state.SshKeyChoices, submitted = state.SshKeyChoices.Update(msg.String())
+		if !submitted || len(state.SshKeys) == 0 {
+			return m, nil
+		}
+		var names []string
+		for _, index := range state.SshKeyChoices.Selected() {
+			names = append(names, state.SshKeys[index].Name)
+		}
+		if len(names) == 0 {
+			state.SshKeyChoices.Err = "select at least one key, space toggles"
+			return m, nil
+		}
+		state.Options.SshKeyNames = names
+		return m, m.nextCreateStep(createStepSshKeys)
+
+	case createStepVolume:
+		var selected bool
+		state.StepChoices, selected = state.StepChoices.Update(msg.String())
+		if !selected {
+			return m, nil
+		}
+		switch state.StepChoices.Cursor {
+		case volumeChoiceNone:
+			state.Options.Volume = nil
+			return m, m.nextCreateStep(createStepVolume)
+		case volumeChoiceNew:
+			state.Step = createStepNewVolume
+			title := "New volume, mounted at /mnt/<name>"
+			if m.isFleet() {
+				title = "New volume for every server, named <server>-data and mounted at /mnt/<server>-data"
+			}
+			state.VolumeForm = newForm(title,
+				formField{Label: "Name", Value: state.Options.ServerName + "-data"},
+				formField{Label: "Size in GB (min 10)", Value: "10"},
+				formField{Label: "Filesystem (ext4 or xfs)", Value: hetzner.VolumeFormatExt4},
+			)
+		case volumeChoiceExisting:
+			state.Step = createStepExistingVolume
+			state.StepChoices = newChoiceList("Loading volumes...")
+			return m, hetzner.LoadVolumes(m.Ctx, m.EnvValues.HetznerApiKey)
+		}
+		return m, nil
+
+	case createStepNewVolume:
+		var submitted bool
+		state.VolumeForm, cmd, submitted = state.VolumeForm.Update(msg)
+		if !submitted {
+			return m, cmd
+		}
+		volumeOption, err := volumeOptionFromForm(state.VolumeForm)
+		if err != nil {
+			state.VolumeForm.Err = err.Error()
+			return m, nil
+		}
+		state.Options.Volume = &volumeOption
+		return m, m.nextCreateStep(createStepVolume)
+
+	case createStepExistingVolume:
+		var selected bool
+		state.StepChoices, selected = state.StepChoices.Update(msg.String())
+		if !selected || len(state.Volumes) == 0 {
+			return m, nil
+		}
+		volume := state.Volumes[state.StepChoices.Cursor]
+		state.Options.Volume = &hetzner.VolumeOption{VolumeID: volume.ID, Name: volume.Name}
+		return m, m.nextCreateStep(createStepVolume)
+
+	case createStepFirewall:
+		var selected bool
+		state.StepChoices, selected = state.StepChoices.Update(msg.String())
+		if !selected {
+			return m, nil
+		}
+		switch state.StepChoices.Cursor {
+		case firewallChoiceNone:
+			state.Options.Firewall = nil
+			return m, m.nextCreateStep(createStepFirewall)
+		case firewallChoiceNew:
+			state.Step = createStepNewFirewall
+			state.FirewallForm = newForm("New firewall, ssh is always opened",
+				formField{Label: "Name", Value: state.Options.ServerName + "-firewall"},
+				formField{Label: "App port to open", Value: sshconnector.AppPort},
+			)
+		case firewallChoiceExisting:
+			state.Step = createStepExistingFirewall
+			state.StepChoices = newChoiceList("Loading firewalls...")
+			return m, hetzner.LoadFirewalls(m.Ctx, m.EnvValues.HetznerApiKey)
+		}
+		return m, nil
+
+	case createStepNewFirewall:
+		var submitted bool
+		state.FirewallForm, cmd, submitted = state.FirewallForm.Update(msg)
+		if !submitted {
+			return m, cmd
+		}
+		if state.FirewallForm.Value(0) == "" {
+			state.FirewallForm.Err = "name is required"
+			return m, nil
+		}
+		if _, err := hetzner.ParseFirewallRule("in", "tcp", state.FirewallForm.Value(1), "0.0.0.0/0"); err != nil {
+			state.FirewallForm.Err = err.Error()
+			return m, nil
+		}
+		state.Options.Firewall = &hetzner.FirewallOption{Name: state.FirewallForm.Value(0), AppPort: state.FirewallForm.Value(1)}
+		return m, m.nextCreateStep(createStepFirewall)
+
+	case createStepExistingFirewall:
+		var selected bool
+		state.StepChoices, selected = state.StepChoices.Update(msg.String())
+		if !selected || len(state.Firewalls) == 0 {
+			return m, nil
+		}
+		firewall := state.Firewalls[state.StepChoices.Cursor]
+		state.Options.Firewall = &hetzner.FirewallOption{FirewallID: firewall.ID, Name: firewall.Name}
+		return m, m.nextCreateStep(createStepFirewall)
+
+	case createStepNetwork:
+		var selected bool
+		state.StepChoices, selected = state.StepChoices.Update(msg.String())
+		if !selected {
+			return m, nil
+		}
+		// the first choice is no network
+		if state.StepChoices.Cursor == 0 || len(state.Networks) == 0 {
+			state.Options.Network = nil
+			return m, m.nextCreateStep(createStepNetwork)
+		}
+		network := state.Networks[state.StepChoices.Cursor-1]
+		state.Options.Network = &hetzner.NetworkOption{NetworkID: network.ID, Name: network.Name}
+		// every server of a fleet gets the next free ip
+		if m.isFleet() {
+			return m, m.nextCreateStep(createStepNetwork)
+		}
+		state.Step = createStepNetworkIP
+		state.NetworkForm = newForm(fmt.Sprintf("Private IP in %s (%s)", network.Name, network.IPRange),
+			formField{Label: "Private IP (empty for automatic)", Placeholder: "10.0.1.10"},
+		)
+		return m, nil
+
+	case createStepNetworkIP:
+		var submitted bool
+		state.NetworkForm, cmd, submitted = state.NetworkForm.Update(msg)
+		if !submitted {
+			return m, cmd
+		}
+		network := state.Networks[state.StepChoices.Cursor-1]
+		if err := validatePrivateIP(network, state.NetworkForm.Value(0)); err != nil {
+			state.NetworkForm.Err = err.Error()
+			return m, nil
+		}
+		state.Options.Network.IP = state.NetworkForm.Value(0)
+		return m, m.nextCreateStep(createStepNetwork)
+
+	case createStepPrimaryIP:
+		var selected bool
+		state.StepChoices, selected = state.StepChoices.Update(msg.String())
+		if !selected {
+			return m, nil
+		}
+		// the first choice is a new ip
+		if state.StepChoices.Cursor == 0 || len(state.PrimaryIPs) == 0 {
+			state.Options.PrimaryIP = nil
+		} else {
+			ip := state.PrimaryIPs[state.StepChoices.Cursor-1]
+			state.Options.PrimaryIP = &hetzner.PrimaryIPOption{PrimaryIPID: ip.ID, IP: ip.IP}
+		}
+		return m, m.nextCreateStep(createStepPrimaryIP)
+
+	case createStepPlacementGroup:
+		var selected bool
+		state.StepChoices, selected = state.StepChoices.Update(msg.String())
+		if !selected {
+			return m, nil
+		}
+		// the first choice is no placement group
+		if state.StepChoices.Cursor == 0 || len(state.PlacementGroups) == 0 {
+			state.Options.PlacementGroup = nil
+		} else {
+			placementGroup := state.PlacementGroups[state.StepChoices.Cursor-1]
+			state.Options.PlacementGroup = &hetzner.PlacementGroupOption{PlacementGroupID: placementGroup.ID, Name: placementGroup.Name}
+		}
+		return m, m.nextCreateStep(createStepPlacementGroup)
+
+	case createStepLabels:
+		var submitted bool
+		state.LabelForm, cmd, submitted = state.LabelForm.Update(msg)
+		if !submitted {
+			return m, cmd
+		}
+		labels, err := hetzner.ParseLabels(state.LabelForm.Value(0))
+		if err != nil {
+			state.LabelForm.Err = err.Error()
+			return m, nil
+		}
+		state.Options.Labels = labels
+		return m, m.nextCreateStep(createStepLabels)
+
+	case createStepConfirm:
+		if msg.Type == tea.KeyEnter || msg.String() == "y" {
+			if m.createNeedsOverride() {
+				return m, nil
+			}
+			return m, m.nextCreateStep(createStepConfirm)
+		}
+		// O starts a create which exceeds the budget or could not be checked against it
+		if msg.String() == "O" && m.createNeedsOverride() && !state.EstimateLoading {
+			state.Budget.Override("server.create")
+			return m, m.nextCreateStep(createStepConfirm)
+		}
+	}
+
+	return m, nil
+}
+
+// setWizardVolumes offers all unattached volumes in the existing volume step
+func (m *Model) setWizardVolumes(msg hetzner.VolumesLoadedMsg) {
+	state := &m.CreateServerState
+	if state.Step != createStepExistingVolume {
+		return
+	}
+	if msg.Err != nil {
+		state.StepChoices = newChoiceList(fmt.Sprintf("Could not load volumes: %s", msg.Err))
+		return
+	}
+	state.Volumes = nil
+	var choices []string
+	for _, volume := range msg.Volumes {
+		if volume.Server != nil {
+			continue
+		}
+		state.Volumes = append(state.Volumes, volume)
+		choices = append(choices, fmt.Sprintf("%s (%d GB, %s)", volume.Name, volume.Size, volume.Location.Name))
+	}
+	state.StepChoices = newChoiceList("Choose an unattached volume", choices...)
+}
+
+// nextCreateStep moves the wizard to the step following the finished one
+func (m *Model) nextCreateStep(finished createStep) tea.Cmd {
+	state := &m.CreateServerState
+	switch finished {
+	case createStepName:
+		state.Step = createStepSshKeys
+		state.SshKeyChoices = newCheckList("Loading ssh keys...")
+		return hetzner.LoadSshKeys(m.Ctx, m.EnvValues.HetznerApiKey)
+	case createStepSshKeys:
+		state.Step = createStepVolume
+		// an existing volume can only be attached to one server
+		if m.isFleet() {
+			state.StepChoices = newChoiceList("Attach a volume to every server?", "No volume", "Create new volume")
+			return nil
+		}
+		state.StepChoices = newChoiceList("Attach a volume?", "No volume", "Create new volume", "Use existing volume")
+		return nil
+	case createStepVolume:
+		state.Step = createStepFirewall
+		state.StepChoices = newChoiceList("Attach a firewall?", "No firewall", "New firewall for ssh and the app port", "Use existing firewall")
+		return nil
+	case createStepFirewall:
+		state.Step = createStepNetwork
+		state.StepChoices = newChoiceList("Loading networks...")
+		return hetzner.LoadNetworks(m.Ctx, m.EnvValues.HetznerApiKey)
+	case createStepNetwork:
+		// a primary ip belongs to one server, a fleet always gets new ones
+		if m.isFleet() {
+			state.Options.PrimaryIP = nil
+			return m.nextCreateStep(createStepPrimaryIP)
+		}
+		state.Step = createStepPrimaryIP
+		state.StepChoices = newChoiceList("Loading primary ips...")
+		return hetzner.LoadIPs(m.Ctx, m.EnvValues.HetznerApiKey)
+	case createStepPrimaryIP:
+		state.Step = createStepPlacementGroup
+		state.StepChoices = newChoiceList("Loading placement groups...")
+		return hetzner.LoadPlacementGroups(m.Ctx, m.EnvValues.HetznerApiKey)
+	case createStepPlacementGroup:
+		state.Step = createStepLabels
+		state.LabelForm = newForm(fmt.Sprintf("Labels, %s=%s is added automatically", hetzner.LabelManagedBy, hetzner.LabelManagedByValue),
+			formField{Label: "Labels (key=value, comma separated)", Placeholder: "env=staging, project=api"},
+		)
+		return nil
+	case createStepLabels:
+		state.Step = createStepConfirm
+		state.Estimate = hetzner.CostEstimate{}
+		state.EstimateErr = nil
+		state.EstimateLoading = true
+		state.Budget = hetzner.Budget{}
+		return tea.Batch(m.Spinner.Tick, hetzner.LoadCreateEstimate(m.Ctx, m.EnvValues.HetznerApiKey, state.Options, state.Count, m.Profile.MonthlyBudget))
+	}
+	return m.startServerCreation()
+}
+
+// setWizardSshKeys offers all keys of the project, the keys of the options are checked
+func (m *Model) setWizardSshKeys(msg hetzner.SshKeysLoadedMsg) {
+	state := &m.CreateServerState
+	if state.Step != createStepSshKeys {
+		return
+	}
+	if msg.Err != nil {
+		state.SshKeyChoices = newCheckList(fmt.Sprintf("Could not load ssh keys: %s", msg.Err))
+		return
+	}
+	state.SshKeys = msg.Keys
+	choices := make([]string, len(msg.Keys))
+	for i, key := range msg.Keys {
+		choices[i] = fmt.Sprintf("%s (%s)", key.Name, key.Fingerprint)
+	}
+	state.SshKeyChoices = newCheckList("SSH keys added to the server", choices...)
+	for i, key := range msg.Keys {
+		for _, name := range state.Options.SshKeyNames {
+			if key.Name == name {
+				state.SshKeyChoices.Checked[i] = true
+			}
+		}
+	}
+}
+
+func (m *Model) setWizardFirewalls(msg hetzner.FirewallsLoadedMsg) {
+	state := &m.CreateServerState
+	if state.Step != createStepExistingFirewall {
+		return
+	}
+	if msg.Err != nil {
+		state.StepChoices = newChoiceList(fmt.Sprintf("Could not load firewalls: %s", msg.Err))
+		return
+	}
+	state.Firewalls = msg.Firewalls
+	choices := make([]string, len(msg.Firewalls))
+	for i, firewall := range msg.Firewalls {
+		choices[i] = fmt.Sprintf("%s (%d rules)", firewall.Name, len(firewall.Rules))
+	}
+	state.StepChoices = newChoiceList("Choose a firewall", choices...)
+}
+
+func (m *Model) setWizardNetworks(msg hetzner.NetworksLoadedMsg) {
+	state := &m.CreateServerState
+	if state.Step != createStepNetwork {
+		return
+	}
+	if msg.Err != nil {
+		state.StepChoices = newChoiceList(fmt.Sprintf("Could not load networks: %s", msg.Err), "No private network")
+		return
+	}
+	state.Networks = msg.Networks
+	choices := []string{"No private network"}
+	for _, network := range msg.Networks {
+		choices = append(choices, fmt.Sprintf("%s (%s)", network.Name, network.IPRange))
+	}
+	state.StepChoices = newChoiceList("Attach to a private network?", choices...)
+}
+
+// setWizardPrimaryIPs offers all unassigned primary ipv4s, the server is created in their datacenter
+func (m *Model) setWizardPrimaryIPs(msg hetzner.IPsLoadedMsg) {
+	state := &m.CreateServerState
+	if state.Step != createStepPrimaryIP {
+		return
+	}
+	if msg.Err != nil {
+		state.StepChoices = newChoiceList(fmt.Sprintf("Could not load ips: %s", msg.Err), "New public ip")
+		return
+	}
+	state.PrimaryIPs = nil
+	choices := []string{"New public ip"}
+	for _, ip := range msg.IPs {
+		if ip.Kind != hetzner.IPKindPrimary || ip.Type != "ipv4" || ip.ServerID != 0 {
+			continue
+		}
+		state.PrimaryIPs = append(state.PrimaryIPs, ip)
+		choices = append(choices, fmt.Sprintf("%s %s (%s)", ip.IP, ip.Name, ip.Location))
+	}
+	state.StepChoices = newChoiceList("Reuse an existing primary ip?", choices...)
+}
+
+// setWizardPlacementGroups offers all spread groups with space for another server
+func (m *Model) setWizardPlacementGroups(msg hetzner.PlacementGroupsLoadedMsg) {
+	state := &m.CreateServerState
+	if state.Step != createStepPlacementGroup {
+		return
+	}
+	if msg.Err != nil {
+		state.StepChoices = newChoiceList(fmt.Sprintf("Could not load placement groups: %s", msg.Err), "No placement group")
+		return
+	}
+	state.PlacementGroups = nil
+	choices := []string{"No placement group"}
+	for _, placementGroup := range msg.PlacementGroups {
+		if len(placementGroup.Servers) >= hetzner.MaxServersPerSpreadGroup {
+			continue
+		}
+		state.PlacementGroups = append(state.PlacementGroups, placementGroup)
+		choices = append(choices, fmt.Sprintf("%s (%d/%d servers)", placementGroup.Name, len(placementGroup.Servers), hetzner.MaxServersPerSpreadGroup))
+	}
+	state.StepChoices = newChoiceList("Spread the server with a placement group?", choices...)
+}
+
+func (m *Model) startServerCreation() tea.Cmd {
+	if m.isFleet() {
+		return m.startFleetCreation()
+	}
+	m.CreateServerState.Step = createStepNone
+	m.CreateServerState.CreatingServer = true
+	m.CreateServerState.Status = ""
+	log.Printf("Creating server %s", m.CreateServerState.Options.ServerName)
+	ctx, cancel := context.WithCancel(m.Ctx)
+	m.CreateServerState.Cancel = cancel
+	return tea.Batch(m.Spinner.Tick, hetzner.CreateServer(ctx, m.EnvValues.HetznerApiKey, m.CreateServerState.Options))
+}
+
+func volumeOptionFromForm(f form) (hetzner.VolumeOption, error) {
+	size, err := strconv.Atoi(f.Value(1))
+	if err != nil || size < 10 {
+		return hetzner.VolumeOption{}, fmt.Errorf("size must be a number of at least 10")
+	}
+	format := f.Value(2)
+	if format != hetzner.VolumeFormatExt4 && format != hetzner.VolumeFormatXfs {
+		return hetzner.VolumeOption{}, fmt.Errorf("filesystem must be ext4 or xfs")
+	}
+	if f.Value(0) == "" {
+		return hetzner.VolumeOption{}, fmt.Errorf("name is required")
+	}
+	return hetzner.VolumeOption{Name: f.Value(0), Size: size, Format: format}, nil
+}
+
+func (m Model) ViewCreateWizard() string {
+	state := m.CreateServerState
+	switch state.Step {
+	case createStepName:
+		return fmt.Sprintf("Enter Server name:\n\n%s\n\n%s", state.ServerNameInput.View(), "(use {n} like api-{n} to create several servers, esc to quit)")
+	case createStepCount:
+		return state.CountForm.View()
+	case createStepSshKeys:
+		return state.SshKeyChoices.View()
+	case createStepVolume, createStepExistingVolume, createStepFirewall, createStepExistingFirewall, createStepNetwork, createStepPrimaryIP, createStepPlacementGroup:
+		return state.StepChoices.View()
+	case createStepNetworkIP:
+		return state.NetworkForm.View()
+	case createStepLabels:
+		return state.LabelForm.View()
+	case createStepNewVolume:
+		return state.VolumeForm.View()
+	case createStepNewFirewall:
+		return state.FirewallForm.View()
+	case createStepConfirm:
+		return m.viewCreateConfirm()
+	}
+	return ""
+}
+
+// setWizardEstimate shows the price in the confirm step, the create works without it
+func (m *Model) setWizardEstimate(msg hetzner.CostEstimateMsg) {
+	state := &m.CreateServerState
+	if state.Step != createStepConfirm {
+		return
+	}
+	state.EstimateLoading = false
+	state.Estimate = msg.Estimate
+	state.EstimateErr = msg.Err
+	state.Budget = msg.Budget
+	if msg.Err == nil && msg.Budget.Enabled() {
+		m.Budget = msg.Budget
+		m.Budget.Added = 0
+		m.BudgetFetched = time.Now()
+	}
+}
+
+// createNeedsOverride is true while the budget of the profile blocks the create
+func (m Model) createNeedsOverride() bool {
+	state := m.CreateServerState
+	if m.Profile.MonthlyBudget <= 0 {
+		return false
+	}
+	return state.EstimateLoading || state.EstimateErr != nil || state.Budget.Exceeded()
+}
+
+func (m Model) viewCreateConfirm() string {
+	state := m.CreateServerState
+	options := state.Options
+	var builder strings.Builder
+	name := options.ServerName
+	if m.isFleet() {
+		name = fmt.Sprintf("%s (%d servers)", options.ServerName, state.Count)
+	}
+	builder.WriteString(fmt.Sprintf("Create %s?\n\n", name))
+	builder.WriteString(fmt.Sprintf("  Type:      %s\n", valueOr(options.ServerType, "smallest")))
+	builder.WriteString(fmt.Sprintf("  Image:     %s\n", valueOr(options.Image, "docker-ce")))
+	builder.WriteString(fmt.Sprintf("  Location:  %s\n", valueOr(options.Location, options.DeployCountry)))
+	builder.WriteString(fmt.Sprintf("  SSH keys:  %s\n", strings.Join(options.SshKeyNames, ", ")))
+	if options.Volume != nil {
+		builder.WriteString(fmt.Sprintf("  Volume:    %s\n", options.Volume.Name))
+	}
+	if options.Firewall != nil {
+		builder.WriteString(fmt.Sprintf("  Firewall:  %s\n", options.Firewall.Name))
+	}
+	if options.Network != nil {
+		builder.WriteString(fmt.Sprintf("  Network:   %s %s\n", options.Network.Name, options.Network.IP))
+	}
+	if options.PrimaryIP != nil {
+		builder.WriteString(fmt.Sprintf("  Public ip: %s\n", options.PrimaryIP.IP))
+	}
+	if options.PlacementGroup != nil {
+		builder.WriteString(fmt.Sprintf("  Placement: %s\n", options.PlacementGroup.Name))
+	}
+	if len(options.Labels) > 0 {
+		builder.WriteString(fmt.Sprintf("  Labels:    %s\n", hetzner.FormatLabels(options.Labels)))
+	}
+
+	builder.WriteString("\n")
+	switch {
+	case state.EstimateLoading:
+		builder.WriteString(fmt.Sprintf("  %s loading prices...\n", m.Spinner.View()))
+	case state.EstimateErr != nil:
+		builder.WriteString("  " + errorStyle.Render(fmt.Sprintf("no price available: %s", state.EstimateErr)) + "\n")
+	default:
+		builder.WriteString(viewEstimate(state.Estimate))
+	}
+	budget := state.Budget
+	if m.Profile.MonthlyBudget > 0 && !state.EstimateLoading {
+		builder.WriteString("\n")
+		switch {
+		case state.EstimateErr != nil:
+			builder.WriteString("  " + errorStyle.Render("the budget could not be checked") + "\n")
+		case budget.Exceeded():
+			builder.WriteString("  " + errorStyle.Render(budget.Err().Error()) + "\n")
+		default:
+			builder.WriteString(fmt.Sprintf("  monthly run-rate %s, %s afterwards, budget %s\n",
+				formatMoney(budget.RunRate, budget.Currency), formatMoney(budget.RunRate+budget.Added, budget.Currency), formatMoney(budget.Limit, budget.Currency)))
+		}
+	}
+	if m.createNeedsOverride() && !state.EstimateLoading {
+		builder.WriteString("\n O create anyway, the override is written to the audit log • esc cancel\n")
+	} else {
+		builder.WriteString("\n enter create • esc cancel\n")
+	}
+	return builder.String()
+}
+
+func valueOr(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,116 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/hetzner"
+	"github.com/crabstars/liftoff/internal"
+)
+
+type ServerDeleteProgressMsg struct {
+	ServerID int64
+	Progress int
+}
+
+// startDeleteConfirm remembers the selected server, a refresh moving the rows can not change the target anymore
+func (m *Model) startDeleteConfirm() {
+	server := m.selectedServer()
+	if server == nil {
+		return
+	}
+	if server.Protection.Delete {
+		m.TableState.Status = errorStyle.Render(fmt.Sprintf("%s has delete protection, press p to disable it", server.Name))
+		return
+	}
+	ti := textinput.New()
+	ti.Placeholder = server.Name
+	ti.CharLimit = 156
+	ti.Width = 30
+	ti.Focus()
+	m.TableState.DeleteTarget = server
+	m.TableState.DeleteInput = ti
+	m.TableState.DeleteError = ""
+	m.TableState.ShowOverlay = true
+}
+
+func (m Model) updateDeleteConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	state := &m.TableState
+
+	switch msg.Type {
+	case tea.KeyEsc:
+		state.ShowOverlay = false
+		state.DeleteTarget = nil
+		return m, nil
+	case tea.KeyEnter:
+		if state.DeleteInput.Value() != state.DeleteTarget.Name {
+			state.DeleteError = "name does not match"
+			return m, nil
+		}
+		target := state.DeleteTarget
+		state.ShowOverlay = false
+		state.DeleteTarget = nil
+		state.Deleting[target.ID] = 0
+		state.DeletingNames[target.ID] = target.Name
+		program := m.Program
+		progress := func(p int) {
+			program.Send(ServerDeleteProgressMsg{ServerID: target.ID, Progress: p})
+		}
+		ctx, cancel := context.WithCancel(m.Ctx)
+		state.DeleteCancel[target.ID] = cancel
+		return m, tea.Batch(m.Spinner.Tick, hetzner.DeleteServer(ctx, m.EnvValues.HetznerApiKey, target.ID, progress))
+	}
+
+	state.DeleteInput, cmd = state.DeleteInput.Update(msg)
+	return m, cmd
+}
+
+// removeServerRow drops the row of the server without waiting for the next refresh
+func (m *Model) removeServerRow(serverID int64) {
+	for index, server := range m.TableState.AllServers {
+		if server.ID != serverID {
+			continue
+		}
+		m.TableState.AllServers = internal.DeleteElementAt(m.TableState.AllServers, index)
+		m.refreshServerTable()
+		return
+	}
+}
+
+func (m Model) ViewDeleteConfirm() string {
+	state := m.TableState
+	s := fmt.Sprintf("Delete %s?\n\nType the server name to confirm:\n\n%s\n", state.DeleteTarget.Name, state.DeleteInput.View())
+	if state.DeleteError != "" {
+		s += "\n" + errorStyle.Render(state.DeleteError) + "\n"
+	}
+	return s + "\n(enter to delete, esc to cancel)"
+}
+
+func (m Model) ViewDeleting() string {
+	ids := make([]int64, 0, len(m.TableState.Deleting))
+	for id := range m.TableState.Deleting {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	s := ""
+	for _, id := range ids {
+		s += fmt.Sprintf(" %s deleting %s %d%%\n", m.Spinner.View(), m.TableState.DeletingNames[id], m.TableState.Deleting[id])
+	}
+	if len(ids) > 0 {
+		s += " x cancel deletes\n"
+	}
+	return s
+}
+
+// cancelDeletes stops waiting for the running deletes, they report the state the servers were left in
+func (m *Model) cancelDeletes() {
+	for id, cancel := range m.TableState.DeleteCancel {
+		cancel()
+		delete(m.TableState.DeleteCancel, id)
+	}
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/crabstars/liftoff/hetzner"
+	"github.com/crabstars/liftoff/inventory"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// ViewServerDetail shows everything liftoff knows about the selected server
+func (m Model) ViewServerDetail() string {
+	server := m.selectedServer()
+	if server == nil {
+		return "Server not found\n\n esc back\n"
+	}
+
+	var builder strings.Builder
+	row := func(name string, value string) {
+		if value == "" {
+			value = "-"
+		}
+		builder.WriteString(fmt.Sprintf(" %-16s %s\n", name, value))
+	}
+
+	builder.WriteString(fmt.Sprintf(" %s\n\n", server.Name))
+	row("ID", fmt.Sprintf("%d", server.ID))
+	row("Status", string(server.Status))
+	row("Created", server.Created.Format("2006-01-02 15:04"))
+	row("Server type", fmt.Sprintf("%s (%d cores, %.0f GB memory, %d GB disk)", server.ServerType.Name, server.ServerType.Cores, server.ServerType.Memory, server.ServerType.Disk))
+	row("Location", fmt.Sprintf("%s (%s)", server.Datacenter.Location.City, server.Datacenter.Name))
+	if server.Image != nil {
+		row("Image", server.Image.Name)
+	}
+	if !server.PublicNet.IPv4.IsUnspecified() {
+		row("Public IPv4", server.PublicNet.IPv4.IP.String())
+	}
+	if !server.PublicNet.IPv6.IsUnspecified() && server.PublicNet.IPv6.Network != nil {
+		row("Public IPv6", server.PublicNet.IPv6.Network.String())
+	}
+	privateIPs := make([]string, len(server.PrivateNet))
+	for i, privateNet := range server.PrivateNet {
+		privateIPs[i] = privateNet.IP.String()
+	}
+	row("Private IPs", strings.Join(privateIPs, ", "))
+	row("Labels", hetzner.FormatLabels(server.Labels))
+	row("Protected", protectionText(server))
+	row("Placement group", placementGroupText(server))
+	volumes := make([]string, len(server.Volumes))
+	for i, volume := range server.Volumes {
+		volumes[i] = fmt.Sprintf("%d", volume.ID)
+	}
+	row("Volume ids", strings.Join(volumes, ", "))
+	if record, ok := inventory.DB.Server(server.ID); ok {
+		row("Profile", record.Profile)
+		row("Recipe", record.Recipe)
+		row("Commit", record.Commit)
+		row("Deployed", formatDate(record.DeployedAt))
+	}
+	builder.WriteString("\n esc back\n")
+	return baseStyle.Render(builder.String())
+}
+
+func placementGroupText(server *hcloud.Server) string {
+	if server.PlacementGroup == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s (%s)", server.PlacementGroup.Name, server.PlacementGroup.Type)
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// startFilter opens the filter form, it shares the form of the label editor
+func (m *Model) startFilter() {
+	m.TableState.LabelMode = labelModeFilter
+	m.TableState.LabelForm = newForm("Only show servers matching, empty fields match all",
+		formField{Label: "Status", Value: m.TableState.StatusFilter, Placeholder: "running"},
+		formField{Label: "Location (name or city)", Value: m.TableState.LocationFilter, Placeholder: "nbg1"},
+		formField{Label: "Server type", Value: m.TableState.TypeFilter, Placeholder: "cx11"},
+		formField{Label: "Label selector", Value: m.TableState.LabelSelector, Placeholder: "env=staging"},
+	)
+}
+
+func (m *Model) startSearch() {
+	ti := textinput.New()
+	ti.Prompt = "/"
+	ti.Placeholder = "server name"
+	ti.CharLimit = 64
+	ti.SetValue(m.TableState.Search)
+	ti.Focus()
+	m.TableState.SearchInput = ti
+	m.TableState.Searching = true
+}
+
+// updateSearch filters the table with every key, enter keeps the search and esc clears it
+func (m Model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	state := &m.TableState
+	switch msg.Type {
+	case tea.KeyEnter:
+		state.Searching = false
+		return m, nil
+	case tea.KeyEsc:
+		state.Searching = false
+		state.Search = ""
+		m.refreshServerTable()
+		return m, nil
+	}
+	state.SearchInput, cmd = state.SearchInput.Update(msg)
+	if state.SearchInput.Value() != state.Search {
+		state.Search = state.SearchInput.Value()
+		state.RowCursor = 0
+		m.refreshServerTable()
+	}
+	return m, cmd
+}
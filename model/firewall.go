@@ -0,0 +1,300 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/hetzner"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+func (m *Model) showFirewalls() tea.Cmd {
+	m.FirewallState.ShowFirewalls = true
+	m.FirewallState.Mode = firewallModeList
+	m.FirewallState.Loading = true
+	m.loadFirewallTable(nil)
+	return tea.Batch(m.Spinner.Tick, hetzner.LoadFirewalls(m.Ctx, m.EnvValues.HetznerApiKey), hetzner.LoadServers(m.Ctx, m.EnvValues.HetznerApiKey))
+}
+
+func (m *Model) loadFirewallTable(firewalls []*hcloud.Firewall) {
+	columns := []table.Column{
+		{Title: "Name", Width: 25},
+		{Title: "Rules", Width: 8},
+		{Title: "Applied to", Width: 60},
+	}
+
+	rows := make([]table.Row, len(firewalls))
+	for i, firewall := range firewalls {
+		rows[i] = table.Row{firewall.Name, fmt.Sprintf("%d", len(firewall.Rules)), m.firewallAppliedTo(firewall)}
+	}
+
+	m.FirewallState.Firewalls = firewalls
+	m.FirewallState.FirewallTable = newStyledTable(columns, rows, m.FirewallState.FirewallTable.Cursor())
+}
+
+func (m Model) firewallAppliedTo(firewall *hcloud.Firewall) string {
+	var appliedTo []string
+	for _, resource := range firewall.AppliedTo {
+		switch resource.Type {
+		case hcloud.FirewallResourceTypeServer:
+			appliedTo = append(appliedTo, m.firewallServerName(resource.Server.ID))
+		case hcloud.FirewallResourceTypeLabelSelector:
+			appliedTo = append(appliedTo, "label "+resource.LabelSelector.Selector)
+		}
+	}
+	if len(appliedTo) == 0 {
+		return "-"
+	}
+	return strings.Join(appliedTo, ", ")
+}
+
+func (m Model) firewallServerName(serverID int64) string {
+	for _, server := range m.FirewallState.Servers {
+		if server.ID == serverID {
+			return server.Name
+		}
+	}
+	return fmt.Sprintf("%d", serverID)
+}
+
+func (m *Model) loadRuleTable() {
+	columns := []table.Column{
+		{Title: "Direction", Width: 10},
+		{Title: "Protocol", Width: 10},
+		{Title: "Port", Width: 12},
+		{Title: "CIDRs", Width: 50},
+	}
+
+	rows := make([]table.Row, len(m.FirewallState.Rules))
+	for i, rule := range m.FirewallState.Rules {
+		fields := strings.SplitN(hetzner.FormatFirewallRule(rule), " ", 4)
+		rows[i] = table.Row{fields[0], fields[1], fields[2], fields[3]}
+	}
+
+	m.FirewallState.RuleTable = newStyledTable(columns, rows, m.FirewallState.RuleTable.Cursor())
+}
+
+func (m Model) selectedFirewall() *hcloud.Firewall {
+	index := m.FirewallState.FirewallTable.Cursor()
+	if index < 0 || index >= len(m.FirewallState.Firewalls) {
+		return nil
+	}
+	return m.FirewallState.Firewalls[index]
+}
+
+// runFirewallAction starts a firewall call, the table gets reloaded after the FirewallActionMsg
+func (m *Model) runFirewallAction(cmd tea.Cmd) tea.Cmd {
+	m.FirewallState.Mode = firewallModeList
+	m.FirewallState.Loading = true
+	m.FirewallState.Status = ""
+	return tea.Batch(m.Spinner.Tick, cmd)
+}
+
+func (m Model) updateFirewallState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var submitted bool
+	state := &m.FirewallState
+	apiKey := m.EnvValues.HetznerApiKey
+	firewall := m.selectedFirewall()
+
+	switch state.Mode {
+	case firewallModeCreate:
+		if msg.Type == tea.KeyEsc {
+			state.Mode = firewallModeList
+			return m, nil
+		}
+		state.Form, cmd, submitted = state.Form.Update(msg)
+		if !submitted {
+			return m, cmd
+		}
+		if state.Form.Value(0) == "" {
+			state.Form.Err = "name is required"
+			return m, nil
+		}
+		if port := state.Form.Value(1); port != "" {
+			if _, err := hetzner.ParseFirewallRule("in", "tcp", port, "0.0.0.0/0"); err != nil {
+				state.Form.Err = err.Error()
+				return m, nil
+			}
+		}
+		// new firewalls start with ssh open, so nobody gets locked out
+		return m, m.runFirewallAction(hetzner.CreateFirewall(m.Ctx, apiKey, state.Form.Value(0), hetzner.SshAndAppRules(state.Form.Value(1))))
+
+	case firewallModeRules:
+		switch msg.String() {
+		case "esc":
+			state.Mode = firewallModeList
+			return m, nil
+		case "a":
+			state.Mode = firewallModeAddRule
+			state.Form = newForm("Add rule",
+				formField{Label: "Direction (in or out)", Value: "in"},
+				formField{Label: "Protocol (tcp, udp, icmp, esp or gre)", Value: "tcp"},
+				formField{Label: "Port or range, empty for icmp, esp and gre", Placeholder: "443 or 8000-8080"},
+				formField{Label: "Source/destination CIDRs", Value: "0.0.0.0/0, ::/0"},
+			)
+			return m, nil
+		case "d":
+			index := state.RuleTable.Cursor()
+			if index >= 0 && index < len(state.Rules) {
+				state.Rules = append(state.Rules[:index:index], state.Rules[index+1:]...)
+				m.loadRuleTable()
+			}
+			return m, nil
+		case "w":
+			if firewall != nil {
+				return m, m.runFirewallAction(hetzner.SetFirewallRules(m.Ctx, apiKey, firewall.ID, state.Rules))
+			}
+		}
+		state.RuleTable, cmd = state.RuleTable.Update(msg)
+		return m, cmd
+
+	case firewallModeAddRule:
+		if msg.Type == tea.KeyEsc {
+			state.Mode = firewallModeRules
+			return m, nil
+		}
+		state.Form, cmd, submitted = state.Form.Update(msg)
+		if !submitted {
+			return m, cmd
+		}
+		rule, err := hetzner.ParseFirewallRule(state.Form.Value(0), state.Form.Value(1), state.Form.Value(2), state.Form.Value(3))
+		if err != nil {
+			state.Form.Err = err.Error()
+			return m, nil
+		}
+		state.Rules = append(state.Rules, rule)
+		state.Mode = firewallModeRules
+		m.loadRuleTable()
+		return m, nil
+
+	case firewallModeApplyServer:
+		if msg.Type == tea.KeyEsc {
+			state.Mode = firewallModeList
+			return m, nil
+		}
+		var selected bool
+		state.ServerChoice, selected = state.ServerChoice.Update(msg.String())
+		if !selected || firewall == nil || len(state.Servers) == 0 {
+			return m, nil
+		}
+		server := state.Servers[state.ServerChoice.Cursor]
+		return m, m.runFirewallAction(hetzner.ApplyFirewallToServer(m.Ctx, apiKey, firewall.ID, server.ID))
+
+	case firewallModeApplyLabel:
+		if msg.Type == tea.KeyEsc {
+			state.Mode = firewallModeList
+			return m, nil
+		}
+		state.Form, cmd, submitted = state.Form.Update(msg)
+		if !submitted {
+			return m, cmd
+		}
+		if state.Form.Value(0) == "" || firewall == nil {
+			state.Form.Err = "label selector is required"
+			return m, nil
+		}
+		return m, m.runFirewallAction(hetzner.ApplyFirewallToLabelSelector(m.Ctx, apiKey, firewall.ID, state.Form.Value(0)))
+
+	case firewallModeDelete:
+		switch msg.String() {
+		case "y":
+			if firewall != nil {
+				return m, m.runFirewallAction(hetzner.DeleteFirewall(m.Ctx, apiKey, firewall.ID))
+			}
+			state.Mode = firewallModeList
+		case "n", "esc":
+			state.Mode = firewallModeList
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		state.ShowFirewalls = false
+		return m, nil
+	case "c":
+		state.Mode = firewallModeCreate
+		state.Form = newForm("Create firewall, ssh is always opened",
+			formField{Label: "Name"},
+			formField{Label: "Additional tcp port to open (optional)", Placeholder: "5021"},
+		)
+		return m, nil
+	}
+	if firewall == nil {
+		state.FirewallTable, cmd = state.FirewallTable.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "e":
+		state.Mode = firewallModeRules
+		state.Rules = append([]hcloud.FirewallRule{}, firewall.Rules...)
+		m.loadRuleTable()
+		return m, nil
+	case "s":
+		state.Mode = firewallModeApplyServer
+		choices := make([]string, len(state.Servers))
+		for i, server := range state.Servers {
+			choices[i] = server.Name
+		}
+		state.ServerChoice = newChoiceList(fmt.Sprintf("Apply %s to server", firewall.Name), choices...)
+		return m, nil
+	case "l":
+		state.Mode = firewallModeApplyLabel
+		state.Form = newForm(fmt.Sprintf("Apply %s to all servers matching", firewall.Name),
+			formField{Label: "Label selector", Placeholder: "env=staging"},
+		)
+		return m, nil
+	case "u":
+		return m, m.runFirewallAction(hetzner.RemoveFirewallResources(m.Ctx, apiKey, firewall.ID))
+	case "d":
+		state.Mode = firewallModeDelete
+		return m, nil
+	}
+
+	state.FirewallTable, cmd = state.FirewallTable.Update(msg)
+	return m, cmd
+}
+
+func (m *Model) setFirewallServers(msg hetzner.ServersLoadedMsg) {
+	if msg.Err != nil {
+		m.FirewallState.Status = fmt.Sprintf("could not load servers: %s", msg.Err)
+		return
+	}
+	m.FirewallState.Servers = msg.Servers
+	m.loadFirewallTable(m.FirewallState.Firewalls)
+}
+
+func (m Model) ViewFirewalls() string {
+	state := m.FirewallState
+	switch state.Mode {
+	case firewallModeCreate, firewallModeAddRule, firewallModeApplyLabel:
+		return state.Form.View()
+	case firewallModeApplyServer:
+		return state.ServerChoice.View()
+	case firewallModeRules:
+		title := ""
+		if firewall := m.selectedFirewall(); firewall != nil {
+			title = fmt.Sprintf("Rules of %s (unsaved until w)\n", firewall.Name)
+		}
+		return title + baseStyle.Render(state.RuleTable.View()) + "\n a add • d remove • w save • esc discard\n"
+	}
+
+	s := baseStyle.Render(state.FirewallTable.View()) + "\n"
+	s += " c create • e edit rules • s apply to server • l apply to label selector • u remove from all • d delete • esc back\n"
+	if state.Loading {
+		s += fmt.Sprintf("\n %s working...\n", m.Spinner.View())
+	}
+	if state.Status != "" {
+		s += "\n " + state.Status + "\n"
+	}
+	if state.Mode == firewallModeDelete {
+		if firewall := m.selectedFirewall(); firewall != nil {
+			s = PlaceOverlay(80, 5, fmt.Sprintf("Delete firewall %s?\n\nPress 'y' to confirm, 'n' to cancel.", firewall.Name), s)
+		}
+	}
+	return s
+}
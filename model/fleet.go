@@ -0,0 +1,102 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/hetzner"
+)
+
+type FleetProgressMsg struct {
+	Index  int
+	Status string
+	Err    error
+}
+
+// isFleet is true when the wizard creates more than one server from a name pattern
+func (m Model) isFleet() bool {
+	return m.CreateServerState.Count > 1
+}
+
+func (m *Model) startFleetCreation() tea.Cmd {
+	state := &m.CreateServerState
+	names, err := hetzner.FleetNames(state.Options.ServerName, state.Count)
+	if err != nil {
+		// the labels step is the last one before the creation
+		state.LabelForm.Err = err.Error()
+		return nil
+	}
+	state.Step = createStepNone
+	state.CreatingServer = true
+	state.FleetResult = ""
+	state.Fleet = make([]taskProgress, len(names))
+	for i, name := range names {
+		state.Fleet[i] = taskProgress{Name: name, Status: hetzner.TaskWaiting}
+	}
+	log.Printf("Creating fleet of %d servers", len(names))
+	ctx, cancel := context.WithCancel(m.Ctx)
+	state.Cancel = cancel
+	program := m.Program
+	progress := func(index int, status string, err error) {
+		program.Send(FleetProgressMsg{Index: index, Status: status, Err: err})
+	}
+	return tea.Batch(m.Spinner.Tick, hetzner.CreateFleet(ctx, m.EnvValues.HetznerApiKey, state.Options, names, progress))
+}
+
+func (m *Model) setFleetProgress(msg FleetProgressMsg) {
+	if msg.Index < 0 || msg.Index >= len(m.CreateServerState.Fleet) {
+		return
+	}
+	m.CreateServerState.Fleet[msg.Index].Status = msg.Status
+	m.CreateServerState.Fleet[msg.Index].Err = msg.Err
+}
+
+func (m *Model) finishFleet(msg hetzner.FleetCreatedMsg) {
+	state := &m.CreateServerState
+	state.CreatingServer = false
+	state.Cancel = nil
+	state.ServerNameInput.Reset()
+	m.BudgetFetched = time.Time{}
+	switch {
+	case msg.Err != nil:
+		state.FleetResult = errorStyle.Render(fmt.Sprintf("no server was created: %s", msg.Err))
+	case msg.Failed > 0:
+		state.FleetResult = errorStyle.Render(fmt.Sprintf("%d servers created, %d failed", msg.Created, msg.Failed))
+	default:
+		state.FleetResult = fmt.Sprintf("all %d servers created", msg.Created)
+	}
+}
+
+func (m Model) ViewFleet() string {
+	state := m.CreateServerState
+	s := fmt.Sprintf(" Creating %d servers, %d at a time\n\n", len(state.Fleet), hetzner.MaxParallelTasks)
+	s += m.viewTasks(state.Fleet)
+	if state.CreatingServer && state.Cancel == nil {
+		s += "\n cancelling, running servers report the state they were left in\n"
+	} else if state.CreatingServer {
+		s += "\n x cancel\n"
+	}
+	if state.FleetResult != "" {
+		s += "\n " + state.FleetResult + "\n\n press enter to continue\n"
+	}
+	return s
+}
+
+// viewTasks lists name and status of every task, running ones get the spinner
+func (m Model) viewTasks(tasks []taskProgress) string {
+	var s string
+	for _, task := range tasks {
+		status := task.Status
+		switch task.Status {
+		case hetzner.TaskRunning:
+			status = m.Spinner.View() + " " + status
+		case hetzner.TaskFailed, hetzner.TaskCancelled:
+			status = errorStyle.Render(fmt.Sprintf("%s: %s", status, task.Err))
+		}
+		s += fmt.Sprintf(" %-30s %s\n", task.Name, status)
+	}
+	return s
+}
@@ -0,0 +1,206 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// formField describes one labeled text input of a form
+type formField struct {
+	Label       string
+	Placeholder string
+	Value       string
+}
+
+// form is a list of text inputs, tab/up/down moves the focus and enter on the last input submits
+type form struct {
+	Title  string
+	Labels []string
+	Inputs []textinput.Model
+	Focus  int
+	Err    string
+}
+
+func newForm(title string, fields ...formField) form {
+	f := form{Title: title}
+	for i, field := range fields {
+		ti := textinput.New()
+		ti.Placeholder = field.Placeholder
+		ti.CharLimit = 156
+		ti.Width = 40
+		ti.SetValue(field.Value)
+		if i == 0 {
+			ti.Focus()
+		}
+		f.Labels = append(f.Labels, field.Label)
+		f.Inputs = append(f.Inputs, ti)
+	}
+	return f
+}
+
+// Update returns true as last value when the form got submitted
+func (f form) Update(msg tea.KeyMsg) (form, tea.Cmd, bool) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		if f.Focus == len(f.Inputs)-1 {
+			return f, nil, true
+		}
+		f.setFocus(f.Focus + 1)
+		return f, nil, false
+	case tea.KeyTab, tea.KeyDown:
+		f.setFocus((f.Focus + 1) % len(f.Inputs))
+		return f, nil, false
+	case tea.KeyShiftTab, tea.KeyUp:
+		f.setFocus((f.Focus - 1 + len(f.Inputs)) % len(f.Inputs))
+		return f, nil, false
+	}
+
+	var cmd tea.Cmd
+	f.Inputs[f.Focus], cmd = f.Inputs[f.Focus].Update(msg)
+	return f, cmd, false
+}
+
+func (f *form) setFocus(index int) {
+	f.Inputs[f.Focus].Blur()
+	f.Focus = index
+	f.Inputs[f.Focus].Focus()
+}
+
+func (f form) Value(index int) string {
+	return strings.TrimSpace(f.Inputs[index].Value())
+}
+
+func (f form) View() string {
+	var builder strings.Builder
+	builder.WriteString(f.Title + "\n\n")
+	for i, input := range f.Inputs {
+		builder.WriteString(fmt.Sprintf("%s\n%s\n\n", f.Labels[i], input.View()))
+	}
+	if f.Err != "" {
+		builder.WriteString(errorStyle.Render(f.Err) + "\n\n")
+	}
+	builder.WriteString("(tab to switch field, enter to submit, esc to cancel)\n")
+	return builder.String()
+}
+
+// choiceList is a vertical selection like the action selection of the main menu
+type choiceList struct {
+	Title   string
+	Choices []string
+	Cursor  int
+}
+
+func newChoiceList(title string, choices ...string) choiceList {
+	return choiceList{Title: title, Choices: choices}
+}
+
+// Update returns true as last value when a choice got selected
+func (c choiceList) Update(keyStroke string) (choiceList, bool) {
+	switch keyStroke {
+	case "up", "k":
+		if c.Cursor > 0 {
+			c.Cursor--
+		}
+	case "down", "j":
+		if c.Cursor < len(c.Choices)-1 {
+			c.Cursor++
+		}
+	case "enter", " ":
+		return c, len(c.Choices) > 0
+	}
+	return c, false
+}
+
+func (c choiceList) View() string {
+	var builder strings.Builder
+	builder.WriteString(c.Title + "\n\n")
+	if len(c.Choices) == 0 {
+		builder.WriteString("  nothing to choose from\n")
+	}
+	for i, choice := range c.Choices {
+		cursor := " "
+		checked := " "
+		if c.Cursor == i {
+			cursor = ">"
+			checked = "x"
+		}
+		builder.WriteString(fmt.Sprintf("%s [%s], %s\n", cursor, checked, choice))
+	}
+	builder.WriteString("\n(enter to select, esc to cancel)\n")
+	return builder.String()
+}
+
+// checkList is a vertical multi selection, space toggles the choice under the cursor and enter submits
+type checkList struct {
+	Title   string
+	Choices []string
+	Checked []bool
+	Cursor  int
+	Err     string
+}
+
+func newCheckList(title string, choices ...string) checkList {
+	return checkList{Title: title, Choices: choices, Checked: make([]bool, len(choices))}
+}
+
+// Update returns true as last value when the selection got submitted
+func (c checkList) Update(keyStroke string) (checkList, bool) {
+	switch keyStroke {
+	case "up", "k":
+		if c.Cursor > 0 {
+			c.Cursor--
+		}
+	case "down", "j":
+		if c.Cursor < len(c.Choices)-1 {
+			c.Cursor++
+		}
+	case " ":
+		if len(c.Choices) > 0 {
+			checked := make([]bool, len(c.Checked))
+			copy(checked, c.Checked)
+			checked[c.Cursor] = !checked[c.Cursor]
+			c.Checked = checked
+		}
+	case "enter":
+		return c, len(c.Choices) > 0
+	}
+	return c, false
+}
+
+// Selected returns the indexes of the checked choices
+func (c checkList) Selected() []int {
+	var indexes []int
+	for i, checked := range c.Checked {
+		if checked {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+func (c checkList) View() string {
+	var builder strings.Builder
+	builder.WriteString(c.Title + "\n\n")
+	if len(c.Choices) == 0 {
+		builder.WriteString("  nothing to choose from\n")
+	}
+	for i, choice := range c.Choices {
+		cursor := " "
+		checked := " "
+		if c.Cursor == i {
+			cursor = ">"
+		}
+		if c.Checked[i] {
+			checked = "x"
+		}
+		builder.WriteString(fmt.Sprintf("%s [%s], %s\n", cursor, checked, choice))
+	}
+	if c.Err != "" {
+		builder.WriteString("\n" + errorStyle.Render(c.Err) + "\n")
+	}
+	builder.WriteString("\n(space to toggle, enter to continue, esc to cancel)\n")
+	return builder.String()
+}
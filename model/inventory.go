@@ -0,0 +1,142 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/inventory"
+)
+
+const dateFormat = "2006-01-02 15:04"
+
+func (m *Model) showInventory() {
+	m.InventoryState.ShowInventory = true
+	m.InventoryState.ShowHistory = false
+	m.loadInventoryTable()
+}
+
+// loadInventoryTable reads the local database, it is fast enough to not need a command
+func (m *Model) loadInventoryTable() {
+	state := &m.InventoryState
+	servers, err := inventory.DB.Servers(state.ShowDeleted)
+	state.Status = ""
+	if err != nil {
+		state.Status = errorStyle.Render(fmt.Sprintf("could not read the inventory: %s", err))
+	}
+
+	columns := []table.Column{
+		{Title: "Name", Width: 20},
+		{Title: "ID", Width: 10},
+		{Title: "Profile", Width: 10},
+		{Title: "Type", Width: 7},
+		{Title: "Location", Width: 8},
+		{Title: "Recipe", Width: 22},
+		{Title: "Commit", Width: 8},
+		{Title: "Deployed", Width: 16},
+		{Title: "Created", Width: 16},
+		{Title: "Deleted", Width: 16},
+	}
+	rows := make([]table.Row, len(servers))
+	for i, server := range servers {
+		rows[i] = table.Row{
+			server.Name, fmt.Sprintf("%d", server.ID), server.Profile, server.ServerType, server.Location,
+			server.Recipe, shortCommit(server.Commit), formatDate(server.DeployedAt), formatDate(server.CreatedAt), formatDate(server.DeletedAt),
+		}
+	}
+	state.Servers = servers
+	state.Table = newStyledTable(columns, rows, state.Table.Cursor())
+}
+
+func (m Model) selectedInventoryServer() *inventory.Server {
+	index := m.InventoryState.Table.Cursor()
+	if index < 0 || index >= len(m.InventoryState.Servers) {
+		return nil
+	}
+	return &m.InventoryState.Servers[index]
+}
+
+func (m Model) updateInventoryState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	state := &m.InventoryState
+	if state.ShowHistory {
+		if msg.Type == tea.KeyEsc || msg.Type == tea.KeyEnter {
+			state.ShowHistory = false
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		state.ShowInventory = false
+		return m, nil
+	case "h":
+		state.ShowDeleted = !state.ShowDeleted
+		m.loadInventoryTable()
+		return m, nil
+	case "enter":
+		if m.selectedInventoryServer() != nil {
+			state.ShowHistory = true
+		}
+		return m, nil
+	}
+	state.Table, cmd = state.Table.Update(msg)
+	return m, cmd
+}
+
+func (m Model) ViewInventory() string {
+	state := m.InventoryState
+	if state.ShowHistory {
+		return m.viewDeploymentHistory()
+	}
+	s := baseStyle.Render(state.Table.View()) + "\n"
+	if state.ShowDeleted {
+		s += " enter deployments • h hide deleted • esc back\n"
+	} else {
+		s += " enter deployments • h show deleted • esc back\n"
+	}
+	if state.Status != "" {
+		s += "\n " + state.Status + "\n"
+	}
+	return s
+}
+
+func (m Model) viewDeploymentHistory() string {
+	server := m.selectedInventoryServer()
+	if server == nil {
+		return "Server not found\n\n esc back\n"
+	}
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf(" Deployments of %s\n\n", server.Name))
+	deployments, err := inventory.DB.Deployments(server.ID)
+	if err != nil {
+		builder.WriteString(" " + errorStyle.Render(err.Error()) + "\n")
+	}
+	if err == nil && len(deployments) == 0 {
+		builder.WriteString(" nothing deployed yet\n")
+	}
+	for _, deployment := range deployments {
+		builder.WriteString(fmt.Sprintf(" %s  %-24s %s %s\n", formatDate(deployment.DeployedAt), deployment.Recipe, shortCommit(deployment.Commit), deployment.Repo))
+	}
+	if server.CloudConfig != "" {
+		builder.WriteString("\n cloud-config\n\n" + server.CloudConfig + "\n")
+	}
+	builder.WriteString("\n esc back\n")
+	return builder.String()
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format(dateFormat)
+}
@@ -0,0 +1,227 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/hetzner"
+)
+
+func (m *Model) showIPs() tea.Cmd {
+	m.IPState.ShowIPs = true
+	m.IPState.Mode = ipModeList
+	m.IPState.Loading = true
+	m.loadIPTable(nil)
+	return tea.Batch(m.Spinner.Tick, hetzner.LoadIPs(m.Ctx, m.EnvValues.HetznerApiKey), hetzner.LoadServers(m.Ctx, m.EnvValues.HetznerApiKey))
+}
+
+func (m *Model) loadIPTable(ips []hetzner.IP) {
+	columns := []table.Column{
+		{Title: "Kind", Width: 9},
+		{Title: "Name", Width: 20},
+		{Title: "IP", Width: 26},
+		{Title: "Type", Width: 5},
+		{Title: "Location", Width: 9},
+		{Title: "Server", Width: 20},
+		{Title: "Reverse DNS", Width: 30},
+	}
+
+	rows := make([]table.Row, len(ips))
+	for i, ip := range ips {
+		rows[i] = table.Row{ip.Kind, ip.Name, ip.IP, ip.Type, ip.Location, m.ipServerName(ip), ip.DNSPtr}
+	}
+
+	m.IPState.IPs = ips
+	m.IPState.IPTable = newStyledTable(columns, rows, m.IPState.IPTable.Cursor())
+}
+
+func (m Model) ipServerName(ip hetzner.IP) string {
+	if ip.ServerID == 0 {
+		return "-"
+	}
+	for _, server := range m.IPState.Servers {
+		if server.ID == ip.ServerID {
+			return server.Name
+		}
+	}
+	return fmt.Sprintf("%d", ip.ServerID)
+}
+
+func (m Model) selectedIP() *hetzner.IP {
+	index := m.IPState.IPTable.Cursor()
+	if index < 0 || index >= len(m.IPState.IPs) {
+		return nil
+	}
+	return &m.IPState.IPs[index]
+}
+
+// runIPAction starts an ip call, the table gets reloaded after the IPActionMsg
+func (m *Model) runIPAction(cmd tea.Cmd) tea.Cmd {
+	m.IPState.Mode = ipModeList
+	m.IPState.Loading = true
+	m.IPState.Status = ""
+	return tea.Batch(m.Spinner.Tick, cmd)
+}
+
+func (m Model) updateIPState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var submitted bool
+	state := &m.IPState
+	apiKey := m.EnvValues.HetznerApiKey
+	ip := m.selectedIP()
+
+	if state.Mode != ipModeList && msg.Type == tea.KeyEsc {
+		state.Mode = ipModeList
+		return m, nil
+	}
+
+	switch state.Mode {
+	case ipModeAllocate:
+		state.Form, cmd, submitted = state.Form.Update(msg)
+		if !submitted {
+			return m, cmd
+		}
+		kind, name, ipType, country := state.Form.Value(0), state.Form.Value(1), state.Form.Value(2), state.Form.Value(3)
+		switch {
+		case kind != hetzner.IPKindPrimary && kind != hetzner.IPKindFloating:
+			state.Form.Err = "kind must be primary or floating"
+		case name == "":
+			state.Form.Err = "name is required"
+		case country != hetzner.CountryGermany && country != hetzner.CountryUSA:
+			state.Form.Err = "location must be germany or us"
+		default:
+			state.OverBudget = budgetOverride{
+				Operation:   "ip.allocate",
+				Description: fmt.Sprintf("Allocating the %s %s ip %s", kind, ipType, name),
+				Unchecked:   hetzner.AllocateIP(m.Ctx, apiKey, kind, name, ipType, country, 0),
+			}
+			return m, m.runIPAction(hetzner.AllocateIP(m.Ctx, apiKey, kind, name, ipType, country, m.Profile.MonthlyBudget))
+		}
+		return m, nil
+
+	case ipModeAssign:
+		var selected bool
+		state.ServerChoice, selected = state.ServerChoice.Update(msg.String())
+		if !selected || ip == nil {
+			return m, nil
+		}
+		server := state.Servers[state.ServerChoice.Cursor]
+		return m, m.runIPAction(hetzner.AssignIP(m.Ctx, apiKey, *ip, server.ID))
+
+	case ipModeReverseDNS:
+		state.Form, cmd, submitted = state.Form.Update(msg)
+		if !submitted || ip == nil {
+			return m, cmd
+		}
+		return m, m.runIPAction(hetzner.SetReverseDNS(m.Ctx, apiKey, *ip, state.Form.Value(0), state.Form.Value(1)))
+
+	case ipModeDelete:
+		switch msg.String() {
+		case "y":
+			if ip != nil {
+				return m, m.runIPAction(hetzner.DeleteIP(m.Ctx, apiKey, *ip))
+			}
+			state.Mode = ipModeList
+		case "n":
+			state.Mode = ipModeList
+		}
+		return m, nil
+
+	case ipModeOverBudget:
+		switch msg.String() {
+		case "o":
+			return m, m.runIPAction(state.OverBudget.override())
+		case "n":
+			state.Mode = ipModeList
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		state.ShowIPs = false
+		return m, nil
+	case "c":
+		state.Mode = ipModeAllocate
+		state.Form = newForm("Allocate ip",
+			formField{Label: "Kind (primary or floating)", Value: hetzner.IPKindPrimary},
+			formField{Label: "Name"},
+			formField{Label: "Type (ipv4 or ipv6)", Value: "ipv4"},
+			formField{Label: "Location (germany or us)", Value: hetzner.CountryGermany},
+		)
+		return m, nil
+	}
+	if ip == nil {
+		state.IPTable, cmd = state.IPTable.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "a":
+		state.Mode = ipModeAssign
+		choices := make([]string, len(state.Servers))
+		for i, server := range state.Servers {
+			choices[i] = server.Name
+		}
+		title := fmt.Sprintf("Assign %s to server", ip.IP)
+		if ip.Kind == hetzner.IPKindPrimary {
+			title += " (has to be powered off and without public ip of this type)"
+		}
+		state.ServerChoice = newChoiceList(title, choices...)
+		return m, nil
+	case "u":
+		return m, m.runIPAction(hetzner.UnassignIP(m.Ctx, apiKey, *ip))
+	case "r":
+		state.Mode = ipModeReverseDNS
+		state.Form = newForm(fmt.Sprintf("Reverse DNS of %s", ip.IP),
+			formField{Label: "IP (for ipv6 an address of the network)", Value: ip.IP},
+			formField{Label: "PTR record (empty resets to default)", Value: ip.DNSPtr, Placeholder: "api.example.com"},
+		)
+		return m, nil
+	case "d":
+		state.Mode = ipModeDelete
+		return m, nil
+	}
+
+	state.IPTable, cmd = state.IPTable.Update(msg)
+	return m, cmd
+}
+
+func (m *Model) setIPServers(msg hetzner.ServersLoadedMsg) {
+	if msg.Err != nil {
+		m.IPState.Status = fmt.Sprintf("could not load servers: %s", msg.Err)
+		return
+	}
+	m.IPState.Servers = msg.Servers
+	m.loadIPTable(m.IPState.IPs)
+}
+
+func (m Model) ViewIPs() string {
+	state := m.IPState
+	switch state.Mode {
+	case ipModeAllocate, ipModeReverseDNS:
+		return state.Form.View()
+	case ipModeAssign:
+		return state.ServerChoice.View()
+	}
+
+	s := baseStyle.Render(state.IPTable.View()) + "\n"
+	s += " c allocate • a assign • u unassign • r reverse dns • d delete • esc back\n"
+	s += " floating ips need to be configured on the server, see hetzner docs\n"
+	if state.Loading {
+		s += fmt.Sprintf("\n %s working...\n", m.Spinner.View())
+	}
+	if state.Status != "" {
+		s += "\n " + state.Status + "\n"
+	}
+	if state.Mode == ipModeDelete {
+		if ip := m.selectedIP(); ip != nil {
+			s = PlaceOverlay(80, 5, fmt.Sprintf("Delete %s ip %s?\n\nPress 'y' to confirm, 'n' to cancel.", ip.Kind, ip.IP), s)
+		}
+	}
+	if state.Mode == ipModeOverBudget {
+		s = state.OverBudget.view(s)
+	}
+	return s
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/hetzner"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+func (m Model) selectedServer() *hcloud.Server {
+	index := m.TableState.ServerTable.Cursor()
+	if index < 0 || index >= len(m.TableState.Servers) {
+		return nil
+	}
+	return m.TableState.Servers[index]
+}
+
+func (m *Model) startLabelEdit() {
+	server := m.selectedServer()
+	if server == nil {
+		return
+	}
+	m.TableState.LabelMode = labelModeEdit
+	m.TableState.LabelForm = newForm(fmt.Sprintf("Labels of %s", server.Name),
+		formField{Label: "Labels (key=value, comma separated)", Value: hetzner.FormatLabels(server.Labels)},
+	)
+}
+
+func (m Model) updateLabelState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var submitted bool
+	state := &m.TableState
+
+	if msg.Type == tea.KeyEsc {
+		state.LabelMode = labelModeNone
+		return m, nil
+	}
+	state.LabelForm, cmd, submitted = state.LabelForm.Update(msg)
+	if !submitted {
+		return m, cmd
+	}
+
+	switch state.LabelMode {
+	case labelModeEdit:
+		server := m.selectedServer()
+		labels, err := hetzner.ParseLabels(state.LabelForm.Value(0))
+		if err != nil {
+			state.LabelForm.Err = err.Error()
+			return m, nil
+		}
+		state.LabelMode = labelModeNone
+		if server == nil {
+			return m, nil
+		}
+		state.InFlight++
+		return m, hetzner.UpdateServerLabels(m.Ctx, m.EnvValues.HetznerApiKey, server.ID, labels)
+	case labelModeFilter:
+		state.StatusFilter = state.LabelForm.Value(0)
+		state.LocationFilter = state.LabelForm.Value(1)
+		state.TypeFilter = state.LabelForm.Value(2)
+		state.LabelMode = labelModeNone
+		m.refreshServerTable()
+		// only a changed label selector needs the api
+		if state.LabelForm.Value(3) != state.LabelSelector {
+			state.LabelSelector = state.LabelForm.Value(3)
+			m.requestRefresh()
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,315 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/hetzner"
+	sshconnector "github.com/crabstars/liftoff/ssh"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+func (m *Model) showLoadBalancers() tea.Cmd {
+	m.LoadBalancerState.ShowLoadBalancers = true
+	m.LoadBalancerState.Mode = loadBalancerModeList
+	m.LoadBalancerState.Loading = true
+	m.loadLoadBalancerTable(nil)
+	return tea.Batch(m.Spinner.Tick, hetzner.LoadLoadBalancers(m.Ctx, m.EnvValues.HetznerApiKey), hetzner.LoadServers(m.Ctx, m.EnvValues.HetznerApiKey))
+}
+
+func (m *Model) loadLoadBalancerTable(loadBalancers []*hcloud.LoadBalancer) {
+	columns := []table.Column{
+		{Title: "Name", Width: 20},
+		{Title: "Type", Width: 6},
+		{Title: "Location", Width: 9},
+		{Title: "Public IP", Width: 16},
+		{Title: "Services", Width: 35},
+		{Title: "Healthy", Width: 8},
+	}
+
+	rows := make([]table.Row, len(loadBalancers))
+	for i, loadBalancer := range loadBalancers {
+		services := make([]string, len(loadBalancer.Services))
+		for j, service := range loadBalancer.Services {
+			services[j] = fmt.Sprintf("%s %d->%d", service.Protocol, service.ListenPort, service.DestinationPort)
+		}
+		healthy, total := hetzner.HealthyTargets(loadBalancer)
+		rows[i] = table.Row{loadBalancer.Name, loadBalancer.LoadBalancerType.Name, loadBalancer.Location.Name, loadBalancer.PublicNet.IPv4.IP.String(), strings.Join(services, ", "), fmt.Sprintf("%d/%d", healthy, total)}
+	}
+
+	m.LoadBalancerState.LoadBalancers = loadBalancers
+	m.LoadBalancerState.LoadBalancerTable = newStyledTable(columns, rows, m.LoadBalancerState.LoadBalancerTable.Cursor())
+	m.loadTargetTable()
+}
+
+// loadTargetTable lists the targets of the selected load balancer, servers of label selectors are indented below them
+func (m *Model) loadTargetTable() {
+	columns := []table.Column{
+		{Title: "Target", Width: 35},
+		{Title: "Type", Width: 14},
+		{Title: "Health", Width: 40},
+	}
+
+	var rows []table.Row
+	if loadBalancer := m.selectedLoadBalancer(); loadBalancer != nil {
+		serverNames := make(map[int64]string, len(m.LoadBalancerState.Servers))
+		for _, server := range m.LoadBalancerState.Servers {
+			serverNames[server.ID] = server.Name
+		}
+		for _, target := range loadBalancer.Targets {
+			rows = append(rows, table.Row{hetzner.TargetName(target, serverNames), string(target.Type), hetzner.TargetHealth(target)})
+			for _, child := range target.Targets {
+				rows = append(rows, table.Row{"  └ " + hetzner.TargetName(child, serverNames), string(child.Type), hetzner.TargetHealth(child)})
+			}
+		}
+	}
+	m.LoadBalancerState.TargetTable = newStyledTable(columns, rows, m.LoadBalancerState.TargetTable.Cursor())
+}
+
+func (m Model) selectedLoadBalancer() *hcloud.LoadBalancer {
+	index := m.LoadBalancerState.LoadBalancerTable.Cursor()
+	if index < 0 || index >= len(m.LoadBalancerState.LoadBalancers) {
+		return nil
+	}
+	return m.LoadBalancerState.LoadBalancers[index]
+}
+
+// runLoadBalancerAction starts a load balancer call, the table gets reloaded after the LoadBalancerActionMsg
+func (m *Model) runLoadBalancerAction(cmd tea.Cmd) tea.Cmd {
+	m.LoadBalancerState.Mode = loadBalancerModeList
+	m.LoadBalancerState.Loading = true
+	m.LoadBalancerState.Status = ""
+	return tea.Batch(m.Spinner.Tick, cmd)
+}
+
+func (m Model) updateLoadBalancerState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var submitted, selected bool
+	state := &m.LoadBalancerState
+	apiKey := m.EnvValues.HetznerApiKey
+	loadBalancer := m.selectedLoadBalancer()
+
+	if state.Mode != loadBalancerModeList && msg.Type == tea.KeyEsc {
+		state.Mode = loadBalancerModeList
+		return m, nil
+	}
+
+	switch state.Mode {
+	case loadBalancerModeCreate:
+		state.Form, cmd, submitted = state.Form.Update(msg)
+		if !submitted {
+			return m, cmd
+		}
+		name, lbType, country := state.Form.Value(0), state.Form.Value(1), state.Form.Value(2)
+		switch {
+		case name == "":
+			state.Form.Err = "name is required"
+		case country != hetzner.CountryGermany && country != hetzner.CountryUSA:
+			state.Form.Err = "location must be germany or us"
+		default:
+			state.OverBudget = budgetOverride{
+				Operation:   "load_balancer.create",
+				Description: fmt.Sprintf("Creating load balancer %s", name),
+				Unchecked:   hetzner.CreateLoadBalancer(m.Ctx, apiKey, name, lbType, country, 0),
+			}
+			return m, m.runLoadBalancerAction(hetzner.CreateLoadBalancer(m.Ctx, apiKey, name, lbType, country, m.Profile.MonthlyBudget))
+		}
+		return m, nil
+
+	case loadBalancerModeAddServer:
+		state.Choice, selected = state.Choice.Update(msg.String())
+		if !selected || loadBalancer == nil || len(state.Servers) == 0 {
+			return m, nil
+		}
+		server := state.Servers[state.Choice.Cursor]
+		return m, m.runLoadBalancerAction(hetzner.AddServerTarget(m.Ctx, apiKey, loadBalancer.ID, server.ID))
+
+	case loadBalancerModeAddLabelSelector:
+		state.Form, cmd, submitted = state.Form.Update(msg)
+		if !submitted || loadBalancer == nil {
+			return m, cmd
+		}
+		return m, m.runLoadBalancerAction(hetzner.AddLabelSelectorTarget(m.Ctx, apiKey, loadBalancer.ID, state.Form.Value(0)))
+
+	case loadBalancerModeRemoveTarget:
+		state.Choice, selected = state.Choice.Update(msg.String())
+		if !selected || loadBalancer == nil || len(loadBalancer.Targets) == 0 {
+			return m, nil
+		}
+		return m, m.runLoadBalancerAction(hetzner.RemoveLoadBalancerTarget(m.Ctx, apiKey, loadBalancer.ID, loadBalancer.Targets[state.Choice.Cursor]))
+
+	case loadBalancerModeAddService:
+		state.Form, cmd, submitted = state.Form.Update(msg)
+		if !submitted || loadBalancer == nil {
+			return m, cmd
+		}
+		serviceOption := hetzner.LoadBalancerServiceOption{
+			Protocol:        state.Form.Value(0),
+			ListenPort:      state.Form.Value(1),
+			DestinationPort: state.Form.Value(2),
+			HealthPath:      state.Form.Value(3),
+			Domain:          state.Form.Value(4),
+		}
+		if serviceOption.Protocol == string(hcloud.LoadBalancerServiceProtocolHTTPS) && serviceOption.Domain == "" {
+			state.Form.Err = "https needs a domain for the certificate"
+			return m, nil
+		}
+		return m, m.runLoadBalancerAction(hetzner.AddLoadBalancerService(m.Ctx, apiKey, loadBalancer.ID, serviceOption))
+
+	case loadBalancerModeDeleteService:
+		state.Choice, selected = state.Choice.Update(msg.String())
+		if !selected || loadBalancer == nil || len(loadBalancer.Services) == 0 {
+			return m, nil
+		}
+		return m, m.runLoadBalancerAction(hetzner.DeleteLoadBalancerService(m.Ctx, apiKey, loadBalancer.ID, loadBalancer.Services[state.Choice.Cursor].ListenPort))
+
+	case loadBalancerModeTargets:
+		state.TargetTable, cmd = state.TargetTable.Update(msg)
+		return m, cmd
+
+	case loadBalancerModeDelete:
+		switch msg.String() {
+		case "y":
+			if loadBalancer != nil {
+				return m, m.runLoadBalancerAction(hetzner.DeleteLoadBalancer(m.Ctx, apiKey, loadBalancer.ID))
+			}
+			state.Mode = loadBalancerModeList
+		case "n":
+			state.Mode = loadBalancerModeList
+		}
+		return m, nil
+
+	case loadBalancerModeOverBudget:
+		switch msg.String() {
+		case "o":
+			return m, m.runLoadBalancerAction(state.OverBudget.override())
+		case "n":
+			state.Mode = loadBalancerModeList
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		state.ShowLoadBalancers = false
+		return m, nil
+	case "c":
+		state.Mode = loadBalancerModeCreate
+		state.Form = newForm("Create load balancer",
+			formField{Label: "Name"},
+			formField{Label: "Type", Value: hetzner.DefaultLoadBalancerType},
+			formField{Label: "Location (germany or us)", Value: hetzner.CountryGermany},
+		)
+		return m, nil
+	}
+	if loadBalancer == nil {
+		state.LoadBalancerTable, cmd = state.LoadBalancerTable.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "enter":
+		state.Mode = loadBalancerModeTargets
+		m.loadTargetTable()
+		return m, nil
+	case "a":
+		state.Mode = loadBalancerModeAddServer
+		choices := make([]string, len(state.Servers))
+		for i, server := range state.Servers {
+			choices[i] = server.Name
+		}
+		state.Choice = newChoiceList(fmt.Sprintf("Add server target to %s", loadBalancer.Name), choices...)
+		return m, nil
+	case "l":
+		state.Mode = loadBalancerModeAddLabelSelector
+		state.Form = newForm(fmt.Sprintf("Add label selector target to %s, matching servers are added automatically", loadBalancer.Name),
+			formField{Label: "Label selector", Placeholder: "app=api"},
+		)
+		return m, nil
+	case "r":
+		state.Mode = loadBalancerModeRemoveTarget
+		serverNames := make(map[int64]string, len(state.Servers))
+		for _, server := range state.Servers {
+			serverNames[server.ID] = server.Name
+		}
+		choices := make([]string, len(loadBalancer.Targets))
+		for i, target := range loadBalancer.Targets {
+			choices[i] = hetzner.TargetName(target, serverNames)
+		}
+		state.Choice = newChoiceList(fmt.Sprintf("Remove target from %s", loadBalancer.Name), choices...)
+		return m, nil
+	case "s":
+		state.Mode = loadBalancerModeAddService
+		state.Form = newForm(fmt.Sprintf("Add service to %s, the health check calls the path on the destination port", loadBalancer.Name),
+			formField{Label: "Protocol (http or https)", Value: string(hcloud.LoadBalancerServiceProtocolHTTP)},
+			formField{Label: "Listen port", Value: "80"},
+			formField{Label: "Destination port", Value: sshconnector.AppPort},
+			formField{Label: "Health check path", Value: "/"},
+			formField{Label: "Domain for the managed certificate (https only)", Placeholder: "api.example.com"},
+		)
+		return m, nil
+	case "x":
+		state.Mode = loadBalancerModeDeleteService
+		choices := make([]string, len(loadBalancer.Services))
+		for i, service := range loadBalancer.Services {
+			choices[i] = fmt.Sprintf("%s %d->%d", service.Protocol, service.ListenPort, service.DestinationPort)
+		}
+		state.Choice = newChoiceList(fmt.Sprintf("Delete service of %s", loadBalancer.Name), choices...)
+		return m, nil
+	case "d":
+		state.Mode = loadBalancerModeDelete
+		return m, nil
+	}
+
+	state.LoadBalancerTable, cmd = state.LoadBalancerTable.Update(msg)
+	m.loadTargetTable()
+	return m, cmd
+}
+
+func (m *Model) setLoadBalancerServers(msg hetzner.ServersLoadedMsg) {
+	if msg.Err != nil {
+		m.LoadBalancerState.Status = fmt.Sprintf("could not load servers: %s", msg.Err)
+		return
+	}
+	m.LoadBalancerState.Servers = msg.Servers
+	m.loadTargetTable()
+}
+
+func (m Model) ViewLoadBalancers() string {
+	state := m.LoadBalancerState
+	switch state.Mode {
+	case loadBalancerModeCreate, loadBalancerModeAddLabelSelector, loadBalancerModeAddService:
+		return state.Form.View()
+	case loadBalancerModeAddServer, loadBalancerModeRemoveTarget, loadBalancerModeDeleteService:
+		return state.Choice.View()
+	}
+
+	var s string
+	if state.Mode == loadBalancerModeTargets {
+		if loadBalancer := m.selectedLoadBalancer(); loadBalancer != nil {
+			s += fmt.Sprintf(" Targets of %s (%s)\n", loadBalancer.Name, loadBalancer.PublicNet.IPv4.IP)
+		}
+		s += baseStyle.Render(state.TargetTable.View()) + "\n"
+		s += " health is refreshed every few seconds • esc back\n"
+	} else {
+		s += baseStyle.Render(state.LoadBalancerTable.View()) + "\n"
+		s += " enter targets • c create • a add server • l add label selector • r remove target • s add service • x delete service • d delete • esc back\n"
+	}
+	if state.Loading {
+		s += fmt.Sprintf("\n %s working...\n", m.Spinner.View())
+	}
+	if state.Status != "" {
+		s += "\n " + state.Status + "\n"
+	}
+	if state.Mode == loadBalancerModeDelete {
+		if loadBalancer := m.selectedLoadBalancer(); loadBalancer != nil {
+			s = PlaceOverlay(80, 5, fmt.Sprintf("Delete load balancer %s?\n\nPress 'y' to confirm, 'n' to cancel.", loadBalancer.Name), s)
+		}
+	}
+	if state.Mode == loadBalancerModeOverBudget {
+		s = state.OverBudget.view(s)
+	}
+	return s
+}
@@ -1,7 +1,8 @@
 package model
 
 import (
-	"log"
+	"context"
+	"fmt"
 	"os"
 	"time"
 
@@ -10,13 +11,80 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/joho/godotenv"
+	"github.com/crabstars/liftoff/audit"
+	"github.com/crabstars/liftoff/config"
+	"github.com/crabstars/liftoff/hetzner"
+	"github.com/crabstars/liftoff/inventory"
+	"github.com/crabstars/liftoff/logging"
+	sshconnector "github.com/crabstars/liftoff/ssh"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+type createStep int
+
+const (
+	createStepNone createStep = iota
+	createStepName
+	createStepCount
+	createStepSshKeys
+	createStepVolume
+	createStepNewVolume
+	createStepExistingVolume
+	createStepFirewall
+	createStepNewFirewall
+	createStepExistingFirewall
+	createStepNetwork
+	createStepNetworkIP
+	createStepPrimaryIP
+	createStepPlacementGroup
+	createStepLabels
+	createStepConfirm
 )
 
 type CreateServerState struct {
-	WaitingForServerNameInput bool
-	ServerNameInput           textinput.Model
-	CreatingServer            bool
+	Step            createStep
+	ServerNameInput textinput.Model
+	// servers created from the name pattern, more than one creates a fleet
+	Count     int
+	CountForm form
+	// keys of the project, the checked ones are added to the server
+	SshKeys       []*hcloud.SSHKey
+	SshKeyChoices checkList
+	// choices of the current wizard step
+	StepChoices choiceList
+	VolumeForm  form
+	// unattached volumes offered in the existing volume step
+	Volumes      []*hcloud.Volume
+	FirewallForm form
+	Firewalls    []*hcloud.Firewall
+	Networks     []*hcloud.Network
+	NetworkForm  form
+	// unassigned primary ipv4s offered in the primary ip step
+	PrimaryIPs      []hetzner.IP
+	PlacementGroups []*hcloud.PlacementGroup
+	LabelForm       form
+	// price of the create shown in the confirm step
+	Estimate        hetzner.CostEstimate
+	EstimateErr     error
+	EstimateLoading bool
+	// run-rate with the estimate added, only loaded with a budget
+	Budget         hetzner.Budget
+	Options        hetzner.CreateServerModel
+	CreatingServer bool
+	// cancels the running single or batch create
+	Cancel context.CancelFunc
+	// outcome of the last create, shown in the menu
+	Status string
+	// progress of a batch create, kept after it finished until enter is pressed
+	Fleet       []taskProgress
+	FleetResult string
+}
+
+// taskProgress is one row of the progress list of a batch create or a bulk action
+type taskProgress struct {
+	Name   string
+	Status string
+	Err    error
 }
 
 type TableState struct {
@@ -24,10 +92,323 @@ type TableState struct {
 	ServerTable           table.Model
 	TabelReloadingChannel chan bool
 	TableReloadRunning    bool
-	RowCursor             int
+	// another fetch was requested while one was running
+	RefreshPending bool
+	LastFetch      time.Time
+	// grows after rate limit responses, 0 otherwise
+	Backoff time.Duration
+	// started protection and label changes which did not report back yet
+	InFlight  int
+	RowCursor int
+	// all servers of the last fetch, Servers only holds the visible ones
+	AllServers []*hcloud.Server
 	// index corresponds to the row index
 	ServerIdIndexRelations []int64
-	ShowOverlay            bool
+	Servers                []*hcloud.Server
+	// incremental search by name, started with /
+	Searching   bool
+	SearchInput textinput.Model
+	Search      string
+	// empty filters match all servers
+	StatusFilter   string
+	LocationFilter string
+	TypeFilter     string
+	// keys of columnRegistry in the shown order
+	Columns []string
+	// index into Columns, -1 keeps the api order
+	SortColumn int
+	SortDesc   bool
+	// column editor, the result is saved in the config file
+	EditingColumns bool
+	ColumnChoices  []columnChoice
+	ColumnCursor   int
+	// delete confirmation, the name of the target has to be typed
+	ShowOverlay  bool
+	DeleteTarget *hcloud.Server
+	DeleteInput  textinput.Model
+	DeleteError  string
+	// progress of running deletes by server id
+	Deleting      map[int64]int
+	DeletingNames map[int64]string
+	// only servers matching the selector are listed, empty lists all
+	LabelSelector string
+	LabelMode     labelMode
+	LabelForm     form
+	Status        string
+	LoadError     string
+	// detail view of the selected server
+	ShowDetail bool
+	// ids of the servers marked for a bulk action
+	Selected     map[int64]bool
+	BulkMode     bulkMode
+	BulkChoice   choiceList
+	BulkForm     form
+	BulkAction   string
+	BulkLabels   map[string]string
+	BulkProgress []taskProgress
+	BulkRunning  bool
+	BulkResult   string
+	BulkCancel   context.CancelFunc
+	// cancels the running deletes by server id
+	DeleteCancel map[int64]context.CancelFunc
+}
+
+type bulkMode int
+
+const (
+	bulkModeNone bulkMode = iota
+	bulkModeFilter
+	bulkModeChoose
+	bulkModeLabels
+	bulkModeConfirm
+	bulkModeProgress
+)
+
+type labelMode int
+
+const (
+	labelModeNone labelMode = iota
+	labelModeEdit
+	labelModeFilter
+)
+
+type volumeMode int
+
+const (
+	volumeModeList volumeMode = iota
+	volumeModeCreate
+	volumeModeAttach
+	volumeModeResize
+	volumeModeDelete
+	volumeModeOverBudget
+)
+
+type VolumeState struct {
+	ShowVolumes bool
+	Mode        volumeMode
+	VolumeTable table.Model
+	// index corresponds to the row index
+	Volumes      []*hcloud.Volume
+	Form         form
+	ServerChoice choiceList
+	Servers      []*hcloud.Server
+	// servers in the location of the selected volume
+	AttachServers []*hcloud.Server
+	// last started create or resize, repeated without budget check after an override
+	OverBudget budgetOverride
+	Loading    bool
+	Status     string
+}
+
+type firewallMode int
+
+const (
+	firewallModeList firewallMode = iota
+	firewallModeCreate
+	firewallModeRules
+	firewallModeAddRule
+	firewallModeApplyServer
+	firewallModeApplyLabel
+	firewallModeDelete
+)
+
+type FirewallState struct {
+	ShowFirewalls bool
+	Mode          firewallMode
+	FirewallTable table.Model
+	// index corresponds to the row index
+	Firewalls []*hcloud.Firewall
+	// rules of the selected firewall while editing, saved with SetFirewallRules
+	Rules        []hcloud.FirewallRule
+	RuleTable    table.Model
+	Form         form
+	ServerChoice choiceList
+	Servers      []*hcloud.Server
+	Loading      bool
+	Status       string
+}
+
+type networkMode int
+
+const (
+	networkModeList networkMode = iota
+	networkModeCreate
+	networkModeAddSubnet
+	networkModeDeleteSubnet
+	networkModeAttachServer
+	networkModeAttachIP
+	networkModeDetachServer
+	networkModeDelete
+)
+
+type NetworkState struct {
+	ShowNetworks bool
+	Mode         networkMode
+	NetworkTable table.Model
+	// index corresponds to the row index
+	Networks []*hcloud.Network
+	Form     form
+	Choice   choiceList
+	Servers  []*hcloud.Server
+	// server picked in the attach mode
+	AttachServer *hcloud.Server
+	Loading      bool
+	Status       string
+}
+
+type ipMode int
+
+const (
+	ipModeList ipMode = iota
+	ipModeAllocate
+	ipModeAssign
+	ipModeReverseDNS
+	ipModeDelete
+	ipModeOverBudget
+)
+
+type IPState struct {
+	ShowIPs bool
+	Mode    ipMode
+	IPTable table.Model
+	// index corresponds to the row index
+	IPs          []hetzner.IP
+	Form         form
+	ServerChoice choiceList
+	Servers      []*hcloud.Server
+	// last started allocation, repeated without budget check after an override
+	OverBudget budgetOverride
+	Loading    bool
+	Status     string
+}
+
+type placementGroupMode int
+
+const (
+	placementGroupModeList placementGroupMode = iota
+	placementGroupModeCreate
+	placementGroupModeAddServer
+	placementGroupModeRemoveServer
+	placementGroupModeDelete
+)
+
+type PlacementGroupState struct {
+	ShowPlacementGroups bool
+	Mode                placementGroupMode
+	PlacementGroupTable table.Model
+	// index corresponds to the row index
+	PlacementGroups []*hcloud.PlacementGroup
+	Form            form
+	ServerChoice    choiceList
+	Servers         []*hcloud.Server
+	// servers without placement group offered in the add mode
+	AddServers []*hcloud.Server
+	Loading    bool
+	Status     string
+}
+
+type loadBalancerMode int
+
+const (
+	loadBalancerModeList loadBalancerMode = iota
+	loadBalancerModeCreate
+	loadBalancerModeTargets
+	loadBalancerModeAddServer
+	loadBalancerModeAddLabelSelector
+	loadBalancerModeRemoveTarget
+	loadBalancerModeAddService
+	loadBalancerModeDeleteService
+	loadBalancerModeDelete
+	loadBalancerModeOverBudget
+)
+
+type LoadBalancerState struct {
+	ShowLoadBalancers bool
+	Mode              loadBalancerMode
+	LoadBalancerTable table.Model
+	// index corresponds to the row index
+	LoadBalancers []*hcloud.LoadBalancer
+	// targets and their health of the selected load balancer
+	TargetTable table.Model
+	Form        form
+	Choice      choiceList
+	Servers     []*hcloud.Server
+	// last started create, repeated without budget check after an override
+	OverBudget budgetOverride
+	Loading    bool
+	Status     string
+	LastFetch  time.Time
+}
+
+type sshKeyMode int
+
+const (
+	sshKeyModeList sshKeyMode = iota
+	sshKeyModeGenerate
+	sshKeyModeUpload
+	sshKeyModeDelete
+)
+
+type SshKeyState struct {
+	ShowSshKeys bool
+	Mode        sshKeyMode
+	KeyTable    table.Model
+	// index corresponds to the row index
+	Keys []*hcloud.SSHKey
+	// public keys of ~/.ssh, matched by fingerprint
+	LocalKeys []sshconnector.LocalKey
+	Form      form
+	Choice    choiceList
+	Loading   bool
+	Status    string
+}
+
+type InventoryState struct {
+	ShowInventory bool
+	Table         table.Model
+	// index corresponds to the row index
+	Servers     []inventory.Server
+	ShowDeleted bool
+	// deployments of the selected server
+	ShowHistory bool
+	Status      string
+}
+
+type AuditState struct {
+	ShowAudit bool
+	Table     table.Model
+	// index corresponds to the row index, the newest first
+	Records []audit.Record
+	// input of audit.ParseFilter
+	Filter     string
+	Filtering  bool
+	Form       form
+	ShowRecord bool
+	Status     string
+}
+
+type CostState struct {
+	ShowCosts bool
+	Table     table.Model
+	// priced resources of the project
+	Items    []hetzner.CostItem
+	Currency string
+	// label key the items are grouped by, index of Groups corresponds to the row index, the total is the last row
+	GroupBy      string
+	Groups       []hetzner.CostGroup
+	Total        hetzner.CostGroup
+	GroupForm    form
+	EditingGroup bool
+	// resources of the selected group
+	ShowItems bool
+	Loading   bool
+	Status    string
+}
+
+type ProfileState struct {
+	ShowProfiles bool
+	Choice       choiceList
+	Status       string
 }
 
 type ActionSelectionState struct {
@@ -36,8 +417,8 @@ type ActionSelectionState struct {
 }
 
 type TableUpdateMsg struct {
-	rows []table.Row
-	ids  []int64
+	servers []*hcloud.Server
+	err     error
 }
 
 type TickMsg time.Time
@@ -47,41 +428,83 @@ type EnvVariables struct {
 	SshKeyName    string
 	Debug         bool
 }
+
+// String keeps the token out of the log and the debug view
+func (e EnvVariables) String() string {
+	apiKey := e.HetznerApiKey
+	if apiKey != "" {
+		apiKey = logging.Redacted
+	}
+	return fmt.Sprintf("{HetznerApiKey:%s SshKeyName:%s Debug:%v}", apiKey, e.SshKeyName, e.Debug)
+}
+
 type Model struct {
 	Spinner              spinner.Model
 	CreateServerState    CreateServerState
 	ActionSelectionState ActionSelectionState
 	TableState           TableState
+	VolumeState          VolumeState
+	FirewallState        FirewallState
+	NetworkState         NetworkState
+	IPState              IPState
+	PlacementGroupState  PlacementGroupState
+	LoadBalancerState    LoadBalancerState
+	SshKeyState          SshKeyState
+	InventoryState       InventoryState
+	AuditState           AuditState
+	CostState            CostState
+	ProfileState         ProfileState
 	Program              *tea.Program
-	EnvValues            EnvVariables
+	// cancelled on quit, every api and ssh call derives its context from it
+	Ctx       context.Context
+	Cancel    context.CancelFunc
+	EnvValues EnvVariables
+	Config    config.Config
+	// active profile of the config file, EnvValues holds its token and ssh key
+	ProfileName string
+	Profile     config.Profile
+	// run-rate of the project against the budget of the profile, shown as banner when it gets close
+	Budget        hetzner.Budget
+	BudgetFetched time.Time
+	// copy of the action tracker, taken on every tick
+	Activity []hetzner.TrackedAction
+	// terminal size from the last tea.WindowSizeMsg
+	Width  int
+	Height int
 }
 
 var baseStyle = lipgloss.NewStyle().
 	BorderStyle(lipgloss.NormalBorder()).
 	BorderForeground(lipgloss.Color("240"))
 
-func InitialModel() Model {
+var errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+var warningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+
+// InitialModel derives the context of all calls from ctx, it is cancelled when liftoff quits or gets interrupted
+func InitialModel(ctx context.Context, conf config.Config, profileName string, profile config.Profile) Model {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	ti := textinput.New()
 	ti.Placeholder = "Server Name"
 	ti.CharLimit = 156
 	ti.Width = 20
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatalf("Error loading .env file")
-	}
+	ctx, cancel := context.WithCancel(ctx)
 	return Model{
+		Ctx:                  ctx,
+		Cancel:               cancel,
 		CreateServerState:    CreateServerState{ServerNameInput: ti},
-		ActionSelectionState: ActionSelectionState{Choices: []string{"Show server", "Create server"}},
-		TableState:           TableState{TabelReloadingChannel: make(chan bool)},
+		ActionSelectionState: ActionSelectionState{Choices: []string{"Show server", "Create server", "Volumes", "Firewalls", "Networks", "IPs", "Placement groups", "Load balancers", "SSH keys", "Inventory", "Audit log", "Costs", "Profiles"}},
+		TableState:           TableState{TabelReloadingChannel: make(chan bool), Deleting: make(map[int64]int), DeletingNames: make(map[int64]string), DeleteCancel: make(map[int64]context.CancelFunc), Selected: make(map[int64]bool), SortColumn: -1, Columns: columnKeys(conf)},
 		Spinner:              s,
-		EnvValues:            EnvVariables{HetznerApiKey: os.Getenv("HETZNER_CLOUD_API_KEY"), SshKeyName: os.Getenv("SSH_KEY_NAME"), Debug: (len(os.Getenv("DEBUG")) > 0)},
+		Config:               conf,
+		ProfileName:          profileName,
+		Profile:              profile,
+		EnvValues:            EnvVariables{HetznerApiKey: profile.Token, SshKeyName: profile.SSHKeyName, Debug: (len(os.Getenv("DEBUG")) > 0)},
 	}
 }
 
 func (m Model) Init() tea.Cmd {
-	return tea.Cmd(tickEvery(time.Second * 2))
+	return tea.Cmd(tickEvery(refreshCheck))
 }
 
 func tickEvery(duration time.Duration) tea.Cmd {
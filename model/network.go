@@ -0,0 +1,273 @@
+package model
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/hetzner"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+func (m *Model) showNetworks() tea.Cmd {
+	m.NetworkState.ShowNetworks = true
+	m.NetworkState.Mode = networkModeList
+	m.NetworkState.Loading = true
+	m.loadNetworkTable(nil)
+	return tea.Batch(m.Spinner.Tick, hetzner.LoadNetworks(m.Ctx, m.EnvValues.HetznerApiKey), hetzner.LoadServers(m.Ctx, m.EnvValues.HetznerApiKey))
+}
+
+func (m *Model) loadNetworkTable(networks []*hcloud.Network) {
+	columns := []table.Column{
+		{Title: "Name", Width: 20},
+		{Title: "IP Range", Width: 16},
+		{Title: "Subnets", Width: 35},
+		{Title: "Servers", Width: 50},
+	}
+
+	rows := make([]table.Row, len(networks))
+	for i, network := range networks {
+		subnets := make([]string, len(network.Subnets))
+		for j, subnet := range network.Subnets {
+			subnets[j] = fmt.Sprintf("%s (%s)", subnet.IPRange, subnet.NetworkZone)
+		}
+		rows[i] = table.Row{network.Name, network.IPRange.String(), strings.Join(subnets, ", "), strings.Join(m.networkServerNames(network), ", ")}
+	}
+
+	m.NetworkState.Networks = networks
+	m.NetworkState.NetworkTable = newStyledTable(columns, rows, m.NetworkState.NetworkTable.Cursor())
+}
+
+// networkServerNames returns name and private ip of all servers in the network
+func (m Model) networkServerNames(network *hcloud.Network) []string {
+	var names []string
+	for _, server := range m.NetworkState.Servers {
+		for _, privateNet := range server.PrivateNet {
+			if privateNet.Network.ID == network.ID {
+				names = append(names, fmt.Sprintf("%s %s", server.Name, privateNet.IP))
+			}
+		}
+	}
+	return names
+}
+
+func (m Model) selectedNetwork() *hcloud.Network {
+	index := m.NetworkState.NetworkTable.Cursor()
+	if index < 0 || index >= len(m.NetworkState.Networks) {
+		return nil
+	}
+	return m.NetworkState.Networks[index]
+}
+
+// runNetworkAction starts a network call, the table gets reloaded after the NetworkActionMsg
+func (m *Model) runNetworkAction(cmd tea.Cmd) tea.Cmd {
+	m.NetworkState.Mode = networkModeList
+	m.NetworkState.Loading = true
+	m.NetworkState.Status = ""
+	return tea.Batch(m.Spinner.Tick, cmd)
+}
+
+func (m Model) updateNetworkState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var submitted, selected bool
+	state := &m.NetworkState
+	apiKey := m.EnvValues.HetznerApiKey
+	network := m.selectedNetwork()
+
+	if state.Mode != networkModeList && msg.Type == tea.KeyEsc {
+		state.Mode = networkModeList
+		return m, nil
+	}
+
+	switch state.Mode {
+	case networkModeCreate:
+		state.Form, cmd, submitted = state.Form.Update(msg)
+		if !submitted {
+			return m, cmd
+		}
+		if state.Form.Value(0) == "" {
+			state.Form.Err = "name is required"
+			return m, nil
+		}
+		return m, m.runNetworkAction(hetzner.CreateNetwork(m.Ctx, apiKey, state.Form.Value(0), state.Form.Value(1), state.Form.Value(2), state.Form.Value(3)))
+
+	case networkModeAddSubnet:
+		state.Form, cmd, submitted = state.Form.Update(msg)
+		if !submitted || network == nil {
+			return m, cmd
+		}
+		return m, m.runNetworkAction(hetzner.AddSubnet(m.Ctx, apiKey, network.ID, state.Form.Value(0), state.Form.Value(1)))
+
+	case networkModeDeleteSubnet:
+		state.Choice, selected = state.Choice.Update(msg.String())
+		if !selected || network == nil {
+			return m, nil
+		}
+		subnet := network.Subnets[state.Choice.Cursor]
+		return m, m.runNetworkAction(hetzner.DeleteSubnet(m.Ctx, apiKey, network.ID, subnet.IPRange.String()))
+
+	case networkModeAttachServer:
+		state.Choice, selected = state.Choice.Update(msg.String())
+		if !selected || network == nil {
+			return m, nil
+		}
+		state.AttachServer = state.Servers[state.Choice.Cursor]
+		state.Mode = networkModeAttachIP
+		state.Form = newForm(fmt.Sprintf("Attach %s to %s", state.AttachServer.Name, network.Name),
+			formField{Label: "Private IP (empty for automatic)", Placeholder: "10.0.1.10"},
+		)
+		return m, nil
+
+	case networkModeAttachIP:
+		state.Form, cmd, submitted = state.Form.Update(msg)
+		if !submitted || network == nil {
+			return m, cmd
+		}
+		if err := validatePrivateIP(network, state.Form.Value(0)); err != nil {
+			state.Form.Err = err.Error()
+			return m, nil
+		}
+		return m, m.runNetworkAction(hetzner.AttachServerToNetwork(m.Ctx, apiKey, network.ID, state.AttachServer.ID, state.Form.Value(0)))
+
+	case networkModeDetachServer:
+		state.Choice, selected = state.Choice.Update(msg.String())
+		if !selected || network == nil {
+			return m, nil
+		}
+		server := m.networkServers(network)[state.Choice.Cursor]
+		return m, m.runNetworkAction(hetzner.DetachServerFromNetwork(m.Ctx, apiKey, network.ID, server.ID))
+
+	case networkModeDelete:
+		switch msg.String() {
+		case "y":
+			if network != nil {
+				return m, m.runNetworkAction(hetzner.DeleteNetwork(m.Ctx, apiKey, network.ID))
+			}
+			state.Mode = networkModeList
+		case "n":
+			state.Mode = networkModeList
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		state.ShowNetworks = false
+		return m, nil
+	case "c":
+		state.Mode = networkModeCreate
+		state.Form = newForm("Create network",
+			formField{Label: "Name"},
+			formField{Label: "IP range", Value: "10.0.0.0/16"},
+			formField{Label: "First subnet", Value: "10.0.1.0/24"},
+			formField{Label: "Network zone (eu-central, us-east or us-west)", Value: string(hcloud.NetworkZoneEUCentral)},
+		)
+		return m, nil
+	}
+	if network == nil {
+		state.NetworkTable, cmd = state.NetworkTable.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "s":
+		state.Mode = networkModeAddSubnet
+		state.Form = newForm(fmt.Sprintf("Add subnet to %s (%s)", network.Name, network.IPRange),
+			formField{Label: "Subnet", Placeholder: "10.0.2.0/24"},
+			formField{Label: "Network zone (eu-central, us-east or us-west)", Value: string(hcloud.NetworkZoneEUCentral)},
+		)
+		return m, nil
+	case "x":
+		state.Mode = networkModeDeleteSubnet
+		choices := make([]string, len(network.Subnets))
+		for i, subnet := range network.Subnets {
+			choices[i] = subnet.IPRange.String()
+		}
+		state.Choice = newChoiceList(fmt.Sprintf("Delete subnet of %s", network.Name), choices...)
+		return m, nil
+	case "a":
+		state.Mode = networkModeAttachServer
+		choices := make([]string, len(state.Servers))
+		for i, server := range state.Servers {
+			choices[i] = server.Name
+		}
+		state.Choice = newChoiceList(fmt.Sprintf("Attach server to %s", network.Name), choices...)
+		return m, nil
+	case "r":
+		state.Mode = networkModeDetachServer
+		state.Choice = newChoiceList(fmt.Sprintf("Detach server from %s", network.Name), m.networkServerNames(network)...)
+		return m, nil
+	case "d":
+		state.Mode = networkModeDelete
+		return m, nil
+	}
+
+	state.NetworkTable, cmd = state.NetworkTable.Update(msg)
+	return m, cmd
+}
+
+// networkServers returns the servers in the network in the same order as networkServerNames
+func (m Model) networkServers(network *hcloud.Network) []*hcloud.Server {
+	var servers []*hcloud.Server
+	for _, server := range m.NetworkState.Servers {
+		for _, privateNet := range server.PrivateNet {
+			if privateNet.Network.ID == network.ID {
+				servers = append(servers, server)
+			}
+		}
+	}
+	return servers
+}
+
+// validatePrivateIP accepts an empty ip or an ip inside of one of the subnets
+func validatePrivateIP(network *hcloud.Network, ip string) error {
+	if ip == "" {
+		return nil
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return fmt.Errorf("invalid ip %s", ip)
+	}
+	for _, subnet := range network.Subnets {
+		if subnet.IPRange.Contains(parsed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is in no subnet of %s", ip, network.Name)
+}
+
+func (m *Model) setNetworkServers(msg hetzner.ServersLoadedMsg) {
+	if msg.Err != nil {
+		m.NetworkState.Status = fmt.Sprintf("could not load servers: %s", msg.Err)
+		return
+	}
+	m.NetworkState.Servers = msg.Servers
+	m.loadNetworkTable(m.NetworkState.Networks)
+}
+
+func (m Model) ViewNetworks() string {
+	state := m.NetworkState
+	switch state.Mode {
+	case networkModeCreate, networkModeAddSubnet, networkModeAttachIP:
+		return state.Form.View()
+	case networkModeDeleteSubnet, networkModeAttachServer, networkModeDetachServer:
+		return state.Choice.View()
+	}
+
+	s := baseStyle.Render(state.NetworkTable.View()) + "\n"
+	s += " c create • s add subnet • x delete subnet • a attach server • r detach server • d delete • esc back\n"
+	if state.Loading {
+		s += fmt.Sprintf("\n %s working...\n", m.Spinner.View())
+	}
+	if state.Status != "" {
+		s += "\n " + state.Status + "\n"
+	}
+	if state.Mode == networkModeDelete {
+		if network := m.selectedNetwork(); network != nil {
+			s = PlaceOverlay(80, 5, fmt.Sprintf("Delete network %s?\n\nPress 'y' to confirm, 'n' to cancel.", network.Name), s)
+		}
+	}
+	return s
+}
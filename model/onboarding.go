@@ -0,0 +1,376 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/config"
+	"github.com/crabstars/liftoff/hetzner"
+	"github.com/crabstars/liftoff/secrets"
+	sshconnector "github.com/crabstars/liftoff/ssh"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+type onboardingStep int
+
+const (
+	onboardingStepToken onboardingStep = iota
+	onboardingStepCheckToken
+	onboardingStepKey
+	onboardingStepUploadName
+	onboardingStepUpload
+	onboardingStepKeyPath
+	onboardingStepDefaults
+	onboardingStepStore
+	onboardingStepPassphrase
+)
+
+// token stores offered in the last step, the plain token is only meant for throwaway setups
+const (
+	storeChoiceKeyring = "system keyring"
+	storeChoiceFile    = "file encrypted with a passphrase, for machines without a keyring"
+)
+
+type tokenCheckedMsg struct {
+	Err error
+}
+
+type sshKeyUploadedMsg struct {
+	Key *hcloud.SSHKey
+	Err error
+}
+
+// onboardingKey is one choice of the key step, either a hetzner key or a local key which gets uploaded
+type onboardingKey struct {
+	Hetzner  *hcloud.SSHKey
+	Local    sshconnector.LocalKey
+	HasLocal bool
+}
+
+// Onboarding asks for everything a profile needs and writes it to the config file, it runs before the
+// main tui when the active profile has no token or ssh key
+type Onboarding struct {
+	Step    onboardingStep
+	Form    form
+	Choice  choiceList
+	Spinner spinner.Model
+	Keys    []onboardingKey
+	// key picked or uploaded in the key step
+	Key    onboardingKey
+	Status string
+	Ctx    context.Context
+	Config config.Config
+	// Done is set after the config file was written, Profile then holds the token
+	Done        bool
+	ProfileName string
+	Profile     config.Profile
+}
+
+// NewOnboarding starts with the values of the profile, e.g. a token from the environment
+func NewOnboarding(ctx context.Context, conf config.Config, profileName string, profile config.Profile) Onboarding {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	o := Onboarding{Ctx: ctx, Config: conf, ProfileName: profileName, Profile: profile, Spinner: s}
+	o.Form = newForm("Welcome to liftoff, let's set up a profile",
+		formField{Label: "Profile name", Placeholder: config.DefaultProfile, Value: profileName},
+		formField{Label: "Hetzner cloud api token (Security > API tokens, read & write)", Value: profile.Token},
+	)
+	o.Form.Inputs[1].EchoMode = textinput.EchoPassword
+	return o
+}
+
+func (o Onboarding) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (o Onboarding) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		o.Spinner, cmd = o.Spinner.Update(msg)
+		return o, cmd
+
+	case tokenCheckedMsg:
+		if msg.Err != nil {
+			o.Step = onboardingStepToken
+			o.Form.Err = msg.Err.Error()
+			return o, nil
+		}
+		o.Status = "loading the ssh keys of the project"
+		return o, hetzner.LoadSshKeys(o.Ctx, o.Profile.Token)
+
+	case hetzner.SshKeysLoadedMsg:
+		if msg.Err != nil {
+			o.Step = onboardingStepToken
+			o.Form.Err = fmt.Sprintf("could not load ssh keys: %s", msg.Err)
+			return o, nil
+		}
+		o.showKeys(msg.Keys)
+		return o, nil
+
+	case sshKeyUploadedMsg:
+		if msg.Err != nil {
+			o.Step = onboardingStepUploadName
+			o.Form.Err = fmt.Sprintf("upload failed: %s", msg.Err)
+			return o, nil
+		}
+		o.Key.Hetzner = msg.Key
+		o.showDefaults()
+		return o, nil
+
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC || msg.Type == tea.KeyEsc {
+			return o, tea.Quit
+		}
+		return o.updateStep(msg)
+	}
+	return o, nil
+}
+
+func (o Onboarding) updateStep(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var submitted, selected bool
+
+	switch o.Step {
+	case onboardingStepToken:
+		o.Form, cmd, submitted = o.Form.Update(msg)
+		if !submitted {
+			return o, cmd
+		}
+		if o.Form.Value(1) == "" {
+			o.Form.Err = "the token is required"
+			return o, nil
+		}
+		o.ProfileName = o.Form.Value(0)
+		if o.ProfileName == "" {
+			o.ProfileName = config.DefaultProfile
+		}
+		o.Profile.Token = o.Form.Value(1)
+		o.Step = onboardingStepCheckToken
+		o.Status = "checking the token"
+		return o, tea.Batch(o.Spinner.Tick, checkToken(o.Ctx, o.Profile.Token))
+
+	case onboardingStepKey:
+		o.Choice, selected = o.Choice.Update(msg.String())
+		if !selected {
+			return o, nil
+		}
+		o.Key = o.Keys[o.Choice.Cursor]
+		if o.Key.Hetzner == nil {
+			hostname, _ := os.Hostname()
+			o.Step = onboardingStepUploadName
+			o.Form = newForm(fmt.Sprintf("Upload %s to hetzner", o.Key.Local.PublicPath),
+				formField{Label: "Key name", Value: fmt.Sprintf("%s-%s", hostname, o.Key.Local.Name)},
+			)
+			return o, nil
+		}
+		if !o.Key.HasLocal {
+			o.Step = onboardingStepKeyPath
+			o.Form = newForm(fmt.Sprintf("No private key in ~/.ssh matches %s", o.Key.Hetzner.Name),
+				formField{Label: "Path of the private key", Value: "~/.ssh/id_ed25519"},
+			)
+			return o, nil
+		}
+		o.showDefaults()
+		return o, nil
+
+	case onboardingStepUploadName:
+		o.Form, cmd, submitted = o.Form.Update(msg)
+		if !submitted {
+			return o, cmd
+		}
+		if o.Form.Value(0) == "" {
+			o.Form.Err = "the key name is required"
+			return o, nil
+		}
+		o.Step = onboardingStepUpload
+		o.Status = "uploading the public key"
+		return o, tea.Batch(o.Spinner.Tick, uploadSshKey(o.Ctx, o.Profile.Token, o.Form.Value(0), o.Key.Local.PublicKey))
+
+	case onboardingStepKeyPath:
+		o.Form, cmd, submitted = o.Form.Update(msg)
+		if !submitted {
+			return o, cmd
+		}
+		path := o.Form.Value(0)
+		expanded, err := sshconnector.ExpandHome(path)
+		if err == nil {
+			_, err = os.Stat(expanded)
+		}
+		if err != nil {
+			o.Form.Err = err.Error()
+			return o, nil
+		}
+		o.Key.Local.PrivatePath = path
+		o.Key.HasLocal = true
+		o.showDefaults()
+		return o, nil
+
+	case onboardingStepDefaults:
+		o.Form, cmd, submitted = o.Form.Update(msg)
+		if !submitted {
+			return o, cmd
+		}
+		o.Profile.ServerType = o.Form.Value(0)
+		o.Profile.Image = o.Form.Value(1)
+		o.Profile.Location = o.Form.Value(2)
+		o.Step = onboardingStepStore
+		o.Choice = newChoiceList("Where should the token be stored?", storeChoiceKeyring, storeChoiceFile)
+		return o, nil
+
+	case onboardingStepStore:
+		o.Choice, selected = o.Choice.Update(msg.String())
+		if !selected {
+			return o, nil
+		}
+		if o.Choice.Choices[o.Choice.Cursor] == storeChoiceFile {
+			path, _ := secrets.FilePath()
+			o.Step = onboardingStepPassphrase
+			o.Form = newForm(fmt.Sprintf("Passphrase of %s, an existing file keeps its passphrase", path),
+				formField{Label: "Passphrase"},
+				formField{Label: "Repeat the passphrase"},
+			)
+			o.Form.Inputs[0].EchoMode = textinput.EchoPassword
+			o.Form.Inputs[1].EchoMode = textinput.EchoPassword
+			return o, nil
+		}
+		if err := o.save(o.Choice.Choices[o.Choice.Cursor]); err != nil {
+			o.Status = errorStyle.Render(err.Error())
+			return o, nil
+		}
+		o.Done = true
+		return o, tea.Quit
+
+	case onboardingStepPassphrase:
+		o.Form, cmd, submitted = o.Form.Update(msg)
+		if !submitted {
+			return o, cmd
+		}
+		switch {
+		case o.Form.Value(0) == "":
+			o.Form.Err = "the passphrase is required"
+			return o, nil
+		case o.Form.Value(0) != o.Form.Value(1):
+			o.Form.Err = "the passphrases do not match"
+			return o, nil
+		}
+		secrets.SetPassphrase(o.Form.Value(0))
+		if err := o.save(storeChoiceFile); err != nil {
+			// a wrong passphrase must not stay cached
+			secrets.SetPassphrase("")
+			o.Form.Err = err.Error()
+			return o, nil
+		}
+		o.Done = true
+		return o, tea.Quit
+	}
+	return o, nil
+}
+
+// showKeys offers the hetzner keys and the local keys which are not uploaded yet
+func (o *Onboarding) showKeys(keys []*hcloud.SSHKey) {
+	local, _ := sshconnector.LocalKeys()
+	o.Keys = nil
+	var choices []string
+	uploaded := make(map[string]bool)
+	for _, key := range keys {
+		uploaded[key.Fingerprint] = true
+		localKey, found := sshconnector.FindLocalKey(local, key.Fingerprint)
+		found = found && localKey.PrivatePath != ""
+		o.Keys = append(o.Keys, onboardingKey{Hetzner: key, Local: localKey, HasLocal: found})
+		if found {
+			choices = append(choices, fmt.Sprintf("%s (%s)", key.Name, localKey.PrivatePath))
+		} else {
+			choices = append(choices, fmt.Sprintf("%s (no private key found)", key.Name))
+		}
+	}
+	for _, key := range local {
+		if uploaded[key.Fingerprint] || key.PrivatePath == "" {
+			continue
+		}
+		o.Keys = append(o.Keys, onboardingKey{Local: key, HasLocal: true})
+		choices = append(choices, fmt.Sprintf("upload %s", key.PublicPath))
+	}
+	o.Step = onboardingStepKey
+	o.Status = ""
+	o.Choice = newChoiceList("SSH key added to new servers", choices...)
+}
+
+func (o *Onboarding) showDefaults() {
+	o.Step = onboardingStepDefaults
+	o.Status = ""
+	o.Form = newForm("Defaults for new servers, empty fields use the liftoff defaults",
+		formField{Label: "Server type", Placeholder: "cx22", Value: o.Profile.ServerType},
+		formField{Label: "Image", Placeholder: "docker-ce", Value: o.Profile.Image},
+		formField{Label: "Location", Placeholder: "fsn1", Value: o.Profile.Location},
+	)
+}
+
+// save writes the profile into the config file and the token into the chosen store
+func (o *Onboarding) save(store string) error {
+	o.Profile.SSHKeyName = o.Key.Hetzner.Name
+	o.Profile.SSHKeyPath = o.Key.Local.PrivatePath
+
+	// the token never goes into the config file, HETZNER_CLOUD_API_KEY covers ci
+	saved := o.Profile
+	saved.Token = ""
+	switch store {
+	case storeChoiceFile:
+		if err := secrets.Save(secrets.StoreFile, o.ProfileName, o.Profile.Token); err != nil {
+			return err
+		}
+		saved.TokenStore = secrets.StoreFile
+	default:
+		if err := secrets.Save(secrets.StoreKeyring, o.ProfileName, o.Profile.Token); err != nil {
+			return fmt.Errorf("no keyring available (%s), store the token in the encrypted file instead", err)
+		}
+		saved.TokenStore = secrets.StoreKeyring
+	}
+	if o.Config.Profiles == nil {
+		o.Config.Profiles = make(map[string]config.Profile)
+	}
+	o.Config.Profiles[o.ProfileName] = saved
+	o.Config.CurrentProfile = o.ProfileName
+	return config.Save(o.Config)
+}
+
+func (o Onboarding) View() string {
+	var s string
+	switch o.Step {
+	case onboardingStepCheckToken, onboardingStepUpload:
+		return fmt.Sprintf("\n %s %s…\n", o.Spinner.View(), o.Status)
+	case onboardingStepKey:
+		s = o.Choice.View()
+		if len(o.Keys) == 0 {
+			s += "\n no ssh keys in the project or in ~/.ssh, create one with ssh-keygen -t ed25519 and restart liftoff\n"
+		}
+	case onboardingStepStore:
+		s = o.Choice.View()
+		if o.Status != "" {
+			s += "\n " + o.Status + "\n"
+		}
+	default:
+		s = o.Form.View()
+	}
+	if path, err := config.Path(); err == nil {
+		s += fmt.Sprintf("\n the profile is saved in %s\n", path)
+	}
+	return s
+}
+
+func checkToken(ctx context.Context, token string) tea.Cmd {
+	return func() tea.Msg {
+		return tokenCheckedMsg{Err: hetzner.ValidateToken(ctx, token)}
+	}
+}
+
+func uploadSshKey(ctx context.Context, token string, name string, publicKey string) tea.Cmd {
+	return func() tea.Msg {
+		key, err := hetzner.UploadSshKey(ctx, token, name, publicKey)
+		return sshKeyUploadedMsg{Key: key, Err: err}
+	}
+}
@@ -0,0 +1,206 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/hetzner"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+func (m *Model) showPlacementGroups() tea.Cmd {
+	m.PlacementGroupState.ShowPlacementGroups = true
+	m.PlacementGroupState.Mode = placementGroupModeList
+	m.PlacementGroupState.Loading = true
+	m.loadPlacementGroupTable(nil)
+	return tea.Batch(m.Spinner.Tick, hetzner.LoadPlacementGroups(m.Ctx, m.EnvValues.HetznerApiKey), hetzner.LoadServers(m.Ctx, m.EnvValues.HetznerApiKey))
+}
+
+func (m *Model) loadPlacementGroupTable(placementGroups []*hcloud.PlacementGroup) {
+	columns := []table.Column{
+		{Title: "Name", Width: 20},
+		{Title: "Type", Width: 8},
+		{Title: "Count", Width: 6},
+		{Title: "Servers", Width: 60},
+	}
+
+	rows := make([]table.Row, len(placementGroups))
+	for i, placementGroup := range placementGroups {
+		rows[i] = table.Row{placementGroup.Name, string(placementGroup.Type), fmt.Sprintf("%d/%d", len(placementGroup.Servers), hetzner.MaxServersPerSpreadGroup), strings.Join(m.placementGroupServerNames(placementGroup), ", ")}
+	}
+
+	m.PlacementGroupState.PlacementGroups = placementGroups
+	m.PlacementGroupState.PlacementGroupTable = newStyledTable(columns, rows, m.PlacementGroupState.PlacementGroupTable.Cursor())
+}
+
+// placementGroupServers returns the servers of the group in the order of placementGroup.Servers
+func (m Model) placementGroupServers(placementGroup *hcloud.PlacementGroup) []*hcloud.Server {
+	var servers []*hcloud.Server
+	for _, serverID := range placementGroup.Servers {
+		for _, server := range m.PlacementGroupState.Servers {
+			if server.ID == serverID {
+				servers = append(servers, server)
+			}
+		}
+	}
+	return servers
+}
+
+func (m Model) placementGroupServerNames(placementGroup *hcloud.PlacementGroup) []string {
+	servers := m.placementGroupServers(placementGroup)
+	names := make([]string, len(servers))
+	for i, server := range servers {
+		names[i] = server.Name
+	}
+	return names
+}
+
+func (m Model) selectedPlacementGroup() *hcloud.PlacementGroup {
+	index := m.PlacementGroupState.PlacementGroupTable.Cursor()
+	if index < 0 || index >= len(m.PlacementGroupState.PlacementGroups) {
+		return nil
+	}
+	return m.PlacementGroupState.PlacementGroups[index]
+}
+
+// runPlacementGroupAction starts a placement group call, the table gets reloaded after the PlacementGroupActionMsg
+func (m *Model) runPlacementGroupAction(cmd tea.Cmd) tea.Cmd {
+	m.PlacementGroupState.Mode = placementGroupModeList
+	m.PlacementGroupState.Loading = true
+	m.PlacementGroupState.Status = ""
+	return tea.Batch(m.Spinner.Tick, cmd)
+}
+
+func (m Model) updatePlacementGroupState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var submitted, selected bool
+	state := &m.PlacementGroupState
+	apiKey := m.EnvValues.HetznerApiKey
+	placementGroup := m.selectedPlacementGroup()
+
+	if state.Mode != placementGroupModeList && msg.Type == tea.KeyEsc {
+		state.Mode = placementGroupModeList
+		return m, nil
+	}
+
+	switch state.Mode {
+	case placementGroupModeCreate:
+		state.Form, cmd, submitted = state.Form.Update(msg)
+		if !submitted {
+			return m, cmd
+		}
+		if state.Form.Value(0) == "" {
+			state.Form.Err = "name is required"
+			return m, nil
+		}
+		return m, m.runPlacementGroupAction(hetzner.CreatePlacementGroup(m.Ctx, apiKey, state.Form.Value(0)))
+
+	case placementGroupModeAddServer:
+		state.ServerChoice, selected = state.ServerChoice.Update(msg.String())
+		if !selected || placementGroup == nil || len(state.AddServers) == 0 {
+			return m, nil
+		}
+		server := state.AddServers[state.ServerChoice.Cursor]
+		return m, m.runPlacementGroupAction(hetzner.AddServerToPlacementGroup(m.Ctx, apiKey, placementGroup.ID, server.ID))
+
+	case placementGroupModeRemoveServer:
+		state.ServerChoice, selected = state.ServerChoice.Update(msg.String())
+		servers := m.placementGroupServers(placementGroup)
+		if !selected || placementGroup == nil || len(servers) == 0 {
+			return m, nil
+		}
+		server := servers[state.ServerChoice.Cursor]
+		return m, m.runPlacementGroupAction(hetzner.RemoveServerFromPlacementGroup(m.Ctx, apiKey, placementGroup.ID, server.ID))
+
+	case placementGroupModeDelete:
+		switch msg.String() {
+		case "y":
+			if placementGroup != nil {
+				return m, m.runPlacementGroupAction(hetzner.DeletePlacementGroup(m.Ctx, apiKey, placementGroup.ID))
+			}
+			state.Mode = placementGroupModeList
+		case "n":
+			state.Mode = placementGroupModeList
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		state.ShowPlacementGroups = false
+		return m, nil
+	case "c":
+		state.Mode = placementGroupModeCreate
+		state.Form = newForm("Create spread placement group, its servers run on different hosts",
+			formField{Label: "Name"},
+		)
+		return m, nil
+	}
+	if placementGroup == nil {
+		state.PlacementGroupTable, cmd = state.PlacementGroupTable.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "a":
+		state.Mode = placementGroupModeAddServer
+		// a server can only be in one placement group and has to be powered off
+		state.AddServers = nil
+		var choices []string
+		for _, server := range state.Servers {
+			if server.PlacementGroup != nil {
+				continue
+			}
+			state.AddServers = append(state.AddServers, server)
+			choices = append(choices, fmt.Sprintf("%s (%s)", server.Name, server.Status))
+		}
+		state.ServerChoice = newChoiceList(fmt.Sprintf("Add server to %s (has to be powered off)", placementGroup.Name), choices...)
+		return m, nil
+	case "r":
+		state.Mode = placementGroupModeRemoveServer
+		state.ServerChoice = newChoiceList(fmt.Sprintf("Remove server from %s (has to be powered off)", placementGroup.Name), m.placementGroupServerNames(placementGroup)...)
+		return m, nil
+	case "d":
+		state.Mode = placementGroupModeDelete
+		return m, nil
+	}
+
+	state.PlacementGroupTable, cmd = state.PlacementGroupTable.Update(msg)
+	return m, cmd
+}
+
+func (m *Model) setPlacementGroupServers(msg hetzner.ServersLoadedMsg) {
+	if msg.Err != nil {
+		m.PlacementGroupState.Status = fmt.Sprintf("could not load servers: %s", msg.Err)
+		return
+	}
+	m.PlacementGroupState.Servers = msg.Servers
+	m.loadPlacementGroupTable(m.PlacementGroupState.PlacementGroups)
+}
+
+func (m Model) ViewPlacementGroups() string {
+	state := m.PlacementGroupState
+	switch state.Mode {
+	case placementGroupModeCreate:
+		return state.Form.View()
+	case placementGroupModeAddServer, placementGroupModeRemoveServer:
+		return state.ServerChoice.View()
+	}
+
+	s := baseStyle.Render(state.PlacementGroupTable.View()) + "\n"
+	s += " c create • a add server • r remove server • d delete • esc back\n"
+	if state.Loading {
+		s += fmt.Sprintf("\n %s working...\n", m.Spinner.View())
+	}
+	if state.Status != "" {
+		s += "\n " + state.Status + "\n"
+	}
+	if state.Mode == placementGroupModeDelete {
+		if placementGroup := m.selectedPlacementGroup(); placementGroup != nil {
+			s = PlaceOverlay(80, 5, fmt.Sprintf("Delete placement group %s?\n\nPress 'y' to confirm, 'n' to cancel.", placementGroup.Name), s)
+		}
+	}
+	return s
+}
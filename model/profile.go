@@ -0,0 +1,91 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/audit"
+	"github.com/crabstars/liftoff/config"
+	"github.com/crabstars/liftoff/hetzner"
+	"github.com/crabstars/liftoff/logging"
+	sshconnector "github.com/crabstars/liftoff/ssh"
+)
+
+func (m *Model) showProfiles() {
+	state := &m.ProfileState
+	state.ShowProfiles = true
+	state.Status = ""
+	names := m.Config.ProfileNames()
+	choices := make([]string, len(names))
+	for i, name := range names {
+		choices[i] = name
+		if name == m.ProfileName {
+			choices[i] += " (active)"
+		}
+	}
+	state.Choice = newChoiceList("Switch to profile", choices...)
+	for i, name := range names {
+		if name == m.ProfileName {
+			state.Choice.Cursor = i
+		}
+	}
+}
+
+func (m Model) updateProfileState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	state := &m.ProfileState
+	if msg.Type == tea.KeyEsc {
+		state.ShowProfiles = false
+		return m, nil
+	}
+	var selected bool
+	state.Choice, selected = state.Choice.Update(msg.String())
+	if !selected {
+		return m, nil
+	}
+	name := m.Config.ProfileNames()[state.Choice.Cursor]
+	_, profile, err := m.Config.Resolve(name)
+	if err != nil {
+		state.Status = errorStyle.Render(err.Error())
+		return m, nil
+	}
+	m.useProfile(name, profile)
+	m.Config.CurrentProfile = name
+	m.showProfiles()
+	state.Status = fmt.Sprintf("switched to %s", name)
+	if err := config.Save(m.Config); err != nil {
+		state.Status = errorStyle.Render(fmt.Sprintf("switched to %s but the config could not be saved: %s", name, err))
+	}
+	return m, nil
+}
+
+// useProfile replaces token and ssh key, the servers of the old project are dropped
+func (m *Model) useProfile(name string, profile config.Profile) {
+	m.ProfileName = name
+	m.Profile = profile
+	audit.Log.SetProfile(name)
+	logging.Redact(profile.Token)
+	m.EnvValues.HetznerApiKey = profile.Token
+	m.EnvValues.SshKeyName = profile.SSHKeyName
+	sshconnector.KeyPath = profile.SSHKeyPath
+	m.TableState.AllServers = nil
+	m.TableState.Selected = make(map[int64]bool)
+	m.Budget = hetzner.Budget{}
+	m.BudgetFetched = time.Time{}
+	m.refreshServerTable()
+}
+
+func (m Model) ViewProfiles() string {
+	s := m.ProfileState.Choice.View()
+	if len(m.ProfileState.Choice.Choices) == 0 {
+		s += "\n add profiles to the config file\n"
+	}
+	if os.Getenv(config.EnvToken) != "" {
+		s += fmt.Sprintf("\n %s is set and overrides the token of every profile\n", config.EnvToken)
+	}
+	if m.ProfileState.Status != "" {
+		s += "\n " + m.ProfileState.Status + "\n"
+	}
+	return s + "\n enter switch • esc back\n"
+}
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"time"
+
+	"github.com/crabstars/liftoff/hetzner"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+const (
+	// refresh interval while liftoff or hetzner is changing servers
+	refreshFast = 2 * time.Second
+	// refresh interval when nothing happens
+	refreshIdle = 15 * time.Second
+	// bounds of the backoff after a rate limit response
+	backoffMin = 10 * time.Second
+	backoffMax = 2 * time.Minute
+	// the refresh engine checks this often if a fetch is due
+	refreshCheck = time.Second
+	// the run-rate for the budget banner lists every billed resource, so it is fetched rarely
+	budgetRefresh = 10 * time.Minute
+)
+
+// requestRefresh fetches the servers right away, a fetch requested while one is running follows directly after it
+func (m *Model) requestRefresh() {
+	state := &m.TableState
+	if state.TableReloadRunning {
+		state.RefreshPending = true
+		return
+	}
+	state.TableReloadRunning = true
+	state.RefreshPending = false
+	state.LastFetch = time.Now()
+	go m.fetchTableRows()
+}
+
+// refreshInterval is short while actions are in flight and grows after rate limit responses
+func (m Model) refreshInterval() time.Duration {
+	interval := refreshIdle
+	if m.actionsInFlight() {
+		interval = refreshFast
+	}
+	if m.TableState.Backoff > interval {
+		interval = m.TableState.Backoff
+	}
+	return interval
+}
+
+// actionsInFlight is true while liftoff waits for an operation or a server is in a transitional state
+func (m Model) actionsInFlight() bool {
+	if m.CreateServerState.CreatingServer || len(m.TableState.Deleting) > 0 || m.TableState.BulkRunning {
+		return true
+	}
+	if m.TableState.InFlight > 0 {
+		return true
+	}
+	if hetzner.Tracker.RunningCount() > 0 {
+		return true
+	}
+	for _, server := range m.TableState.AllServers {
+		switch server.Status {
+		case hcloud.ServerStatusRunning, hcloud.ServerStatusOff:
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// refreshDue is checked on every tick, the table is only fetched while it is shown
+func (m Model) refreshDue() bool {
+	state := m.TableState
+	return state.ShowTable && !state.TableReloadRunning && time.Since(state.LastFetch) >= m.refreshInterval()
+}
+
+// updateBackoff doubles the wait after a rate limit response and resets it after a successful fetch
+func (m *Model) updateBackoff(err error) {
+	state := &m.TableState
+	if err == nil {
+		state.Backoff = 0
+		return
+	}
+	if !hcloud.IsError(err, hcloud.ErrorCodeRateLimitExceeded) {
+		return
+	}
+	state.Backoff *= 2
+	if state.Backoff < backoffMin {
+		state.Backoff = backoffMin
+	}
+	if state.Backoff > backoffMax {
+		state.Backoff = backoffMax
+	}
+}
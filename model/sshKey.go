@@ -0,0 +1,194 @@
+package model
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/hetzner"
+	sshconnector "github.com/crabstars/liftoff/ssh"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+func (m *Model) showSshKeys() tea.Cmd {
+	m.SshKeyState.ShowSshKeys = true
+	m.SshKeyState.Mode = sshKeyModeList
+	m.SshKeyState.Loading = true
+	m.SshKeyState.LocalKeys, _ = sshconnector.LocalKeys()
+	m.loadSshKeyTable(nil)
+	return tea.Batch(m.Spinner.Tick, hetzner.LoadSshKeys(m.Ctx, m.EnvValues.HetznerApiKey))
+}
+
+func (m *Model) loadSshKeyTable(keys []*hcloud.SSHKey) {
+	columns := []table.Column{
+		{Title: "Name", Width: 24},
+		{Title: "Fingerprint", Width: 48},
+		{Title: "Private key", Width: 30},
+		{Title: "Created", Width: 10},
+	}
+
+	rows := make([]table.Row, len(keys))
+	for i, key := range keys {
+		privateKey := "-"
+		if local, found := sshconnector.FindLocalKey(m.SshKeyState.LocalKeys, key.Fingerprint); found && local.PrivatePath != "" {
+			privateKey = local.PrivatePath
+		}
+		if key.Name == m.EnvValues.SshKeyName {
+			privateKey += " (profile)"
+		}
+		rows[i] = table.Row{key.Name, key.Fingerprint, privateKey, key.Created.Format("2006-01-02")}
+	}
+
+	m.SshKeyState.Keys = keys
+	m.SshKeyState.KeyTable = newStyledTable(columns, rows, m.SshKeyState.KeyTable.Cursor())
+}
+
+func (m Model) selectedSshKey() *hcloud.SSHKey {
+	index := m.SshKeyState.KeyTable.Cursor()
+	if index < 0 || index >= len(m.SshKeyState.Keys) {
+		return nil
+	}
+	return m.SshKeyState.Keys[index]
+}
+
+// runSshKeyAction starts a ssh key call, the table gets reloaded after the SshKeyActionMsg
+func (m *Model) runSshKeyAction(cmd tea.Cmd) tea.Cmd {
+	m.SshKeyState.Mode = sshKeyModeList
+	m.SshKeyState.Loading = true
+	m.SshKeyState.Status = ""
+	return tea.Batch(m.Spinner.Tick, cmd)
+}
+
+// uploadableKeys are the local keys with private key which are not in the project yet
+func (m Model) uploadableKeys() []sshconnector.LocalKey {
+	uploaded := make(map[string]bool)
+	for _, key := range m.SshKeyState.Keys {
+		uploaded[key.Fingerprint] = true
+	}
+	var keys []sshconnector.LocalKey
+	for _, key := range m.SshKeyState.LocalKeys {
+		if !uploaded[key.Fingerprint] && key.PrivatePath != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (m Model) updateSshKeyState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var submitted, selected bool
+	state := &m.SshKeyState
+	apiKey := m.EnvValues.HetznerApiKey
+	sshKey := m.selectedSshKey()
+
+	if state.Mode != sshKeyModeList && msg.Type == tea.KeyEsc {
+		state.Mode = sshKeyModeList
+		return m, nil
+	}
+
+	switch state.Mode {
+	case sshKeyModeGenerate:
+		state.Form, cmd, submitted = state.Form.Update(msg)
+		if !submitted {
+			return m, cmd
+		}
+		if state.Form.Value(0) == "" || state.Form.Value(1) == "" {
+			state.Form.Err = "name and path are required"
+			return m, nil
+		}
+		local, err := sshconnector.GenerateKey(state.Form.Value(1), state.Form.Value(0))
+		if err != nil {
+			state.Form.Err = err.Error()
+			return m, nil
+		}
+		state.LocalKeys = append(state.LocalKeys, local)
+		return m, m.runSshKeyAction(hetzner.CreateSshKey(m.Ctx, apiKey, state.Form.Value(0), local.PublicKey))
+
+	case sshKeyModeUpload:
+		state.Choice, selected = state.Choice.Update(msg.String())
+		keys := m.uploadableKeys()
+		if !selected || len(keys) == 0 {
+			return m, nil
+		}
+		local := keys[state.Choice.Cursor]
+		hostname, _ := os.Hostname()
+		return m, m.runSshKeyAction(hetzner.CreateSshKey(m.Ctx, apiKey, fmt.Sprintf("%s-%s", hostname, local.Name), local.PublicKey))
+
+	case sshKeyModeDelete:
+		switch msg.String() {
+		case "y":
+			if sshKey != nil {
+				return m, m.runSshKeyAction(hetzner.DeleteSshKey(m.Ctx, apiKey, sshKey.ID, sshKey.Name))
+			}
+			state.Mode = sshKeyModeList
+		case "n":
+			state.Mode = sshKeyModeList
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		state.ShowSshKeys = false
+		return m, nil
+	case "g":
+		hostname, _ := os.Hostname()
+		state.Mode = sshKeyModeGenerate
+		state.Form = newForm("Generate an ed25519 key and upload the public key",
+			formField{Label: "Name", Value: hostname + "-liftoff"},
+			formField{Label: "Path of the private key, the public key gets .pub appended", Value: sshconnector.DefaultKeyPath},
+		)
+		return m, nil
+	case "u":
+		state.Mode = sshKeyModeUpload
+		var choices []string
+		for _, key := range m.uploadableKeys() {
+			choices = append(choices, fmt.Sprintf("%s (%s)", key.PublicPath, key.Fingerprint))
+		}
+		state.Choice = newChoiceList("Upload a public key of ~/.ssh", choices...)
+		return m, nil
+	case "d":
+		if sshKey != nil {
+			state.Mode = sshKeyModeDelete
+		}
+		return m, nil
+	}
+
+	state.KeyTable, cmd = state.KeyTable.Update(msg)
+	return m, cmd
+}
+
+func (m *Model) setSshKeys(msg hetzner.SshKeysLoadedMsg) {
+	m.SshKeyState.Loading = false
+	if msg.Err != nil {
+		m.SshKeyState.Status = fmt.Sprintf("could not load ssh keys: %s", msg.Err)
+		return
+	}
+	m.loadSshKeyTable(msg.Keys)
+}
+
+func (m Model) ViewSshKeys() string {
+	state := m.SshKeyState
+	switch state.Mode {
+	case sshKeyModeGenerate:
+		return state.Form.View()
+	case sshKeyModeUpload:
+		return state.Choice.View()
+	}
+
+	s := baseStyle.Render(state.KeyTable.View()) + "\n"
+	s += " g generate • u upload • d delete • esc back\n"
+	if state.Loading {
+		s += fmt.Sprintf("\n %s working...\n", m.Spinner.View())
+	}
+	if state.Status != "" {
+		s += "\n " + state.Status + "\n"
+	}
+	if state.Mode == sshKeyModeDelete {
+		if sshKey := m.selectedSshKey(); sshKey != nil {
+			s = PlaceOverlay(80, 5, fmt.Sprintf("Delete ssh key %s?\nServers created with it keep the key.\n\nPress 'y' to confirm, 'n' to cancel.", sshKey.Name), s)
+		}
+	}
+	return s
+}
@@ -3,25 +3,224 @@ package model
 import (
 	"fmt"
 	"log"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/crabstars/liftoff/hetzner"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 )
 
-func (m *Model) loadTableWithoutFetch(rows []table.Row) {
-	columns := []table.Column{
-		{Title: "Name", Width: 30},
-		{Title: "Image", Width: 20},
-		{Title: "Status", Width: 15},
-		{Title: "Datacenter", Width: 10},
-		{Title: "CPU Type", Width: 8},
-		{Title: "Server Type", Width: 15},
-		{Title: "Cores", Width: 10},
-		{Title: "Memory", Width: 10},
-		{Title: "Disk", Width: 10},
+// tableColumns fits the columns into the terminal width, the first column shows the selection mark
+func tableColumns(columns []serverColumn, width int, sortColumn int, sortDesc bool) []table.Column {
+	result := make([]table.Column, 0, len(columns)+1)
+	result = append(result, table.Column{Title: "✓", Width: 1})
+
+	// every column has a padding of one on both sides, the border takes two more
+	used := 2 + 3
+	flexCount := 0
+	for _, column := range columns {
+		used += column.Width + 2
+		if column.Flex {
+			flexCount++
+		}
+	}
+	extra := 0
+	if width > used && flexCount > 0 {
+		extra = (width - used) / flexCount
+	}
+
+	for i, column := range columns {
+		title := column.Title
+		if i == sortColumn {
+			if sortDesc {
+				title += " ↓"
+			} else {
+				title += " ↑"
+			}
+		}
+		columnWidth := column.Width
+		if column.Flex {
+			columnWidth += extra
+		}
+		if len(title) > columnWidth {
+			columnWidth = len(title)
+		}
+		result = append(result, table.Column{Title: title, Width: columnWidth})
+	}
+	return result
+}
+
+// refreshServerTable filters and sorts the fetched servers without calling the api.
+// The cursor stays on the same server and unchanged rows are not replaced
+func (m *Model) refreshServerTable() {
+	state := &m.TableState
+	var selectedID int64
+	if server := m.selectedServer(); server != nil {
+		selectedID = server.ID
+	}
+	var servers []*hcloud.Server
+	for _, server := range state.AllServers {
+		if m.serverVisible(server) {
+			servers = append(servers, server)
+		}
+	}
+	columns := m.visibleColumns()
+	if state.SortColumn >= 0 && state.SortColumn < len(columns) {
+		column := columns[state.SortColumn]
+		less := column.Less
+		if less == nil {
+			less = func(a, b *hcloud.Server) bool {
+				return strings.ToLower(column.Value(a)) < strings.ToLower(column.Value(b))
+			}
+		}
+		sort.SliceStable(servers, func(i, j int) bool {
+			if state.SortDesc {
+				return less(servers[j], servers[i])
+			}
+			return less(servers[i], servers[j])
+		})
+	}
+
+	visible := make(map[int64]bool, len(servers))
+	rows := make([]table.Row, len(servers))
+	ids := make([]int64, len(servers))
+	for i, server := range servers {
+		visible[server.ID] = true
+		mark := ""
+		if state.Selected[server.ID] {
+			mark = "✓"
+		}
+		row := table.Row{mark}
+		for _, column := range columns {
+			row = append(row, column.Value(server))
+		}
+		rows[i] = row
+		ids[i] = server.ID
+	}
+	// hidden servers can not be part of a bulk action
+	for id := range state.Selected {
+		if !visible[id] {
+			delete(state.Selected, id)
+		}
+	}
+
+	cursor := state.ServerTable.Cursor()
+	for i, id := range ids {
+		if id == selectedID {
+			cursor = i
+		}
+	}
+	state.Servers = servers
+	state.ServerIdIndexRelations = ids
+	state.RowCursor = cursor
+
+	tableColumns := tableColumns(columns, m.Width, state.SortColumn, state.SortDesc)
+	if len(state.ServerTable.Columns()) == 0 {
+		state.ServerTable = newStyledTable(tableColumns, rows, cursor)
+	} else {
+		if !equalColumns(state.ServerTable.Columns(), tableColumns) {
+			state.ServerTable.SetColumns(tableColumns)
+		}
+		if !equalRows(state.ServerTable.Rows(), rows) {
+			state.ServerTable.SetRows(rows)
+		}
+		state.ServerTable.SetCursor(max(cursor, 0))
+	}
+	if m.Height > 0 {
+		// leaves room for the help, filter and status lines
+		state.ServerTable.SetHeight(max(m.Height-14, 3))
+	}
+}
+
+func equalColumns(a []table.Column, b []table.Column) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalRows(a []table.Row, b []table.Row) bool {
+	if len(a) != len(b) {
+		return false
 	}
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+		for j := range a[i] {
+			if a[i][j] != b[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
 
+// serverVisible applies the search and the status, location and type filters, labels are filtered by the api
+func (m Model) serverVisible(server *hcloud.Server) bool {
+	state := m.TableState
+	if state.Search != "" && !strings.Contains(strings.ToLower(server.Name), strings.ToLower(state.Search)) {
+		return false
+	}
+	if state.StatusFilter != "" && !strings.EqualFold(string(server.Status), state.StatusFilter) {
+		return false
+	}
+	if state.LocationFilter != "" {
+		location := server.Datacenter.Location
+		if !strings.EqualFold(location.Name, state.LocationFilter) && !strings.EqualFold(location.City, state.LocationFilter) {
+			return false
+		}
+	}
+	if state.TypeFilter != "" && !strings.EqualFold(server.ServerType.Name, state.TypeFilter) {
+		return false
+	}
+	return true
+}
+
+// filterText describes the active search, filters and sorting, empty if the table shows all servers in api order
+func (m Model) filterText() string {
+	state := m.TableState
+	var parts []string
+	for _, filter := range []struct{ name, value string }{
+		{"search", state.Search},
+		{"status", state.StatusFilter},
+		{"location", state.LocationFilter},
+		{"type", state.TypeFilter},
+		{"labels", state.LabelSelector},
+	} {
+		if filter.value != "" {
+			parts = append(parts, fmt.Sprintf("%s=%s", filter.name, filter.value))
+		}
+	}
+	if columns := m.visibleColumns(); state.SortColumn >= 0 && state.SortColumn < len(columns) {
+		order := "asc"
+		if state.SortDesc {
+			order = "desc"
+		}
+		parts = append(parts, fmt.Sprintf("sort=%s %s", columns[state.SortColumn].Title, order))
+	}
+	return strings.Join(parts, " • ")
+}
+
+// nextSortColumn cycles through all columns and back to the api order
+func (m *Model) nextSortColumn() {
+	m.TableState.SortColumn++
+	if m.TableState.SortColumn >= len(m.TableState.Columns) {
+		m.TableState.SortColumn = -1
+	}
+	m.refreshServerTable()
+}
+
+// newStyledTable creates a focused table with the liftoff styles and keeps the cursor inside the rows
+func newStyledTable(columns []table.Column, rows []table.Row, cursor int) table.Model {
 	t := table.New(
 		table.WithColumns(columns),
 		table.WithRows(rows),
@@ -41,26 +240,37 @@ func (m *Model) loadTableWithoutFetch(rows []table.Row) {
 		Bold(false)
 	t.SetStyles(s)
 
-	if len(rows)-1 < m.TableState.RowCursor {
+	if len(rows)-1 < cursor {
 		t.SetCursor(len(rows) - 1)
 	} else {
-		t.SetCursor(m.TableState.RowCursor)
+		t.SetCursor(cursor)
 	}
-
-	m.TableState.ServerTable = t
+	return t
 }
 
 func (m *Model) fetchTableRows() {
-	servers, err := hetzner.ListServer(m.EnvValues.HetznerApiKey)
+	listedAt := time.Now()
+	servers, err := hetzner.ListServer(m.Ctx, m.EnvValues.HetznerApiKey, m.TableState.LabelSelector)
 	if err != nil {
 		log.Println("Failed to load server", err.Error())
 	}
-	rows := make([]table.Row, len(servers))
-	serverIndexIdRelations := make([]int64, len(servers))
-	for i, server := range servers {
-		rows[i] = table.Row{server.Name, server.Image.Name, string(server.Status), server.Datacenter.Location.City, string(server.ServerType.CPUType), server.ServerType.Name, fmt.Sprintf("%d", server.ServerType.Cores), fmt.Sprintf("%.0f GB", server.ServerType.Memory), fmt.Sprintf("%d GB", server.ServerType.Disk)}
-		serverIndexIdRelations[i] = server.ID
+	// only the unfiltered list shows which servers were deleted outside of liftoff
+	if err == nil && m.TableState.LabelSelector == "" {
+		hetzner.RecordMissingServers(m.ProfileName, servers, listedAt)
+	}
+	m.Program.Send(TableUpdateMsg{servers, err})
+}
+
+func imageName(server *hcloud.Server) string {
+	if server.Image == nil {
+		return ""
 	}
+	return server.Image.Name
+}
 
-	m.Program.Send(TableUpdateMsg{rows, serverIndexIdRelations})
+func protectionText(server *hcloud.Server) string {
+	if server.Protection.Delete {
+		return "yes"
+	}
+	return ""
 }
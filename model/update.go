@@ -1,13 +1,13 @@
 package model
 
 import (
+	"fmt"
 	"log"
 	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/crabstars/liftoff/hetzner"
-	"github.com/crabstars/liftoff/internal"
 )
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -16,46 +16,348 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 
 	case TableUpdateMsg:
-		m.loadTableWithoutFetch(msg.rows)
-		m.TableState.ServerIdIndexRelations = msg.ids
 		m.TableState.TableReloadRunning = false
+		m.TableState.LoadError = ""
+		m.updateBackoff(msg.err)
+		if m.TableState.RefreshPending {
+			m.requestRefresh()
+		}
+		if msg.err != nil {
+			// keeps the last servers instead of an empty table
+			m.TableState.LoadError = fmt.Sprintf("could not load servers: %s", msg.err)
+		} else {
+			m.TableState.AllServers = msg.servers
+			m.refreshServerTable()
+		}
+
+	case tea.WindowSizeMsg:
+		m.Width = msg.Width
+		m.Height = msg.Height
+		m.refreshServerTable()
+
+	case ServerDeleteProgressMsg:
+		if _, ok := m.TableState.Deleting[msg.ServerID]; ok {
+			m.TableState.Deleting[msg.ServerID] = msg.Progress
+		}
+
+	case hetzner.ServerDeletedSuccessMsg:
+		m.TableState.Status = fmt.Sprintf("%s deleted", m.TableState.DeletingNames[msg.ServerID])
+		delete(m.TableState.DeleteCancel, msg.ServerID)
+		delete(m.TableState.Deleting, msg.ServerID)
+		delete(m.TableState.DeletingNames, msg.ServerID)
+		m.removeServerRow(msg.ServerID)
+
+	case hetzner.ServerDeletedErrorMsg:
+		m.TableState.Status = errorStyle.Render(fmt.Sprintf("deleting %s failed: %s", m.TableState.DeletingNames[msg.ServerID], msg.Err))
+		delete(m.TableState.DeleteCancel, msg.ServerID)
+		delete(m.TableState.Deleting, msg.ServerID)
+		delete(m.TableState.DeletingNames, msg.ServerID)
+
+	case hetzner.ServerProtectionChangedMsg:
+		m.TableState.InFlight--
+		if msg.Err != nil {
+			m.TableState.Status = errorStyle.Render(fmt.Sprintf("protection could not be changed: %s", msg.Err))
+		} else if msg.Enabled {
+			m.TableState.Status = "delete and rebuild protection enabled"
+		} else {
+			m.TableState.Status = "delete and rebuild protection disabled"
+		}
+		m.requestRefresh()
+
+	case hetzner.ServerLabelsUpdatedMsg:
+		m.TableState.InFlight--
+		if msg.Err != nil {
+			m.TableState.Status = errorStyle.Render(fmt.Sprintf("labels could not be updated: %s", msg.Err))
+		} else {
+			m.TableState.Status = "labels updated"
+			m.requestRefresh()
+		}
 
 	case TickMsg:
-		if m.TableState.ShowTable && !m.TableState.TableReloadRunning {
-			m.TableState.TableReloadRunning = true
-			go m.fetchTableRows()
+		m.Activity = hetzner.Tracker.Snapshot()
+		if m.refreshDue() {
+			m.requestRefresh()
+		}
+		if m.Profile.MonthlyBudget > 0 && time.Since(m.BudgetFetched) >= budgetRefresh {
+			m.BudgetFetched = time.Now()
+			return m, tea.Batch(tickEvery(refreshCheck), hetzner.LoadBudget(m.Ctx, m.EnvValues.HetznerApiKey, m.Profile.MonthlyBudget))
 		}
-		return m, tickEvery(time.Second * 3)
+		// keeps the target health up to date
+		if m.LoadBalancerState.ShowLoadBalancers && !m.LoadBalancerState.Loading && time.Since(m.LoadBalancerState.LastFetch) >= refreshFast*2 {
+			m.LoadBalancerState.LastFetch = time.Now()
+			return m, tea.Batch(tickEvery(refreshCheck), hetzner.LoadLoadBalancers(m.Ctx, m.EnvValues.HetznerApiKey))
+		}
+		return m, tickEvery(refreshCheck)
+
+	case hetzner.VolumesLoadedMsg:
+		m.VolumeState.Loading = false
+		if msg.Err != nil {
+			m.VolumeState.Status = fmt.Sprintf("could not load volumes: %s", msg.Err)
+		} else {
+			m.loadVolumeTable(msg.Volumes)
+		}
+		m.setWizardVolumes(msg)
+
+	case hetzner.VolumeActionMsg:
+		if m.VolumeState.OverBudget.blockedBy(msg.Err) {
+			m.VolumeState.Loading = false
+			m.VolumeState.Mode = volumeModeOverBudget
+			return m, nil
+		}
+		if msg.Err != nil {
+			m.VolumeState.Loading = false
+			m.VolumeState.Status = errorStyle.Render(fmt.Sprintf("%s failed: %s", msg.Description, msg.Err))
+			return m, nil
+		}
+		m.VolumeState.Status = msg.Description
+		return m, hetzner.LoadVolumes(m.Ctx, m.EnvValues.HetznerApiKey)
+
+	case hetzner.FirewallsLoadedMsg:
+		m.FirewallState.Loading = false
+		if msg.Err != nil {
+			m.FirewallState.Status = fmt.Sprintf("could not load firewalls: %s", msg.Err)
+		} else {
+			m.loadFirewallTable(msg.Firewalls)
+		}
+		m.setWizardFirewalls(msg)
+
+	case hetzner.FirewallActionMsg:
+		if msg.Err != nil {
+			m.FirewallState.Loading = false
+			m.FirewallState.Status = errorStyle.Render(fmt.Sprintf("%s failed: %s", msg.Description, msg.Err))
+			return m, nil
+		}
+		m.FirewallState.Status = msg.Description
+		return m, hetzner.LoadFirewalls(m.Ctx, m.EnvValues.HetznerApiKey)
+
+	case hetzner.NetworksLoadedMsg:
+		m.NetworkState.Loading = false
+		if msg.Err != nil {
+			m.NetworkState.Status = fmt.Sprintf("could not load networks: %s", msg.Err)
+		} else {
+			m.loadNetworkTable(msg.Networks)
+		}
+		m.setWizardNetworks(msg)
+
+	case hetzner.NetworkActionMsg:
+		if msg.Err != nil {
+			m.NetworkState.Loading = false
+			m.NetworkState.Status = errorStyle.Render(fmt.Sprintf("%s failed: %s", msg.Description, msg.Err))
+			return m, nil
+		}
+		m.NetworkState.Status = msg.Description
+		return m, tea.Batch(hetzner.LoadNetworks(m.Ctx, m.EnvValues.HetznerApiKey), hetzner.LoadServers(m.Ctx, m.EnvValues.HetznerApiKey))
+
+	case hetzner.IPsLoadedMsg:
+		m.IPState.Loading = false
+		if msg.Err != nil {
+			m.IPState.Status = fmt.Sprintf("could not load ips: %s", msg.Err)
+		} else {
+			m.loadIPTable(msg.IPs)
+		}
+		m.setWizardPrimaryIPs(msg)
+
+	case hetzner.IPActionMsg:
+		if m.IPState.OverBudget.blockedBy(msg.Err) {
+			m.IPState.Loading = false
+			m.IPState.Mode = ipModeOverBudget
+			return m, nil
+		}
+		if msg.Err != nil {
+			m.IPState.Loading = false
+			m.IPState.Status = errorStyle.Render(fmt.Sprintf("%s failed: %s", msg.Description, msg.Err))
+			return m, nil
+		}
+		m.IPState.Status = msg.Description
+		return m, hetzner.LoadIPs(m.Ctx, m.EnvValues.HetznerApiKey)
+
+	case hetzner.PlacementGroupsLoadedMsg:
+		m.PlacementGroupState.Loading = false
+		if msg.Err != nil {
+			m.PlacementGroupState.Status = fmt.Sprintf("could not load placement groups: %s", msg.Err)
+		} else {
+			m.loadPlacementGroupTable(msg.PlacementGroups)
+		}
+		m.setWizardPlacementGroups(msg)
+
+	case hetzner.PlacementGroupActionMsg:
+		if msg.Err != nil {
+			m.PlacementGroupState.Loading = false
+			m.PlacementGroupState.Status = errorStyle.Render(fmt.Sprintf("%s failed: %s", msg.Description, msg.Err))
+			return m, nil
+		}
+		m.PlacementGroupState.Status = msg.Description
+		return m, tea.Batch(hetzner.LoadPlacementGroups(m.Ctx, m.EnvValues.HetznerApiKey), hetzner.LoadServers(m.Ctx, m.EnvValues.HetznerApiKey))
+
+	case hetzner.LoadBalancersLoadedMsg:
+		m.LoadBalancerState.Loading = false
+		if msg.Err != nil {
+			m.LoadBalancerState.Status = fmt.Sprintf("could not load load balancers: %s", msg.Err)
+		} else {
+			m.loadLoadBalancerTable(msg.LoadBalancers)
+		}
+
+	case hetzner.SshKeysLoadedMsg:
+		m.setSshKeys(msg)
+		m.setWizardSshKeys(msg)
+
+	case hetzner.CostEstimateMsg:
+		m.setWizardEstimate(msg)
+
+	case hetzner.CostsLoadedMsg:
+		m.setCosts(msg)
+		if msg.Err == nil && m.Profile.MonthlyBudget > 0 {
+			m.Budget = hetzner.BudgetOf(msg.Items, msg.Currency, m.Profile.MonthlyBudget)
+			m.BudgetFetched = time.Now()
+		}
+
+	case hetzner.BudgetLoadedMsg:
+		if msg.Err != nil {
+			log.Println("could not load the budget", msg.Err)
+		} else {
+			m.Budget = msg.Budget
+		}
+
+	case hetzner.SshKeyActionMsg:
+		if msg.Err != nil {
+			m.SshKeyState.Loading = false
+			m.SshKeyState.Status = errorStyle.Render(fmt.Sprintf("%s failed: %s", msg.Description, msg.Err))
+			return m, nil
+		}
+		m.SshKeyState.Status = msg.Description
+		return m, hetzner.LoadSshKeys(m.Ctx, m.EnvValues.HetznerApiKey)
+
+	case hetzner.LoadBalancerActionMsg:
+		if m.LoadBalancerState.OverBudget.blockedBy(msg.Err) {
+			m.LoadBalancerState.Loading = false
+			m.LoadBalancerState.Mode = loadBalancerModeOverBudget
+			return m, nil
+		}
+		if msg.Err != nil {
+			m.LoadBalancerState.Loading = false
+			m.LoadBalancerState.Status = errorStyle.Render(fmt.Sprintf("%s failed: %s", msg.Description, msg.Err))
+			return m, nil
+		}
+		m.LoadBalancerState.Status = msg.Description
+		return m, hetzner.LoadLoadBalancers(m.Ctx, m.EnvValues.HetznerApiKey)
+
+	case BulkProgressMsg:
+		m.setBulkProgress(msg)
+
+	case hetzner.BulkActionDoneMsg:
+		m.finishBulkAction(msg)
+
+	case FleetProgressMsg:
+		m.setFleetProgress(msg)
+
+	case hetzner.FleetCreatedMsg:
+		m.finishFleet(msg)
+
+	case hetzner.ServersLoadedMsg:
+		m.setVolumeServers(msg)
+		m.setFirewallServers(msg)
+		m.setNetworkServers(msg)
+		m.setIPServers(msg)
+		m.setPlacementGroupServers(msg)
+		m.setLoadBalancerServers(msg)
 
 	case tea.KeyMsg:
 		keyStroke := msg.String()
 
-		if keyStroke == "q" || keyStroke == "ctrl+c" {
+		if keyStroke == "ctrl+c" || (keyStroke == "q" && !m.textInputActive()) {
+			m.Cancel()
 			return m, tea.Quit
 		}
 
 		if m.CreateServerState.CreatingServer {
+			if keyStroke == "x" && m.CreateServerState.Cancel != nil {
+				m.CreateServerState.Cancel()
+				m.CreateServerState.Cancel = nil
+			}
 			return m, nil
 		}
 
-		if m.CreateServerState.WaitingForServerNameInput {
-			if !m.CreateServerState.ServerNameInput.Focused() {
-				m.CreateServerState.ServerNameInput.Focus()
+		if len(m.CreateServerState.Fleet) > 0 {
+			if msg.Type == tea.KeyEnter || msg.Type == tea.KeyEsc {
+				m.CreateServerState.Fleet = nil
+				m.CreateServerState.FleetResult = ""
 			}
-			switch msg.Type {
-			case tea.KeyCtrlC, tea.KeyEsc:
-				m.CreateServerState.WaitingForServerNameInput = false
-				return m, nil
-			case tea.KeyEnter:
-				m.CreateServerState.WaitingForServerNameInput = false
-				m.CreateServerState.CreatingServer = true
+			return m, nil
+		}
+
+		if m.CreateServerState.Step != createStepNone {
+			return m.updateCreateServerState(msg)
+		}
 
-				return m, tea.Batch(m.Spinner.Tick, hetzner.CreateServer(m.EnvValues.HetznerApiKey, m.EnvValues.SshKeyName, m.CreateServerState.ServerNameInput.Value()))
+		if m.VolumeState.ShowVolumes {
+			return m.updateVolumeState(msg)
+		}
+
+		if m.FirewallState.ShowFirewalls {
+			return m.updateFirewallState(msg)
+		}
+
+		if m.NetworkState.ShowNetworks {
+			return m.updateNetworkState(msg)
+		}
+
+		if m.IPState.ShowIPs {
+			return m.updateIPState(msg)
+		}
+
+		if m.ProfileState.ShowProfiles {
+			return m.updateProfileState(msg)
+		}
+
+		if m.PlacementGroupState.ShowPlacementGroups {
+			return m.updatePlacementGroupState(msg)
+		}
+
+		if m.LoadBalancerState.ShowLoadBalancers {
+			return m.updateLoadBalancerState(msg)
+		}
+
+		if m.SshKeyState.ShowSshKeys {
+			return m.updateSshKeyState(msg)
+		}
+
+		if m.InventoryState.ShowInventory {
+			return m.updateInventoryState(msg)
+		}
+
+		if m.AuditState.ShowAudit {
+			return m.updateAuditState(msg)
+		}
+
+		if m.CostState.ShowCosts {
+			return m.updateCostState(msg)
+		}
+
+		if m.TableState.ShowTable && m.TableState.ShowDetail {
+			if msg.Type == tea.KeyEsc {
+				m.TableState.ShowDetail = false
 			}
-			m.CreateServerState.ServerNameInput, cmd = m.CreateServerState.ServerNameInput.Update(msg)
+			return m, nil
+		}
 
-			return m, cmd
+		if m.TableState.ShowTable && m.TableState.EditingColumns {
+			return m.updateColumnEditor(msg)
+		}
 
+		if m.TableState.ShowTable && m.TableState.Searching {
+			return m.updateSearch(msg)
+		}
+
+		if m.TableState.ShowTable && m.TableState.BulkMode != bulkModeNone {
+			return m.updateBulkState(msg)
+		}
+
+		if m.TableState.ShowTable && m.TableState.LabelMode != labelModeNone {
+			return m.updateLabelState(msg)
+		}
+
+		if m.TableState.ShowTable && m.TableState.ShowOverlay {
+			return m.updateDeleteConfirm(msg)
 		}
 
 		if m.TableState.ShowTable {
@@ -64,34 +366,56 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.TableState.ShowOverlay = false
 				m.TableState.ShowTable = false
 			case "q", "ctrl+c":
+				m.Cancel()
 				return m, tea.Quit
+			case "x":
+				m.cancelDeletes()
+				return m, nil
 			case "enter":
-				return m, tea.Batch(
-					tea.Printf("Let's go to %s!", m.TableState.ServerTable.SelectedRow()[1]),
-				)
+				m.TableState.ShowDetail = m.selectedServer() != nil
+				return m, nil
 			case "d":
-				m.TableState.ShowOverlay = true
-				return m, nil
-			case "y":
-				if m.TableState.ShowOverlay {
-					index := m.TableState.RowCursor
-					if len(m.TableState.ServerTable.Rows()) > 0 && index >= 0 {
-						err := hetzner.DeleteServer2(m.EnvValues.HetznerApiKey, m.TableState.ServerIdIndexRelations[index])
-						if err != nil {
-							log.Println("error while deleting", err)
-						} else {
-							rows := m.TableState.ServerTable.Rows()
-							rows = internal.DeleteElementAt(rows, index)
-							m.TableState.ServerIdIndexRelations = internal.DeleteElementAt(m.TableState.ServerIdIndexRelations, index)
-							m.TableState.ServerTable.SetRows(rows)
-						}
-					}
-					m.TableState.ShowOverlay = false
-				}
-			case "n":
-				if m.TableState.ShowOverlay {
-					m.TableState.ShowOverlay = false
+				m.startDeleteConfirm()
+				return m, nil
+			case "p":
+				if server := m.selectedServer(); server != nil {
+					enabled := !server.Protection.Delete
+					m.TableState.Status = fmt.Sprintf("changing protection of %s...", server.Name)
+					m.TableState.InFlight++
+					return m, hetzner.SetServerProtection(m.Ctx, m.EnvValues.HetznerApiKey, server.ID, enabled)
 				}
+				return m, nil
+			case "l":
+				m.startLabelEdit()
+				return m, nil
+			case "f":
+				m.startFilter()
+				return m, nil
+			case "/":
+				m.startSearch()
+				return m, nil
+			case "c":
+				m.startColumnEditor()
+				return m, nil
+			case "o":
+				m.nextSortColumn()
+				return m, nil
+			case "O":
+				m.TableState.SortDesc = !m.TableState.SortDesc
+				m.refreshServerTable()
+				return m, nil
+			case " ":
+				m.toggleSelection()
+				return m, nil
+			case "a":
+				m.toggleSelectAll()
+				return m, nil
+			case "s":
+				m.startBulkFilter()
+				return m, nil
+			case "b":
+				m.startBulkMenu()
+				return m, nil
 			}
 			m.TableState.ServerTable, cmd = m.TableState.ServerTable.Update(msg)
 			m.TableState.RowCursor = m.TableState.ServerTable.Cursor()
@@ -114,32 +438,79 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case 0:
 				log.Printf("Showing Server")
 				m.TableState.ShowTable = true
-				go m.fetchTableRows()
+				m.requestRefresh()
 			case 1:
-				m.CreateServerState.WaitingForServerNameInput = true
+				m.startCreateWizard()
 				log.Printf("Waiting for name input")
 				return m, nil
 			case 2:
-				log.Printf("Delete Server")
+				log.Printf("Showing Volumes")
+				return m, m.showVolumes()
+			case 3:
+				log.Printf("Showing Firewalls")
+				return m, m.showFirewalls()
+			case 4:
+				log.Printf("Showing Networks")
+				return m, m.showNetworks()
+			case 5:
+				log.Printf("Showing IPs")
+				return m, m.showIPs()
+			case 6:
+				log.Printf("Showing Placement groups")
+				return m, m.showPlacementGroups()
+			case 7:
+				log.Printf("Showing Load balancers")
+				return m, m.showLoadBalancers()
+			case 8:
+				log.Printf("Showing SSH keys")
+				return m, m.showSshKeys()
+			case 9:
+				log.Printf("Showing Inventory")
+				m.showInventory()
+				return m, nil
+			case 10:
+				log.Printf("Showing Audit log")
+				m.showAudit()
+				return m, nil
+			case 11:
+				log.Printf("Showing Costs")
+				return m, m.showCosts()
+			case 12:
+				log.Printf("Showing Profiles")
+				m.showProfiles()
+				return m, nil
 			default:
 
 				log.Printf("Choice not found")
 			}
 
 		}
+	case hetzner.OperationCancelledMsg:
+		m.CreateServerState.ServerNameInput.Reset()
+		m.CreateServerState.CreatingServer = false
+		m.CreateServerState.Cancel = nil
+		m.CreateServerState.Status = errorStyle.Render(fmt.Sprintf("%s cancelled: %s", msg.Operation, msg.State))
+		log.Printf("%s cancelled: %s", msg.Operation, msg.State)
+
 	case string:
 		if msg == hetzner.SERVER_CREATED_SUCCESS {
 			m.CreateServerState.ServerNameInput.Reset()
 			m.CreateServerState.CreatingServer = false
+			m.CreateServerState.Cancel = nil
+			m.CreateServerState.Status = "server created"
+			// the banner picks up the new server with the next tick
+			m.BudgetFetched = time.Time{}
 			log.Printf("Server created successfully")
 		} else if msg == hetzner.SERVER_CREATED_Failed {
 			m.CreateServerState.ServerNameInput.Reset()
 			m.CreateServerState.CreatingServer = false
+			m.CreateServerState.Cancel = nil
+			m.CreateServerState.Status = errorStyle.Render("server creation failed, see the log")
 			log.Printf("Server creation failed")
 		}
 
 	case spinner.TickMsg:
-		if m.CreateServerState.CreatingServer {
+		if m.CreateServerState.CreatingServer || len(m.TableState.Deleting) > 0 || m.TableState.BulkRunning || m.VolumeState.Loading || m.FirewallState.Loading || m.NetworkState.Loading || m.IPState.Loading || m.PlacementGroupState.Loading || m.LoadBalancerState.Loading || m.SshKeyState.Loading || m.CreateServerState.EstimateLoading || m.CostState.Loading || hetzner.Tracker.RunningCount() > 0 {
 			var cmd tea.Cmd
 			m.Spinner, cmd = m.Spinner.Update(msg)
 			return m, cmd
@@ -148,3 +519,51 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	return m, nil
 }
+
+// textInputActive is true while the user types into an input, keys like q must not quit then
+func (m Model) textInputActive() bool {
+	switch m.CreateServerState.Step {
+	case createStepName, createStepCount, createStepNewVolume, createStepNewFirewall, createStepNetworkIP, createStepLabels:
+		return true
+	}
+	if m.TableState.ShowTable && (m.TableState.Searching || m.TableState.LabelMode != labelModeNone || m.TableState.ShowOverlay || m.TableState.BulkMode == bulkModeFilter || m.TableState.BulkMode == bulkModeLabels) {
+		return true
+	}
+	if m.VolumeState.ShowVolumes && (m.VolumeState.Mode == volumeModeCreate || m.VolumeState.Mode == volumeModeResize) {
+		return true
+	}
+	if m.FirewallState.ShowFirewalls {
+		switch m.FirewallState.Mode {
+		case firewallModeCreate, firewallModeAddRule, firewallModeApplyLabel:
+			return true
+		}
+	}
+	if m.NetworkState.ShowNetworks {
+		switch m.NetworkState.Mode {
+		case networkModeCreate, networkModeAddSubnet, networkModeAttachIP:
+			return true
+		}
+	}
+	if m.PlacementGroupState.ShowPlacementGroups && m.PlacementGroupState.Mode == placementGroupModeCreate {
+		return true
+	}
+	if m.LoadBalancerState.ShowLoadBalancers {
+		switch m.LoadBalancerState.Mode {
+		case loadBalancerModeCreate, loadBalancerModeAddLabelSelector, loadBalancerModeAddService:
+			return true
+		}
+	}
+	if m.SshKeyState.ShowSshKeys && m.SshKeyState.Mode == sshKeyModeGenerate {
+		return true
+	}
+	if m.IPState.ShowIPs && (m.IPState.Mode == ipModeAllocate || m.IPState.Mode == ipModeReverseDNS) {
+		return true
+	}
+	if m.AuditState.ShowAudit && m.AuditState.Filtering {
+		return true
+	}
+	if m.CostState.ShowCosts && m.CostState.EditingGroup {
+		return true
+	}
+	return false
+}
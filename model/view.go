@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
 	"strings"
+
+	"github.com/crabstars/liftoff/hetzner"
 )
 
 func (m Model) ViewState() string {
@@ -15,7 +17,7 @@ func (m Model) ViewState() string {
 	builder.WriteString(fmt.Sprintf("    Current Frame: %s\n", m.Spinner.View()))
 
 	builder.WriteString("  CreateServerState:\n")
-	builder.WriteString(fmt.Sprintf("    WaitingForServerNameInput: %v\n", m.CreateServerState.WaitingForServerNameInput))
+	builder.WriteString(fmt.Sprintf("    Step: %d\n", m.CreateServerState.Step))
 	builder.WriteString(fmt.Sprintf("    ServerNameInput: %s\n", m.CreateServerState.ServerNameInput.Value()))
 	builder.WriteString(fmt.Sprintf("    CreatingServer: %v\n", m.CreateServerState.CreatingServer))
 
@@ -25,10 +27,59 @@ func (m Model) ViewState() string {
 
 	builder.WriteString("  TableState:\n")
 	builder.WriteString(fmt.Sprintf("    TableReloadRunning: %v\n", m.TableState.TableReloadRunning))
+	builder.WriteString(fmt.Sprintf("    RefreshInterval: %s\n", m.refreshInterval()))
 	builder.WriteString(fmt.Sprintf("    ShowTable: %v\n", m.TableState.ShowTable))
+	builder.WriteString(fmt.Sprintf("    ShowDetail: %v\n", m.TableState.ShowDetail))
 	builder.WriteString(fmt.Sprintf("    RowCursor: %d\n", m.TableState.RowCursor))
 	builder.WriteString(fmt.Sprintf("    ServerIds: %v\n", m.TableState.ServerIdIndexRelations))
 
+	builder.WriteString(fmt.Sprintf("    RunningActions: %d\n", hetzner.Tracker.RunningCount()))
+
+	builder.WriteString("  VolumeState:\n")
+	builder.WriteString(fmt.Sprintf("    ShowVolumes: %v\n", m.VolumeState.ShowVolumes))
+	builder.WriteString(fmt.Sprintf("    Mode: %d\n", m.VolumeState.Mode))
+	builder.WriteString(fmt.Sprintf("    Loading: %v\n", m.VolumeState.Loading))
+
+	builder.WriteString("  FirewallState:\n")
+	builder.WriteString(fmt.Sprintf("    ShowFirewalls: %v\n", m.FirewallState.ShowFirewalls))
+	builder.WriteString(fmt.Sprintf("    Mode: %d\n", m.FirewallState.Mode))
+	builder.WriteString(fmt.Sprintf("    Loading: %v\n", m.FirewallState.Loading))
+
+	builder.WriteString("  NetworkState:\n")
+	builder.WriteString(fmt.Sprintf("    ShowNetworks: %v\n", m.NetworkState.ShowNetworks))
+	builder.WriteString(fmt.Sprintf("    Mode: %d\n", m.NetworkState.Mode))
+	builder.WriteString(fmt.Sprintf("    Loading: %v\n", m.NetworkState.Loading))
+
+	builder.WriteString("  IPState:\n")
+	builder.WriteString(fmt.Sprintf("    ShowIPs: %v\n", m.IPState.ShowIPs))
+	builder.WriteString(fmt.Sprintf("    Mode: %d\n", m.IPState.Mode))
+	builder.WriteString(fmt.Sprintf("    Loading: %v\n", m.IPState.Loading))
+
+	builder.WriteString("  ProfileState:\n")
+	builder.WriteString(fmt.Sprintf("    ShowProfiles: %v\n", m.ProfileState.ShowProfiles))
+	builder.WriteString(fmt.Sprintf("    ProfileName: %s\n", m.ProfileName))
+
+	builder.WriteString("  PlacementGroupState:\n")
+	builder.WriteString(fmt.Sprintf("    ShowPlacementGroups: %v\n", m.PlacementGroupState.ShowPlacementGroups))
+	builder.WriteString(fmt.Sprintf("    Mode: %d\n", m.PlacementGroupState.Mode))
+	builder.WriteString(fmt.Sprintf("    Loading: %v\n", m.PlacementGroupState.Loading))
+
+	builder.WriteString("  LoadBalancerState:\n")
+	builder.WriteString(fmt.Sprintf("    ShowLoadBalancers: %v\n", m.LoadBalancerState.ShowLoadBalancers))
+	builder.WriteString(fmt.Sprintf("    Mode: %d\n", m.LoadBalancerState.Mode))
+	builder.WriteString(fmt.Sprintf("    Loading: %v\n", m.LoadBalancerState.Loading))
+	builder.WriteString("  SshKeyState:\n")
+	builder.WriteString(fmt.Sprintf("    ShowSshKeys: %v\n", m.SshKeyState.ShowSshKeys))
+	builder.WriteString(fmt.Sprintf("    Mode: %d\n", m.SshKeyState.Mode))
+	builder.WriteString(fmt.Sprintf("    Loading: %v\n", m.SshKeyState.Loading))
+	builder.WriteString("  AuditState:\n")
+	builder.WriteString(fmt.Sprintf("    ShowAudit: %v\n", m.AuditState.ShowAudit))
+	builder.WriteString(fmt.Sprintf("    Filter: %s\n", m.AuditState.Filter))
+	builder.WriteString("  CostState:\n")
+	builder.WriteString(fmt.Sprintf("    ShowCosts: %v\n", m.CostState.ShowCosts))
+	builder.WriteString(fmt.Sprintf("    GroupBy: %s\n", m.CostState.GroupBy))
+	builder.WriteString(fmt.Sprintf("    Loading: %v\n", m.CostState.Loading))
+
 	builder.WriteString("\n\n")
 	return builder.String()
 
@@ -36,18 +87,44 @@ func (m Model) ViewState() string {
 
 func (m Model) ViewHandleCreateServerState() string {
 
-	if m.CreateServerState.WaitingForServerNameInput {
-		return fmt.Sprintf("Enter Server name:\n\n%s\n\n%s", m.CreateServerState.ServerNameInput.View(), "(esc to quit)")
+	if m.CreateServerState.Step != createStepNone {
+		return m.ViewCreateWizard()
+	}
+
+	if len(m.CreateServerState.Fleet) > 0 {
+		return m.ViewFleet()
 	}
 
 	if m.CreateServerState.CreatingServer {
-		return fmt.Sprintf("\n\n   %s Loading Server creation...press q to quit LiftOff\n\n", m.Spinner.View())
+		if m.CreateServerState.Cancel == nil {
+			return fmt.Sprintf("\n\n   %s Cancelling server creation...\n\n", m.Spinner.View())
+		}
+		return fmt.Sprintf("\n\n   %s Loading Server creation...press x to cancel or q to quit LiftOff\n\n", m.Spinner.View())
 	}
 
 	return ""
 }
 
 func (m Model) View() string {
+	return m.viewBudgetBanner() + m.viewScreen() + m.ViewActivity()
+}
+
+// viewBudgetBanner warns once the run-rate gets close to the budget of the profile
+func (m Model) viewBudgetBanner() string {
+	budget := m.Budget
+	if !budget.Warning() {
+		return ""
+	}
+	text := fmt.Sprintf(" monthly run-rate %s is at %.0f%% of the budget %s of profile %s ",
+		formatMoney(budget.RunRate, budget.Currency), budget.RunRate/budget.Limit*100, formatMoney(budget.Limit, budget.Currency), m.ProfileName)
+	if budget.RunRate >= budget.Limit {
+		return errorStyle.Render(text) + "\n\n"
+	}
+	return warningStyle.Render(text) + "\n\n"
+}
+
+// viewScreen renders the current screen without the activity panel
+func (m Model) viewScreen() string {
 	s := ""
 	if m.EnvValues.Debug {
 		s = m.ViewState()
@@ -57,11 +134,82 @@ func (m Model) View() string {
 		s += state
 		return s
 	}
+	if m.VolumeState.ShowVolumes {
+		return s + m.ViewVolumes()
+	}
+	if m.FirewallState.ShowFirewalls {
+		return s + m.ViewFirewalls()
+	}
+	if m.NetworkState.ShowNetworks {
+		return s + m.ViewNetworks()
+	}
+	if m.IPState.ShowIPs {
+		return s + m.ViewIPs()
+	}
+	if m.ProfileState.ShowProfiles {
+		return s + m.ViewProfiles()
+	}
+	if m.PlacementGroupState.ShowPlacementGroups {
+		return s + m.ViewPlacementGroups()
+	}
+	if m.LoadBalancerState.ShowLoadBalancers {
+		return s + m.ViewLoadBalancers()
+	}
+	if m.SshKeyState.ShowSshKeys {
+		return s + m.ViewSshKeys()
+	}
+	if m.InventoryState.ShowInventory {
+		return s + m.ViewInventory()
+	}
+	if m.AuditState.ShowAudit {
+		return s + m.ViewAudit()
+	}
+	if m.CostState.ShowCosts {
+		return s + m.ViewCosts()
+	}
+	if m.TableState.ShowTable && m.TableState.ShowDetail {
+		return s + m.ViewServerDetail()
+	}
 	if m.TableState.ShowTable {
 		log.Printf("%s", m.TableState.ServerTable.View()+" "+m.TableState.ServerTable.HelpView()+"\n")
+		if m.TableState.LabelMode != labelModeNone {
+			return s + m.TableState.LabelForm.View()
+		}
+		if m.TableState.EditingColumns {
+			return s + m.ViewColumnEditor()
+		}
+		switch m.TableState.BulkMode {
+		case bulkModeFilter, bulkModeChoose, bulkModeLabels, bulkModeProgress:
+			return s + m.ViewBulk()
+		}
+		if m.TableState.Searching {
+			s += " " + m.TableState.SearchInput.View() + "\n"
+		} else if filter := m.filterText(); filter != "" {
+			s += fmt.Sprintf(" %s (%d of %d servers)\n", filter, len(m.TableState.Servers), len(m.TableState.AllServers))
+		}
 		s += baseStyle.Render(m.TableState.ServerTable.View()) + "\n " + m.TableState.ServerTable.HelpView() + "\n"
+		s += " enter details • d delete • p toggle delete/rebuild protection • l edit labels • esc back\n"
+		s += " / search • f filter by status, location, type or labels • o sort by next column • O reverse order • c columns\n"
+		s += " space select • a select all • s select by name or labels • b bulk action"
+		if len(m.TableState.Selected) > 0 {
+			s += fmt.Sprintf(" (%d selected)", len(m.TableState.Selected))
+		}
+		s += "\n"
+		s += m.ViewDeleting()
+		if m.TableState.LoadError != "" {
+			s += "\n " + errorStyle.Render(m.TableState.LoadError) + "\n"
+		}
+		if m.TableState.Backoff > 0 {
+			s += fmt.Sprintf("\n rate limited by hetzner, refreshing every %s\n", m.TableState.Backoff)
+		}
+		if m.TableState.Status != "" {
+			s += "\n " + m.TableState.Status + "\n"
+		}
+		if m.TableState.BulkMode == bulkModeConfirm {
+			s = PlaceOverlay(80, 20, m.ViewBulkConfirm(), s)
+		}
 		if m.TableState.ShowOverlay {
-			s = PlaceOverlay(80, 20, fmt.Sprintf("Delete?\n\nPress 'y' to confirm, 'n' to cancel."), s)
+			s = PlaceOverlay(80, 20, m.ViewDeleteConfirm(), s)
 			// overlay := lipgloss.NewStyle().
 			// 	Border(lipgloss.RoundedBorder()).
 			// 	Padding(1).
@@ -78,7 +226,7 @@ func (m Model) View() string {
 
 	}
 
-	s += "Choose hetzner Action\n\n"
+	s += fmt.Sprintf("Choose hetzner Action (profile %s)\n\n", m.ProfileName)
 
 	for i, choice := range m.ActionSelectionState.Choices {
 		cursor := " "
@@ -92,6 +240,9 @@ func (m Model) View() string {
 		}
 		s += fmt.Sprintf("%s [%s], %s\n", cursor, checked, choice)
 	}
+	if m.CreateServerState.Status != "" {
+		s += "\n" + m.CreateServerState.Status + "\n"
+	}
 	s += "\nPress q to quit.\n"
 	return s
 }
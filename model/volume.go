@@ -0,0 +1,248 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/crabstars/liftoff/hetzner"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+func (m *Model) showVolumes() tea.Cmd {
+	m.VolumeState.ShowVolumes = true
+	m.VolumeState.Mode = volumeModeList
+	m.VolumeState.Loading = true
+	m.loadVolumeTable(nil)
+	return tea.Batch(m.Spinner.Tick, hetzner.LoadVolumes(m.Ctx, m.EnvValues.HetznerApiKey), hetzner.LoadServers(m.Ctx, m.EnvValues.HetznerApiKey))
+}
+
+func (m *Model) loadVolumeTable(volumes []*hcloud.Volume) {
+	columns := []table.Column{
+		{Title: "Name", Width: 25},
+		{Title: "Size", Width: 8},
+		{Title: "Status", Width: 10},
+		{Title: "Location", Width: 10},
+		{Title: "Server", Width: 25},
+		{Title: "Device", Width: 40},
+	}
+
+	rows := make([]table.Row, len(volumes))
+	for i, volume := range volumes {
+		rows[i] = table.Row{volume.Name, fmt.Sprintf("%d GB", volume.Size), string(volume.Status), volume.Location.Name, m.volumeServerName(volume), volume.LinuxDevice}
+	}
+
+	m.VolumeState.Volumes = volumes
+	m.VolumeState.VolumeTable = newStyledTable(columns, rows, m.VolumeState.VolumeTable.Cursor())
+}
+
+func (m Model) volumeServerName(volume *hcloud.Volume) string {
+	if volume.Server == nil {
+		return "-"
+	}
+	for _, server := range m.VolumeState.Servers {
+		if server.ID == volume.Server.ID {
+			return server.Name
+		}
+	}
+	return fmt.Sprintf("%d", volume.Server.ID)
+}
+
+func (m Model) selectedVolume() *hcloud.Volume {
+	index := m.VolumeState.VolumeTable.Cursor()
+	if index < 0 || index >= len(m.VolumeState.Volumes) {
+		return nil
+	}
+	return m.VolumeState.Volumes[index]
+}
+
+// runVolumeAction starts a volume call, the table gets reloaded after the VolumeActionMsg
+func (m *Model) runVolumeAction(cmd tea.Cmd) tea.Cmd {
+	m.VolumeState.Mode = volumeModeList
+	m.VolumeState.Loading = true
+	m.VolumeState.Status = ""
+	return tea.Batch(m.Spinner.Tick, cmd)
+}
+
+func (m Model) updateVolumeState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	state := &m.VolumeState
+	apiKey := m.EnvValues.HetznerApiKey
+
+	switch state.Mode {
+	case volumeModeCreate, volumeModeResize:
+		if msg.Type == tea.KeyEsc {
+			state.Mode = volumeModeList
+			return m, nil
+		}
+		var submitted bool
+		state.Form, cmd, submitted = state.Form.Update(msg)
+		if !submitted {
+			return m, cmd
+		}
+		if state.Mode == volumeModeCreate {
+			volumeOption, err := volumeOptionFromForm(state.Form)
+			if err != nil {
+				state.Form.Err = err.Error()
+				return m, nil
+			}
+			country := state.Form.Value(3)
+			if country != hetzner.CountryGermany && country != hetzner.CountryUSA {
+				state.Form.Err = "location must be germany or us"
+				return m, nil
+			}
+			state.OverBudget = budgetOverride{
+				Operation:   "volume.create",
+				Description: fmt.Sprintf("Creating volume %s", volumeOption.Name),
+				Unchecked:   hetzner.CreateVolume(m.Ctx, apiKey, volumeOption, country, 0),
+			}
+			return m, m.runVolumeAction(hetzner.CreateVolume(m.Ctx, apiKey, volumeOption, country, m.Profile.MonthlyBudget))
+		}
+		volume := m.selectedVolume()
+		size, err := strconv.Atoi(state.Form.Value(0))
+		if err != nil || volume == nil || size <= volume.Size {
+			state.Form.Err = "size must be a number bigger than the current size"
+			return m, nil
+		}
+		state.OverBudget = budgetOverride{
+			Operation:   "volume.resize",
+			Description: fmt.Sprintf("Resizing %s to %d GB", volume.Name, size),
+			Unchecked:   hetzner.ResizeVolume(m.Ctx, apiKey, volume.ID, size, 0),
+		}
+		return m, m.runVolumeAction(hetzner.ResizeVolume(m.Ctx, apiKey, volume.ID, size, m.Profile.MonthlyBudget))
+
+	case volumeModeAttach:
+		if msg.Type == tea.KeyEsc {
+			state.Mode = volumeModeList
+			return m, nil
+		}
+		var selected bool
+		state.ServerChoice, selected = state.ServerChoice.Update(msg.String())
+		volume := m.selectedVolume()
+		if !selected || volume == nil || len(state.AttachServers) == 0 {
+			return m, nil
+		}
+		server := state.AttachServers[state.ServerChoice.Cursor]
+		return m, m.runVolumeAction(hetzner.AttachVolume(m.Ctx, apiKey, volume.ID, server.ID))
+
+	case volumeModeOverBudget:
+		switch msg.String() {
+		case "o":
+			return m, m.runVolumeAction(state.OverBudget.override())
+		case "n", "esc":
+			state.Mode = volumeModeList
+		}
+		return m, nil
+
+	case volumeModeDelete:
+		switch msg.String() {
+		case "y":
+			if volume := m.selectedVolume(); volume != nil {
+				return m, m.runVolumeAction(hetzner.DeleteVolume(m.Ctx, apiKey, volume.ID))
+			}
+			state.Mode = volumeModeList
+		case "n", "esc":
+			state.Mode = volumeModeList
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		state.ShowVolumes = false
+		return m, nil
+	case "c":
+		state.Mode = volumeModeCreate
+		state.Form = newForm("Create volume",
+			formField{Label: "Name"},
+			formField{Label: "Size in GB (min 10)", Value: "10"},
+			formField{Label: "Filesystem (ext4 or xfs)", Value: hetzner.VolumeFormatExt4},
+			formField{Label: "Location (germany or us)", Value: hetzner.CountryGermany},
+		)
+		return m, nil
+	case "a":
+		if volume := m.selectedVolume(); volume != nil {
+			state.Mode = volumeModeAttach
+			state.ServerChoice = newChoiceList("Loading servers...")
+			return m, hetzner.LoadServers(m.Ctx, apiKey)
+		}
+	case "x":
+		if volume := m.selectedVolume(); volume != nil {
+			return m, m.runVolumeAction(hetzner.DetachVolume(m.Ctx, apiKey, volume.ID))
+		}
+	case "r":
+		if volume := m.selectedVolume(); volume != nil {
+			state.Mode = volumeModeResize
+			state.Form = newForm(fmt.Sprintf("Resize %s (currently %d GB)", volume.Name, volume.Size),
+				formField{Label: "New size in GB", Value: strconv.Itoa(volume.Size)},
+			)
+		}
+		return m, nil
+	case "d":
+		if m.selectedVolume() != nil {
+			state.Mode = volumeModeDelete
+		}
+		return m, nil
+	}
+
+	state.VolumeTable, cmd = state.VolumeTable.Update(msg)
+	return m, cmd
+}
+
+// setVolumeServers fills the server choice of the attach mode, only servers in the volume location can be used
+func (m *Model) setVolumeServers(msg hetzner.ServersLoadedMsg) {
+	state := &m.VolumeState
+	if msg.Err != nil {
+		state.Status = fmt.Sprintf("could not load servers: %s", msg.Err)
+		return
+	}
+	state.Servers = msg.Servers
+	if state.Mode != volumeModeAttach {
+		m.loadVolumeTable(state.Volumes)
+		return
+	}
+	volume := m.selectedVolume()
+	if volume == nil {
+		return
+	}
+	var servers []*hcloud.Server
+	var choices []string
+	for _, server := range msg.Servers {
+		if server.Datacenter.Location.Name != volume.Location.Name {
+			continue
+		}
+		servers = append(servers, server)
+		choices = append(choices, server.Name)
+	}
+	state.AttachServers = servers
+	state.ServerChoice = newChoiceList(fmt.Sprintf("Attach %s to server in %s", volume.Name, volume.Location.Name), choices...)
+}
+
+func (m Model) ViewVolumes() string {
+	state := m.VolumeState
+	switch state.Mode {
+	case volumeModeCreate, volumeModeResize:
+		return state.Form.View()
+	case volumeModeAttach:
+		return state.ServerChoice.View()
+	}
+
+	s := baseStyle.Render(state.VolumeTable.View()) + "\n"
+	s += " c create • a attach • x detach • r resize • d delete • esc back\n"
+	if state.Loading {
+		s += fmt.Sprintf("\n %s working...\n", m.Spinner.View())
+	}
+	if state.Status != "" {
+		s += "\n " + state.Status + "\n"
+	}
+	if state.Mode == volumeModeDelete {
+		if volume := m.selectedVolume(); volume != nil {
+			s = PlaceOverlay(80, 5, fmt.Sprintf("Delete volume %s?\n\nPress 'y' to confirm, 'n' to cancel.", volume.Name), s)
+		}
+	}
+	if state.Mode == volumeModeOverBudget {
+		s = state.OverBudget.view(s)
+	}
+	return s
+}
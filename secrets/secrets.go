@@ -0,0 +1,209 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"filippo.io/age"
+	"github.com/zalando/go-keyring"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v2"
+)
+
+// where a token is stored
+const (
+	StoreKeyring = "keyring"
+	StoreFile    = "file"
+)
+
+// EnvPassphrase unlocks the encrypted token file without a prompt, e.g. on headless servers
+const EnvPassphrase = "LIFTOFF_PASSPHRASE"
+
+const service = "liftoff"
+
+var (
+	// the passphrase is asked once per run
+	passphrase string
+	// no prompt while the tui owns the terminal
+	promptDisabled bool
+)
+
+// DisablePrompt makes a locked token file an error instead of asking for the passphrase
+func DisablePrompt() {
+	promptDisabled = true
+}
+
+// SetPassphrase unlocks the token file with a passphrase the tui asked for, it can not prompt on the terminal
+func SetPassphrase(secret string) {
+	passphrase = secret
+}
+
+// Store saves the token of the profile in the system keyring and falls back to the encrypted file
+// when the keyring can not be used. It returns the store which was used
+func Store(profile string, token string) (string, error) {
+	if err := keyring.Set(service, profile, token); err == nil {
+		return StoreKeyring, nil
+	}
+	return StoreFile, Save(StoreFile, profile, token)
+}
+
+// Save saves the token of the profile in the given store
+func Save(store string, profile string, token string) error {
+	switch store {
+	case StoreKeyring:
+		return keyring.Set(service, profile, token)
+	case StoreFile:
+		tokens, err := readFile()
+		if err != nil {
+			return err
+		}
+		tokens[profile] = token
+		return writeFile(tokens)
+	}
+	return fmt.Errorf("unknown token store %s", store)
+}
+
+// Lookup returns the token of the profile from the given store
+func Lookup(store string, profile string) (string, error) {
+	switch store {
+	case StoreKeyring:
+		token, err := keyring.Get(service, profile)
+		if err != nil {
+			return "", fmt.Errorf("token of %s not in the keyring: %w", profile, err)
+		}
+		return token, nil
+	case StoreFile:
+		tokens, err := readFile()
+		if err != nil {
+			return "", err
+		}
+		token, ok := tokens[profile]
+		if !ok {
+			return "", fmt.Errorf("token of %s not in the token file", profile)
+		}
+		return token, nil
+	}
+	return "", fmt.Errorf("unknown token store %s", store)
+}
+
+// Delete removes the token of the profile from the given store
+func Delete(store string, profile string) error {
+	switch store {
+	case StoreKeyring:
+		return keyring.Delete(service, profile)
+	case StoreFile:
+		tokens, err := readFile()
+		if err != nil {
+			return err
+		}
+		delete(tokens, profile)
+		return writeFile(tokens)
+	}
+	return fmt.Errorf("unknown token store %s", store)
+}
+
+// FilePath is the passphrase encrypted token file next to the config
+func FilePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "liftoff", "tokens.age"), nil
+}
+
+// readFile decrypts the token file, a missing file has no tokens
+func readFile() (map[string]string, error) {
+	tokens := make(map[string]string)
+	path, err := FilePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	secret, err := getPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	identity, err := age.NewScryptIdentity(secret)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := age.Decrypt(bytes.NewReader(data), identity)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt %s: %w", path, err)
+	}
+	plain, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(plain, &tokens)
+	return tokens, err
+}
+
+func writeFile(tokens map[string]string) error {
+	path, err := FilePath()
+	if err != nil {
+		return err
+	}
+	secret, err := getPassphrase()
+	if err != nil {
+		return err
+	}
+	recipient, err := age.NewScryptRecipient(secret)
+	if err != nil {
+		return err
+	}
+	plain, err := yaml.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	var encrypted bytes.Buffer
+	writer, err := age.Encrypt(&encrypted, recipient)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(plain); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, encrypted.Bytes(), 0o600)
+}
+
+func getPassphrase() (string, error) {
+	if passphrase != "" {
+		return passphrase, nil
+	}
+	if env := os.Getenv(EnvPassphrase); env != "" {
+		passphrase = env
+		return passphrase, nil
+	}
+	if promptDisabled || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("the token file is locked, set %s", EnvPassphrase)
+	}
+	fmt.Fprint(os.Stderr, "passphrase of the liftoff token file: ")
+	input, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if len(input) == 0 {
+		return "", errors.New("empty passphrase")
+	}
+	passphrase = string(input)
+	return passphrase, nil
+}
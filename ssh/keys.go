@@ -0,0 +1,137 @@
+package sshconnector
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// DefaultKeyPath is offered for generated keys
+const DefaultKeyPath = "~/.ssh/liftoff_ed25519"
+
+// LocalKey is a public key in ~/.ssh, PrivatePath is empty if the private key is not next to it
+type LocalKey struct {
+	Name        string
+	PublicKey   string
+	PublicPath  string
+	PrivatePath string
+	// md5 fingerprint like the one hetzner shows
+	Fingerprint string
+}
+
+// ExpandHome replaces a leading ~ with the home directory
+func ExpandHome(path string) (string, error) {
+	if len(path) == 0 || path[0] != '~' {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// ReadLocalKey parses the public key file and looks for the private key without the .pub suffix
+func ReadLocalKey(publicPath string) (LocalKey, error) {
+	publicPath, err := ExpandHome(publicPath)
+	if err != nil {
+		return LocalKey{}, err
+	}
+	data, err := os.ReadFile(publicPath)
+	if err != nil {
+		return LocalKey{}, err
+	}
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return LocalKey{}, fmt.Errorf("%s is no public key: %w", publicPath, err)
+	}
+	key := LocalKey{
+		Name:        strings.TrimSuffix(filepath.Base(publicPath), ".pub"),
+		PublicKey:   strings.TrimSpace(string(data)),
+		PublicPath:  publicPath,
+		Fingerprint: ssh.FingerprintLegacyMD5(publicKey),
+	}
+	private := strings.TrimSuffix(publicPath, ".pub")
+	if _, err := os.Stat(private); err == nil && private != publicPath {
+		key.PrivatePath = private
+	}
+	return key, nil
+}
+
+// LocalKeys returns the public keys of ~/.ssh which can be parsed
+func LocalKeys() ([]LocalKey, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(home, ".ssh", "*.pub"))
+	if err != nil {
+		return nil, err
+	}
+	var keys []LocalKey
+	for _, path := range paths {
+		key, err := ReadLocalKey(path)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// FindLocalKey returns the local key with the fingerprint of a hetzner ssh key
+func FindLocalKey(keys []LocalKey, fingerprint string) (LocalKey, bool) {
+	for _, key := range keys {
+		if key.Fingerprint == fingerprint {
+			return key, true
+		}
+	}
+	return LocalKey{}, false
+}
+
+// GenerateKey writes a new ed25519 keypair to path and path.pub, existing files are never overwritten
+func GenerateKey(path string, comment string) (LocalKey, error) {
+	path, err := ExpandHome(path)
+	if err != nil {
+		return LocalKey{}, err
+	}
+	for _, file := range []string{path, path + ".pub"} {
+		if _, err := os.Stat(file); !errors.Is(err, fs.ErrNotExist) {
+			return LocalKey{}, fmt.Errorf("%s already exists", file)
+		}
+	}
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return LocalKey{}, err
+	}
+	block, err := ssh.MarshalPrivateKey(privateKey, comment)
+	if err != nil {
+		return LocalKey{}, err
+	}
+	sshPublicKey, err := ssh.NewPublicKey(publicKey)
+	if err != nil {
+		return LocalKey{}, err
+	}
+	authorizedKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPublicKey)))
+	if comment != "" {
+		authorizedKey += " " + comment
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return LocalKey{}, err
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		return LocalKey{}, err
+	}
+	if err := os.WriteFile(path+".pub", []byte(authorizedKey+"\n"), 0o644); err != nil {
+		return LocalKey{}, err
+	}
+	return ReadLocalKey(path + ".pub")
+}
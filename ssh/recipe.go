@@ -0,0 +1,92 @@
+package sshconnector
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Recipe is a list of commands which deploy an app on a server
+type Recipe struct {
+	Name string
+	// set as the liftoff-app label after a deploy, it must be a valid label value
+	App      string
+	Commands []Command
+	// git repo the recipe checks out into Dir, its commit is recorded after a deploy
+	Repo string
+	Dir  string
+}
+
+// peerPattern matches {{peer:NAME}}, it gets replaced by the private ip of the server NAME
+var peerPattern = regexp.MustCompile(`{{peer:([^}]+)}}`)
+
+var ExampleCSharpWeather = Recipe{
+	Name: "ExampleCSharpWeather",
+	App:  exampleApp,
+	Repo: "https://github.com/crabstars/ExampleCSharpWeather.git",
+	Dir:  "/root/ExampleCSharpWeather",
+	Commands: []Command{
+		// {"apt update && apt upgrade -y && apt install git -y", "system updated"},
+		{"git clone https://github.com/crabstars/ExampleCSharpWeather.git", "git repo pulled"},
+		{"cd /root/ExampleCSharpWeather && docker build -t exampledotnet -f dotnet.Dockerfile .", "build docker image done"},
+		{"cd /root/ExampleCSharpWeather && docker run --name " + appContainer + " -p " + AppPort + ":" + AppPort + " -d exampledotnet", "docker container is running"},
+	},
+}
+
+// exampleApp is the app label of both example recipes, a redeploy keeps it
+const exampleApp = "example-csharp-weather"
+
+// appContainer is the name of the container of the example recipes, a redeploy replaces it
+const appContainer = "exampledotnet"
+
+func NewCommand(cmd string, successMessage string) Command {
+	return Command{cmd: cmd, successMessage: successMessage}
+}
+
+// HasPeers is true if a command of the recipe references another server with {{peer:NAME}}
+func (r Recipe) HasPeers() bool {
+	for _, command := range r.Commands {
+		if peerPattern.MatchString(command.cmd) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithPeers replaces all peer references with the private ips of the peers,
+// e.g. "docker run -e DB_HOST={{peer:db}} app" reaches the server db over the private network
+func (r Recipe) WithPeers(peers map[string]string) (Recipe, error) {
+	resolved := r
+	resolved.Commands = make([]Command, len(r.Commands))
+	for i, command := range r.Commands {
+		var missing string
+		cmd := peerPattern.ReplaceAllStringFunc(command.cmd, func(reference string) string {
+			name := peerPattern.FindStringSubmatch(reference)[1]
+			ip, ok := peers[name]
+			if !ok {
+				missing = name
+				return reference
+			}
+			return ip
+		})
+		if missing != "" {
+			return Recipe{}, fmt.Errorf("peer %s has no private ip", missing)
+		}
+		resolved.Commands[i] = Command{cmd: cmd, successMessage: command.successMessage}
+	}
+	return resolved, nil
+}
+
+// ExampleCSharpWeatherRedeploy pulls the latest code of an existing deployment and replaces the running container
+var ExampleCSharpWeatherRedeploy = Recipe{
+	Name: "ExampleCSharpWeather redeploy",
+	App:  exampleApp,
+	Repo: "https://github.com/crabstars/ExampleCSharpWeather.git",
+	Dir:  "/root/ExampleCSharpWeather",
+	Commands: []Command{
+		{"cd /root/ExampleCSharpWeather && git pull", "git repo updated"},
+		{"cd /root/ExampleCSharpWeather && docker build -t exampledotnet -f dotnet.Dockerfile .", "build docker image done"},
+		// the rebuild moved the image tag, so the old container is found by its name or, from deploys before it had one, by the port
+		{"docker rm -f " + appContainer + " >/dev/null 2>&1; docker ps -q --filter publish=" + AppPort + " | xargs -r docker rm -f", "old container removed"},
+		{"cd /root/ExampleCSharpWeather && docker run --name " + appContainer + " -p " + AppPort + ":" + AppPort + " -d exampledotnet", "docker container is running"},
+	},
+}
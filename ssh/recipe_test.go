@@ -0,0 +1,40 @@
+package sshconnector
+
+import "testing"
+
+func TestWithPeers(t *testing.T) {
+	peers := map[string]string{"db": "10.0.0.2", "cache": "10.0.0.3"}
+	tests := []struct {
+		name         string
+		cmd          string
+		wantHasPeers bool
+		want         string
+		wantErr      bool
+	}{
+		{"no reference", "docker run app", false, "docker run app", false},
+		{"one reference", "docker run -e DB_HOST={{peer:db}} app", true, "docker run -e DB_HOST=10.0.0.2 app", false},
+		{"two references", "ping {{peer:db}} && ping {{peer:cache}}", true, "ping 10.0.0.2 && ping 10.0.0.3", false},
+		{"unknown peer", "ping {{peer:queue}}", true, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recipe := Recipe{Name: "test", App: "test", Commands: []Command{NewCommand(tt.cmd, "done")}}
+			if got := recipe.HasPeers(); got != tt.wantHasPeers {
+				t.Errorf("HasPeers() = %v, want %v", got, tt.wantHasPeers)
+			}
+			resolved, err := recipe.WithPeers(peers)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WithPeers() error = %v, want an error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := resolved.Commands[0].cmd; got != tt.want {
+				t.Errorf("WithPeers() command = %q, want %q", got, tt.want)
+			}
+			if resolved.App != recipe.App || recipe.Commands[0].cmd != tt.cmd {
+				t.Errorf("WithPeers() changed the recipe or lost its app")
+			}
+		})
+	}
+}
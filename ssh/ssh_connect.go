@@ -2,14 +2,16 @@ package sshconnector
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"log"
+	"net"
 	"os"
-	"os/user"
-	"path/filepath"
+	"strings"
 	"time"
 
-	"github.com/crabstars/liftoff/logging"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -19,19 +21,28 @@ const sshPort = "22"
 const protocol = "tcp"
 const retryCount = 30
 
+// AppPort is the port the deployed container is exposed on
+const AppPort = "5021"
+
+// KeyPath is the private key of the active profile, SSH_KEY_PATH is used when it is empty
+var KeyPath string
+
+// Output receives the output of remote commands while they run, e.g. the terminal of the cli.
+// The tui owns the terminal, so without Output the output only goes to the log when a command fails
+var Output io.Writer
+
 func getSshClientConfi() (*ssh.ClientConfig, error) {
 
-	ssh_key_path := os.Getenv("SSH_KEY_PATH")
+	ssh_key_path := KeyPath
 	if len(ssh_key_path) == 0 {
-		log.Fatalf("ssh path is empty")
+		ssh_key_path = os.Getenv("SSH_KEY_PATH")
 	}
-	if ssh_key_path[0] == '~' {
-		user, err := user.Current()
-		if err != nil {
-			log.Println("ssh path is empty")
-			return nil, err
-		}
-		ssh_key_path = filepath.Join(user.HomeDir, ssh_key_path[1:])
+	if len(ssh_key_path) == 0 {
+		return nil, errors.New("ssh key path is empty, set ssh_key_path in the profile or SSH_KEY_PATH")
+	}
+	ssh_key_path, err := ExpandHome(ssh_key_path)
+	if err != nil {
+		return nil, err
 	}
 
 	key, err := os.ReadFile(
@@ -58,8 +69,8 @@ func getSshClientConfi() (*ssh.ClientConfig, error) {
 	}, nil
 }
 
-// Caller needs to call defer client.Close()
-func EstablishSshConnection(serverIP string) (*ssh.Client, error) {
+// Caller needs to call defer client.Close(), the retries stop when ctx is done
+func EstablishSshConnection(ctx context.Context, serverIP string) (*ssh.Client, error) {
 	config, err := getSshClientConfi()
 	if err != nil {
 		return nil, err
@@ -68,14 +79,18 @@ func EstablishSshConnection(serverIP string) (*ssh.Client, error) {
 	addr := serverIP + ":" + sshPort
 	for i := 0; i < retryCount; i++ {
 
-		client, err = ssh.Dial(protocol, addr, config)
+		client, err = dial(ctx, addr, config)
 
 		log.Println("Trying to establish ssh connection...")
-		if err != nil {
-			time.Sleep(1 * time.Second)
-		} else {
+		if err == nil {
 			break
 		}
+		select {
+		case <-ctx.Done():
+			log.Println("ssh connection cancelled", ctx.Err())
+			return nil, ctx.Err()
+		case <-time.After(1 * time.Second):
+		}
 	}
 	if err != nil {
 		log.Println("Dial failed to create client ", err)
@@ -87,7 +102,25 @@ func EstablishSshConnection(serverIP string) (*ssh.Client, error) {
 
 }
 
-func ExecuteCommand(client *ssh.Client, command Command) error {
+// dial is ssh.Dial with a context, the handshake gets aborted by closing the connection
+func dial(ctx context.Context, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	dialer := net.Dialer{Timeout: config.Timeout}
+	conn, err := dialer.DialContext(ctx, protocol, addr)
+	if err != nil {
+		return nil, err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
+
+// ExecuteCommand runs the command in a new session, a cancelled ctx kills the remote command
+func ExecuteCommand(ctx context.Context, client *ssh.Client, command Command) error {
 
 	session, err := client.NewSession()
 	if err != nil {
@@ -95,9 +128,24 @@ func ExecuteCommand(client *ssh.Client, command Command) error {
 	}
 	defer session.Close()
 	var outputBuffer bytes.Buffer
-	session.Stdout = io.MultiWriter(&outputBuffer, logging.LogWriter{})
-	session.Stderr = io.MultiWriter(&outputBuffer, logging.LogWriter{})
+	var output io.Writer = &outputBuffer
+	if Output != nil {
+		output = io.MultiWriter(&outputBuffer, Output)
+	}
+	session.Stdout = output
+	session.Stderr = output
+	stop := context.AfterFunc(ctx, func() {
+		session.Signal(ssh.SIGKILL)
+		session.Close()
+	})
+	defer stop()
 	if err := session.Run(command.cmd); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if Output == nil {
+			log.Printf("output of the failed command %q:\n%s", command.cmd, outputBuffer.String())
+		}
 		return err
 	}
 	log.Println(command.successMessage)
@@ -110,28 +158,56 @@ type Command struct {
 	successMessage string
 }
 
-func RunCommandsOnServer(serverIP string, commands []Command) error {
+// RunCommandsOnServer runs the commands one after another, without commands the example recipe gets deployed.
+// The error tells how many commands finished, so a cancelled deploy shows what is left on the server
+func RunCommandsOnServer(ctx context.Context, serverIP string, commands []Command) error {
 
-	commands = []Command{
-		// {"apt update && apt upgrade -y && apt install git -y", "system updated"},
-		{"git clone https://github.com/crabstars/ExampleCSharpWeather.git", "git repo pulled"},
-		{"cd /root/ExampleCSharpWeather && docker build -t exampledotnet -f dotnet.Dockerfile .", "build docker image done"},
-		{"cd /root/ExampleCSharpWeather && docker run -p 5021:5021 -d exampledotnet", "docker container is running"},
+	if len(commands) == 0 {
+		commands = ExampleCSharpWeather.Commands
 	}
-	client, err := EstablishSshConnection(serverIP)
+	client, err := EstablishSshConnection(ctx, serverIP)
 	if err != nil {
 		return err
 	}
 	defer client.Close()
+	return runCommands(ctx, client, commands)
+}
 
-	for _, command := range commands {
+func runCommands(ctx context.Context, client *ssh.Client, commands []Command) error {
+	for i, command := range commands {
 
-		err = ExecuteCommand(client, command)
+		err := ExecuteCommand(ctx, client, command)
 		if err != nil {
-			return err
+			return fmt.Errorf("%w after %d of %d commands", err, i, len(commands))
 		}
 	}
 
 	log.Println("finished starting api")
 	return nil
 }
+
+// Deploy runs the recipe and returns the checked out commit, it is empty if the recipe has no repo
+func (r Recipe) Deploy(ctx context.Context, serverIP string) (string, error) {
+	client, err := EstablishSshConnection(ctx, serverIP)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+	if err := runCommands(ctx, client, r.Commands); err != nil {
+		return "", err
+	}
+	if r.Dir == "" {
+		return "", nil
+	}
+	session, err := client.NewSession()
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+	output, err := session.Output("git -C " + r.Dir + " rev-parse HEAD")
+	if err != nil {
+		log.Println("could not read the deployed commit", err)
+		return "", nil
+	}
+	return strings.TrimSpace(string(output)), nil
+}